@@ -0,0 +1,173 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/database"
+)
+
+// adsFileTypePattern restricts the ads file type as it is used as a part of the storage key
+var adsFileTypePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// AdsCodeFileName is a function to turn the given ads code into a file name safe string, e.g. K/I-ADS/2021-J/123 into K-I-ADS-2021-J-123
+func AdsCodeFileName(adsCode string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(adsCode)
+}
+
+// tempFileObject wraps a temporary file so it can be served like a stored object
+type tempFileObject struct {
+	*os.File
+}
+
+// Close closes the temporary file and removes it as it is only used once
+func (object *tempFileObject) Close() error {
+
+	err := object.File.Close()
+	os.Remove(object.File.Name())
+
+	return err
+}
+
+// adsFileReadError maps the error of reading the uploaded ads file, the invalid base64 file and the oversized body
+// are reported to the client while the storage errors are kept as internal errors
+func adsFileReadError(err error) error {
+
+	var corruptErr base64.CorruptInputError
+	if errors.As(err, &corruptErr) {
+		return WrapError(ErrCodeValidation, err, "File iklan tidak valid")
+	}
+
+	if strings.Contains(err.Error(), "request body too large") {
+		return RequestBodyError(err)
+	}
+
+	return err
+}
+
+// StoreAdsFile will store the given ads file content as a binary object and fill the metadata of the given ads file
+func (kost *Kost) StoreAdsFile(adsFile *database.DBKostAdsFiles, index int, src io.Reader) error {
+
+	if !adsFileTypePattern.MatchString(adsFile.AdsFileType) {
+		return NewError(ErrCodeValidation, "Tipe file iklan tidak valid")
+	}
+
+	// sniff the content type from the first bytes of the file instead of trusting the client
+	buffered := bufio.NewReaderSize(src, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return adsFileReadError(err)
+	}
+
+	contentType := http.DetectContentType(head)
+
+	// determine the file extension based on the sniffed content type
+	extension := ".bin"
+	extensions, _ := mime.ExtensionsByType(contentType)
+	if len(extensions) > 0 {
+		extension = extensions[0]
+	}
+
+	key := fmt.Sprintf("ads-%d/%s/%d%s", adsFile.AdsID, adsFile.AdsFileType, index, extension)
+	info, err := kost.storage.Put(key, buffered)
+	if err != nil {
+		return adsFileReadError(err)
+	}
+
+	if adsFile.AdsFileName == "" {
+		adsFile.AdsFileName = fmt.Sprintf("%d%s", index, extension)
+	}
+
+	adsFile.ContentType = contentType
+	adsFile.FileSize = info.Size
+	adsFile.Checksum = info.Checksum
+	adsFile.StorageKey = info.Key
+
+	return nil
+}
+
+// DecodeBase64AdsFile returns a reader decoding the given base64 string, the data url prefix is ignored if any
+func (kost *Kost) DecodeBase64AdsFile(base64String string) io.Reader {
+
+	// strip the data url prefix, e.g. data:image/png;base64,
+	if strings.HasPrefix(base64String, "data:") {
+		if index := strings.Index(base64String, ","); index != -1 {
+			base64String = base64String[index+1:]
+		}
+	}
+
+	return base64.NewDecoder(base64.StdEncoding, strings.NewReader(base64String))
+}
+
+// OpenAdsFile will open the given ads file content for reading
+func (kost *Kost) OpenAdsFile(adsFile *database.DBKostAdsFiles) (StorageObject, time.Time, error) {
+
+	// the binary objects are opened from the storage
+	if adsFile.StorageKey != "" {
+		object, info, err := kost.storage.Open(adsFile.StorageKey)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		return object, info.Modified, nil
+	}
+
+	// the legacy ads files are base64 text files, decode them into a temporary file
+	// so the file is never read into memory and can still be seeked when it is served
+	legacyFile, err := os.Open(adsFile.AdsDirPath)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer legacyFile.Close()
+
+	legacyReader := bufio.NewReader(legacyFile)
+
+	// strip the data url prefix, e.g. data:image/png;base64,
+	head, _ := legacyReader.Peek(256)
+	if bytes.HasPrefix(head, []byte("data:")) {
+		if index := bytes.IndexByte(head, ','); index != -1 {
+			legacyReader.Discard(index + 1)
+		}
+	}
+
+	tempFile, err := ioutil.TempFile("", "kost-ads-")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	object := &tempFileObject{tempFile}
+	if _, err = io.Copy(tempFile, base64.NewDecoder(base64.StdEncoding, legacyReader)); err != nil {
+		object.Close()
+
+		return nil, time.Time{}, err
+	}
+
+	if _, err = tempFile.Seek(0, io.SeekStart); err != nil {
+		object.Close()
+
+		return nil, time.Time{}, err
+	}
+
+	return object, adsFile.Modified, nil
+}
+
+// DeleteAdsFiles will remove the stored binary objects of the given storage keys
+func (kost *Kost) DeleteAdsFiles(storageKeys []string) {
+
+	for _, key := range storageKeys {
+		if err := kost.storage.Delete(key); err != nil {
+			kost.logger.Error("Unable to delete the stored ads file", "key", key, "error", err.Error())
+		}
+	}
+}
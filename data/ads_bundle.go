@@ -0,0 +1,164 @@
+package data
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+// AdsBundle holds everything written to the downloadable zip bundle of an ads
+type AdsBundle struct {
+	Ads      database.DBKostAds
+	Files    []database.DBKostAdsFiles
+	Metadata entities.AdsBundleMetadata
+	Caption  string
+}
+
+// GetAdsBundle is a function to collect the content of the zip bundle of the given ads,
+// it is done before anything is streamed so the errors can still be reported to the client
+func (kost *Kost) GetAdsBundle(adsID uint) (*AdsBundle, error) {
+
+	bundle := &AdsBundle{}
+
+	// look for the target ads in the db
+	if err := config.DB.Where("id = ?", adsID).First(&bundle.Ads).Error; err != nil {
+		return nil, err
+	}
+
+	// look for the active files of the ads
+	if err := config.DB.Where("ads_id = ? AND is_active = ?", adsID, true).Order("id").Find(&bundle.Files).Error; err != nil {
+		return nil, err
+	}
+
+	// the caption is rendered for the channel of the ads package
+	captions, err := kost.GetAdsCaptions(adsID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	bundle.Caption = captions[0].Caption
+
+	adsPackage, err := kost.GetAdsPackageByAds(config.DB, adsID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle.Metadata = entities.AdsBundleMetadata{
+		Ads:         bundle.Ads,
+		AdsStatus:   AdsStatusNames[bundle.Ads.Status],
+		AdsPackage:  *adsPackage,
+		GeneratedAt: time.Now().Local(),
+	}
+
+	for index, adsFile := range bundle.Files {
+		bundle.Metadata.Files = append(bundle.Metadata.Files, entities.AdsBundleFile{
+			ID:          adsFile.ID,
+			AdsFileType: adsFile.AdsFileType,
+			AdsFileName: adsFile.AdsFileName,
+			ContentType: adsFile.ContentType,
+			FileSize:    adsFile.FileSize,
+			Checksum:    adsFile.Checksum,
+			Path:        adsBundleFilePath(index, &adsFile),
+		})
+	}
+
+	return bundle, nil
+}
+
+// adsBundleFilePath gets the path of the given ads file inside the zip bundle, the index prefix keeps the paths unique
+func adsBundleFilePath(index int, adsFile *database.DBKostAdsFiles) string {
+
+	fileName := path.Base(strings.Replace(adsFile.AdsFileName, "\\", "/", -1))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		fileName = strconv.FormatUint(uint64(adsFile.ID), 10)
+	}
+
+	// the legacy file types were never validated, so they are only used as a folder name when they are safe
+	fileType := adsFile.AdsFileType
+	if !adsFileTypePattern.MatchString(fileType) {
+		fileType = "other"
+	}
+
+	return fmt.Sprintf("media/%s/%02d-%s", fileType, index+1, fileName)
+}
+
+// WriteAdsBundle is a function to stream the zip bundle of the given ads to the given writer,
+// every file is copied from the storage one at a time so the whole archive is never buffered in memory
+func (kost *Kost) WriteAdsBundle(w io.Writer, bundle *AdsBundle) error {
+
+	archive := zip.NewWriter(w)
+
+	for index := range bundle.Files {
+
+		adsFile := &bundle.Files[index]
+		if err := kost.writeAdsBundleFile(archive, adsBundleFilePath(index, adsFile), adsFile); err != nil {
+			return err
+		}
+	}
+
+	// add the ready to post caption
+	captionWriter, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     "caption.txt",
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.WriteString(captionWriter, bundle.Caption); err != nil {
+		return err
+	}
+
+	// add the ads metadata
+	metadataWriter, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     "metadata.json",
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(metadataWriter)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(bundle.Metadata); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// writeAdsBundleFile copies the given ads file content into the zip archive
+func (kost *Kost) writeAdsBundleFile(archive *zip.Writer, filePath string, adsFile *database.DBKostAdsFiles) error {
+
+	object, modified, err := kost.OpenAdsFile(adsFile)
+	if err != nil {
+		return err
+	}
+
+	defer object.Close()
+
+	// the media files are mostly compressed already, so they are stored as is
+	fileWriter, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     filePath,
+		Method:   zip.Store,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fileWriter, object)
+
+	return err
+}
@@ -0,0 +1,340 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+// AdsCaptionLimit is the caption character limit of every channel, it is overridden by the app configuration
+var AdsCaptionLimit = map[string]int{
+	AdsChannelIGFeed:  2200,
+	AdsChannelIGStory: 500,
+	AdsChannelTiktok:  2200,
+}
+
+// AdsCaptionMaxHashtags is the hashtag limit of every channel, zero means unlimited
+var AdsCaptionMaxHashtags = map[string]int{
+	AdsChannelIGFeed:  30,
+	AdsChannelIGStory: 10,
+	AdsChannelTiktok:  0,
+}
+
+// defaultAdsCaptionTemplates are the caption templates used when the app configuration doesn't override them
+var defaultAdsCaptionTemplates = map[string]string{
+	AdsChannelIGFeed: `🏠 {{.KostType}}{{if .Gender}} {{.Gender}}{{end}} di {{.City}}
+
+💰 Harga: {{.Price}}
+📍 Alamat: {{.Address}}
+🐾 {{.PetAllowedDesc}}
+{{if .Desc}}
+{{.Desc}}
+{{end}}
+📲 Info & booking: {{.WhatsappLink}}{{if .OwnerIG}}
+📷 IG: @{{.OwnerIG}}{{end}}
+
+{{.Hashtags}}`,
+	AdsChannelIGStory: `{{.KostType}}{{if .Gender}} {{.Gender}}{{end}} {{.City}} - {{.Price}}
+{{.PetAllowedDesc}}
+{{if .LinkSwipeUp}}Swipe up: {{.LinkSwipeUp}}{{else}}Info: {{.WhatsappLink}}{{end}}
+{{.Hashtags}}`,
+	AdsChannelTiktok: `{{.KostType}}{{if .Gender}} {{.Gender}}{{end}} di {{.City}} mulai {{.Price}}! {{.PetAllowedDesc}}.
+{{if .Desc}}{{.Desc}}
+{{end}}Info: {{.WhatsappLink}}{{if .IgBioLink}} / {{.IgBioLink}}{{end}}
+{{.Hashtags}}`,
+}
+
+// adsCaptionTemplates are the parsed caption templates of every channel, the default ones are used until the app configuration is loaded
+var adsCaptionTemplates = mustParseAdsCaptionTemplates(defaultAdsCaptionTemplates)
+
+// nonHashtagPattern matches every character that can't be a part of a hashtag
+var nonHashtagPattern = regexp.MustCompile(`[^\p{L}\p{N}_]`)
+
+// adsCaptionData is the data given to the caption templates
+type adsCaptionData struct {
+	Code           string
+	Owner          string
+	OwnerIG        string
+	KostType       string
+	City           string
+	Address        string
+	Price          string
+	Gender         string
+	PetAllowed     bool
+	PetAllowedDesc string
+	Desc           string
+	WhatsappLink   string
+	LinkSwipeUp    string
+	IgBioLink      string
+	Hashtags       string
+}
+
+// parseAdsCaptionTemplates parses the caption templates of every channel, the given templates override the default ones per channel
+func parseAdsCaptionTemplates(overrides map[string]string) (map[string]*template.Template, error) {
+
+	parsedTemplates := map[string]*template.Template{}
+	for _, channel := range AdsChannels {
+
+		text := defaultAdsCaptionTemplates[channel]
+		if override := strings.TrimSpace(overrides[channel]); override != "" {
+			text = override
+		}
+
+		parsedTemplate, err := template.New(channel).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s caption template: %s", channel, err.Error())
+		}
+
+		parsedTemplates[channel] = parsedTemplate
+	}
+
+	return parsedTemplates, nil
+}
+
+// mustParseAdsCaptionTemplates parses the given caption templates and panics if any of them is invalid
+func mustParseAdsCaptionTemplates(overrides map[string]string) map[string]*template.Template {
+
+	parsedTemplates, err := parseAdsCaptionTemplates(overrides)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsedTemplates
+}
+
+// SetAdsCaptionTemplates is a function to set the caption templates, the given templates override the default ones per channel
+func SetAdsCaptionTemplates(overrides map[string]string) error {
+
+	parsedTemplates, err := parseAdsCaptionTemplates(overrides)
+	if err != nil {
+		return err
+	}
+
+	adsCaptionTemplates = parsedTemplates
+
+	return nil
+}
+
+// NormalizeAdsHashtags is a function to normalize the given hashtag string into a deduplicated hashtag list,
+// the hashtags may be separated by spaces, commas or the hash sign itself
+func NormalizeAdsHashtags(hashtagString string) []string {
+
+	var hashtags []string
+	var seen = make(map[string]bool)
+
+	words := strings.FieldsFunc(hashtagString, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';' || r == '#'
+	})
+
+	for _, word := range words {
+		tag := strings.ToLower(nonHashtagPattern.ReplaceAllString(word, ""))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		hashtags = append(hashtags, "#"+tag)
+	}
+
+	return hashtags
+}
+
+// FormatWhatsappLink is a function to build the wa.me link of the given phone number, the local 0 prefix is replaced by the 62 country code
+func FormatWhatsappLink(phoneNumber string) string {
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, phoneNumber)
+
+	if digits == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(digits, "0") {
+		digits = "62" + digits[1:]
+	}
+
+	return "https://wa.me/" + digits
+}
+
+// FormatAdsPrice is a function to format the given ads price as rupiah, the non numeric price is returned as is
+func FormatAdsPrice(price string) string {
+
+	price = strings.TrimSpace(price)
+	for _, r := range price {
+		if r < '0' || r > '9' {
+			return price
+		}
+	}
+
+	if price == "" {
+		return price
+	}
+
+	// group the digits by thousand with the indonesian separator
+	var grouped []string
+	for len(price) > 3 {
+		grouped = append([]string{price[len(price)-3:]}, grouped...)
+		price = price[:len(price)-3]
+	}
+
+	grouped = append([]string{price}, grouped...)
+
+	return "Rp" + strings.Join(grouped, ".")
+}
+
+// isAdsPetAllowed checks whether the given pet allowed value means yes
+func isAdsPetAllowed(petAllowed string) bool {
+
+	switch strings.ToLower(strings.TrimSpace(petAllowed)) {
+	case "1", "true", "yes", "y", "ya", "boleh":
+		return true
+	}
+
+	return false
+}
+
+// RenderAdsCaption is a function to render the caption of the given ads for the given channel,
+// the caption is shortened to the channel character limit by dropping hashtags and then the description
+func (kost *Kost) RenderAdsCaption(targetAds *database.DBKostAds, channel string) (*entities.AdsCaption, error) {
+
+	captionTemplate, ok := adsCaptionTemplates[channel]
+	if !ok {
+		return nil, NewError(ErrCodeValidation, "Channel iklan %s tidak valid", channel)
+	}
+
+	hashtags := NormalizeAdsHashtags(targetAds.AdsHashtag)
+	if maxHashtags := AdsCaptionMaxHashtags[channel]; maxHashtags > 0 && len(hashtags) > maxHashtags {
+		hashtags = hashtags[:maxHashtags]
+	}
+
+	captionData := &adsCaptionData{
+		Code:         targetAds.AdsCode,
+		Owner:        strings.TrimSpace(targetAds.AdsOwner),
+		OwnerIG:      strings.TrimPrefix(strings.TrimSpace(targetAds.AdsOwnerIG), "@"),
+		KostType:     strings.TrimSpace(targetAds.AdsKostType),
+		City:         strings.TrimSpace(targetAds.AdsPropertyCity),
+		Address:      strings.TrimSpace(targetAds.AdsPropertyAddress),
+		Price:        FormatAdsPrice(targetAds.AdsPropertyPrice),
+		Gender:       strings.TrimSpace(targetAds.AdsGender),
+		PetAllowed:   isAdsPetAllowed(targetAds.AdsPetAllowed),
+		Desc:         strings.TrimSpace(targetAds.AdsDesc),
+		WhatsappLink: FormatWhatsappLink(targetAds.AdsPICWhatsapp),
+		LinkSwipeUp:  strings.TrimSpace(targetAds.AdsLinkSwipeUp),
+		IgBioLink:    strings.TrimSpace(targetAds.AdsIgBioLink),
+		Hashtags:     strings.Join(hashtags, " "),
+	}
+
+	if captionData.PetAllowed {
+		captionData.PetAllowedDesc = "Boleh bawa hewan peliharaan"
+	} else {
+		captionData.PetAllowedDesc = "Tidak boleh bawa hewan peliharaan"
+	}
+
+	render := func() (string, error) {
+		var buffer bytes.Buffer
+		if err := captionTemplate.Execute(&buffer, captionData); err != nil {
+			return "", err
+		}
+
+		return strings.TrimSpace(buffer.String()), nil
+	}
+
+	limit := AdsCaptionLimit[channel]
+	caption, err := render()
+	if err != nil {
+		return nil, err
+	}
+
+	truncated := false
+
+	// drop the hashtags from the last one until the caption fits
+	for limit > 0 && utf8.RuneCountInString(caption) > limit && len(hashtags) > 0 {
+		truncated = true
+		hashtags = hashtags[:len(hashtags)-1]
+		captionData.Hashtags = strings.Join(hashtags, " ")
+
+		if caption, err = render(); err != nil {
+			return nil, err
+		}
+	}
+
+	// shorten the description by the exceeding characters, the ellipsis takes one character
+	if over := utf8.RuneCountInString(caption) - limit; limit > 0 && over > 0 && captionData.Desc != "" {
+		truncated = true
+		descRunes := []rune(captionData.Desc)
+		if over+1 < len(descRunes) {
+			captionData.Desc = strings.TrimSpace(string(descRunes[:len(descRunes)-over-1])) + "…"
+		} else {
+			captionData.Desc = ""
+		}
+
+		if caption, err = render(); err != nil {
+			return nil, err
+		}
+	}
+
+	// cut the caption itself as the last resort
+	if captionRunes := []rune(caption); limit > 0 && len(captionRunes) > limit {
+		truncated = true
+		caption = string(captionRunes[:limit-1]) + "…"
+	}
+
+	return &entities.AdsCaption{
+		AdsID:     targetAds.ID,
+		AdsCode:   targetAds.AdsCode,
+		Channel:   channel,
+		Caption:   caption,
+		Length:    utf8.RuneCountInString(caption),
+		Limit:     limit,
+		Truncated: truncated,
+	}, nil
+}
+
+// GetAdsCaptions is a function to render the captions of the given ads, the ads package channel is used if no channel is given
+func (kost *Kost) GetAdsCaptions(adsID uint, channel string) ([]entities.AdsCaption, error) {
+
+	// look for the target ads in the db
+	var targetAds database.DBKostAds
+	if err := config.DB.Where("id = ?", adsID).First(&targetAds).Error; err != nil {
+		return nil, err
+	}
+
+	var channels []string
+	if channel == "all" {
+		channels = AdsChannels
+	} else if channel != "" {
+		channels = []string{channel}
+	} else {
+		adsPackage, err := kost.GetAdsPackageByAds(config.DB, adsID)
+		if err != nil {
+			return nil, err
+		}
+
+		channels = []string{adsPackage.Channel}
+	}
+
+	var captions []entities.AdsCaption
+	for _, captionChannel := range channels {
+		caption, err := kost.RenderAdsCaption(&targetAds, captionChannel)
+		if err != nil {
+			return nil, err
+		}
+
+		captions = append(captions, *caption)
+	}
+
+	return captions, nil
+}
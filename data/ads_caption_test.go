@@ -0,0 +1,218 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+func TestFormatAdsPrice(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		price string
+		want  string
+	}{
+		{name: "hundreds", price: "500", want: "Rp500"},
+		{name: "thousands", price: "1000", want: "Rp1.000"},
+		{name: "millions", price: "1500000", want: "Rp1.500.000"},
+		{name: "spaces are trimmed", price: " 2500000 ", want: "Rp2.500.000"},
+		{name: "already formatted", price: "1.500.000", want: "1.500.000"},
+		{name: "non numeric", price: "nego", want: "nego"},
+		{name: "empty", price: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := FormatAdsPrice(test.price); got != test.want {
+				t.Errorf("FormatAdsPrice(%q) = %q, want %q", test.price, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAdsHashtags(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		hashtags string
+		want     []string
+	}{
+		{name: "hash separated", hashtags: "#kost#jakarta", want: []string{"#kost", "#jakarta"}},
+		{name: "mixed separators", hashtags: "#Kost, kostJakarta; #anak_kost", want: []string{"#kost", "#kostjakarta", "#anak_kost"}},
+		{name: "invalid characters are dropped", hashtags: "kost-murah #kost.putri!", want: []string{"#kostmurah", "#kostputri"}},
+		{name: "duplicates are dropped", hashtags: "#kost #KOST kost", want: []string{"#kost"}},
+		{name: "empty", hashtags: "", want: nil},
+		{name: "only separators", hashtags: "# ## !!!, ;", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeAdsHashtags(test.hashtags); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("NormalizeAdsHashtags(%q) = %q, want %q", test.hashtags, got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatWhatsappLink(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		phoneNumber string
+		want        string
+	}{
+		{name: "local prefix", phoneNumber: "081234567890", want: "https://wa.me/6281234567890"},
+		{name: "formatted local number", phoneNumber: "0812-3456-7890", want: "https://wa.me/6281234567890"},
+		{name: "country code", phoneNumber: "+62 812 3456 7890", want: "https://wa.me/6281234567890"},
+		{name: "no digits", phoneNumber: "n/a", want: ""},
+		{name: "empty", phoneNumber: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := FormatWhatsappLink(test.phoneNumber); got != test.want {
+				t.Errorf("FormatWhatsappLink(%q) = %q, want %q", test.phoneNumber, got, test.want)
+			}
+		})
+	}
+}
+
+// captionTestAds is the ads the caption tests are rendered from
+func captionTestAds(hashtags string) *database.DBKostAds {
+	return &database.DBKostAds{
+		AdsCode:            "ADS-1",
+		AdsKostType:        "Kost",
+		AdsGender:          "Putri",
+		AdsPropertyCity:    "Jakarta",
+		AdsPropertyAddress: "Jl. Melati No. 1",
+		AdsPropertyPrice:   "1500000",
+		AdsPetAllowed:      "ya",
+		AdsDesc:            "Dekat stasiun, kamar mandi dalam, wifi cepat dan dapur bersama.",
+		AdsPICWhatsapp:     "081234567890",
+		AdsHashtag:         hashtags,
+	}
+}
+
+// renderTestCaption renders the ig feed caption of the given ads with the given character limit
+func renderTestCaption(t *testing.T, targetAds *database.DBKostAds, limit int) *entities.AdsCaption {
+
+	previousLimit := AdsCaptionLimit[AdsChannelIGFeed]
+	AdsCaptionLimit[AdsChannelIGFeed] = limit
+	defer func() { AdsCaptionLimit[AdsChannelIGFeed] = previousLimit }()
+
+	caption, err := (&Kost{}).RenderAdsCaption(targetAds, AdsChannelIGFeed)
+	if err != nil {
+		t.Fatalf("RenderAdsCaption() error = %v", err)
+	}
+
+	return caption
+}
+
+func TestRenderAdsCaptionTruncation(t *testing.T) {
+
+	const hashtags = "#kost #jakarta #kostputri"
+
+	// the lengths of the captions that are never truncated, the emojis of the template count as a single character
+	fullLength := renderTestCaption(t, captionTestAds(hashtags), 0).Length
+	noHashtagLength := renderTestCaption(t, captionTestAds(""), 0).Length
+
+	tests := []struct {
+		name          string
+		limit         int
+		wantTruncated bool
+		wantLength    int // zero means at most the limit
+		wantContains  []string
+		wantMissing   []string
+		wantSuffix    string
+	}{
+		{
+			name:         "exactly the limit",
+			limit:        fullLength,
+			wantLength:   fullLength,
+			wantContains: []string{"#kostputri", "wifi cepat dan dapur bersama."},
+		},
+		{
+			name:          "a character over drops the last hashtag",
+			limit:         fullLength - 1,
+			wantTruncated: true,
+			wantContains:  []string{"#kost #jakarta", "wifi cepat dan dapur bersama."},
+			wantMissing:   []string{"#kostputri"},
+		},
+		{
+			name:          "the description is shortened once every hashtag is dropped",
+			limit:         noHashtagLength - 5,
+			wantTruncated: true,
+			wantLength:    noHashtagLength - 5,
+			wantContains:  []string{"Dekat stasiun", "…"},
+			wantMissing:   []string{"#kost", "dapur bersama."},
+		},
+		{
+			name:          "the caption is cut when the description isn't enough",
+			limit:         10,
+			wantTruncated: true,
+			wantLength:    10,
+			wantSuffix:    "…",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			caption := renderTestCaption(t, captionTestAds(hashtags), test.limit)
+			if caption.Truncated != test.wantTruncated {
+				t.Errorf("RenderAdsCaption().Truncated = %v, want %v", caption.Truncated, test.wantTruncated)
+			}
+
+			length := utf8.RuneCountInString(caption.Caption)
+			if caption.Length != length {
+				t.Errorf("RenderAdsCaption().Length = %d, want %d", caption.Length, length)
+			}
+
+			if length > test.limit || (test.wantLength > 0 && length != test.wantLength) {
+				t.Errorf("RenderAdsCaption() length = %d, want %d within the limit %d", length, test.wantLength, test.limit)
+			}
+
+			for _, want := range test.wantContains {
+				if !strings.Contains(caption.Caption, want) {
+					t.Errorf("RenderAdsCaption() = %q, want it to contain %q", caption.Caption, want)
+				}
+			}
+
+			for _, missing := range test.wantMissing {
+				if strings.Contains(caption.Caption, missing) {
+					t.Errorf("RenderAdsCaption() = %q, want it without %q", caption.Caption, missing)
+				}
+			}
+
+			if !strings.HasSuffix(caption.Caption, test.wantSuffix) {
+				t.Errorf("RenderAdsCaption() = %q, want the suffix %q", caption.Caption, test.wantSuffix)
+			}
+		})
+	}
+}
+
+func TestRenderAdsCaptionMaxHashtags(t *testing.T) {
+
+	// more hashtags than the ig story allows
+	hashtags := "#a #b #c #d #e #f #g #h #i #j #k #l #m #n #o"
+
+	caption, err := (&Kost{}).RenderAdsCaption(captionTestAds(hashtags), AdsChannelIGStory)
+	if err != nil {
+		t.Fatalf("RenderAdsCaption() error = %v", err)
+	}
+
+	if got := strings.Count(caption.Caption, "#"); got != AdsCaptionMaxHashtags[AdsChannelIGStory] {
+		t.Errorf("RenderAdsCaption() hashtags = %d, want %d", got, AdsCaptionMaxHashtags[AdsChannelIGStory])
+	}
+}
+
+func TestRenderAdsCaptionInvalidChannel(t *testing.T) {
+	if _, err := (&Kost{}).RenderAdsCaption(captionTestAds(""), "facebook"); err == nil {
+		t.Errorf("RenderAdsCaption() error = nil, want an error")
+	}
+}
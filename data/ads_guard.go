@@ -0,0 +1,211 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+)
+
+// AdsSubmission is the abuse protection settings of the ads submission, it is overridden by the app configuration
+var AdsSubmission = entities.AdsSubmissionConfiguration{
+	MaxRequestSizeMB:     50,
+	MaxFiles:             10,
+	PerIPHourly:          5,
+	PerPhoneDaily:        3,
+	PowDifficulty:        18,
+	DuplicateWindowHours: 24,
+}
+
+// adsChallengeTTL is how long a proof of work challenge can be solved and used
+const adsChallengeTTL = 10 * time.Minute
+
+// phoneNumberPattern matches the normalized indonesian mobile phone number
+var phoneNumberPattern = regexp.MustCompile(`^08[1-9][0-9]{7,10}$`)
+
+// usedAdsChallenges keeps the already used challenges until they expire so a solved challenge can only be used once
+var usedAdsChallenges = struct {
+	sync.Mutex
+	expiries map[string]time.Time
+}{expiries: make(map[string]time.Time)}
+
+// signAdsChallenge signs the given challenge payload with the app secret
+func signAdsChallenge(payload string) string {
+
+	mac := hmac.New(sha256.New, []byte(MySigningKey))
+	mac.Write([]byte(payload))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewAdsChallenge is a function to issue a new signed proof of work challenge for the ads submission
+func (kost *Kost) NewAdsChallenge() (*entities.AdsChallenge, error) {
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(adsChallengeTTL)
+
+	// the challenge carries its own expiry and difficulty, both protected by the signature
+	payload := fmt.Sprintf("%d.%d.%s", expiresAt.Unix(), AdsSubmission.PowDifficulty, hex.EncodeToString(random))
+
+	return &entities.AdsChallenge{
+		Challenge:  payload + "." + signAdsChallenge(payload),
+		Difficulty: AdsSubmission.PowDifficulty,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// VerifyAdsChallenge is a function to verify the solved proof of work challenge,
+// sha256(challenge + ":" + nonce) must start with as many zero bits as the challenge difficulty
+func (kost *Kost) VerifyAdsChallenge(challenge string, nonce string) error {
+
+	if challenge == "" || nonce == "" {
+		return NewError(ErrCodeValidation, "Challenge iklan harus diisi")
+	}
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return NewError(ErrCodeValidation, "Challenge iklan tidak valid")
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(signAdsChallenge(payload)), []byte(parts[3])) {
+		return NewError(ErrCodeValidation, "Challenge iklan tidak valid")
+	}
+
+	expiry, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return NewError(ErrCodeValidation, "Challenge iklan tidak valid")
+	}
+
+	expiresAt := time.Unix(expiry, 0)
+	if time.Now().After(expiresAt) {
+		return NewError(ErrCodeValidation, "Challenge iklan sudah kedaluwarsa")
+	}
+
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return NewError(ErrCodeValidation, "Challenge iklan tidak valid")
+	}
+
+	// count the leading zero bits of the hash
+	hash := sha256.Sum256([]byte(challenge + ":" + nonce))
+	zeroBits := 0
+	for _, b := range hash {
+		zeroBits += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+
+	if zeroBits < difficulty {
+		return NewError(ErrCodeValidation, "Jawaban challenge iklan salah")
+	}
+
+	usedAdsChallenges.Lock()
+	defer usedAdsChallenges.Unlock()
+
+	// forget the expired challenges as they are rejected by the expiry check anyway
+	now := time.Now()
+	for usedChallenge, usedExpiry := range usedAdsChallenges.expiries {
+		if now.After(usedExpiry) {
+			delete(usedAdsChallenges.expiries, usedChallenge)
+		}
+	}
+
+	if _, used := usedAdsChallenges.expiries[challenge]; used {
+		return NewError(ErrCodeValidation, "Challenge iklan sudah digunakan")
+	}
+
+	usedAdsChallenges.expiries[challenge] = expiresAt
+
+	return nil
+}
+
+// NormalizePhoneNumber is a function to validate the given indonesian mobile phone number and normalize it to the 08 format
+func NormalizePhoneNumber(phoneNumber string) (string, error) {
+
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+
+		return r
+	}, phoneNumber)
+
+	if strings.HasPrefix(normalized, "+62") {
+		normalized = "0" + normalized[3:]
+	} else if strings.HasPrefix(normalized, "62") {
+		normalized = "0" + normalized[2:]
+	}
+
+	if !phoneNumberPattern.MatchString(normalized) {
+		return "", NewError(ErrCodeValidation, "Nomor telepon %s tidak valid", phoneNumber)
+	}
+
+	return normalized, nil
+}
+
+// AdsSubmissionHash is a function to get the fingerprint of the given ads submission used for the duplicate detection
+func AdsSubmissionHash(kostAds *entities.KostAds, packageID uint) string {
+
+	// normalize the free text so the whitespace and letter case changes are still detected as duplicate
+	normalize := func(text string) string {
+		return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	}
+
+	fields := []string{
+		kostAds.AdsPhoneNumber,
+		kostAds.AdsPICWhatsapp,
+		normalize(kostAds.AdsPropertyAddress),
+		normalize(kostAds.AdsPropertyCity),
+		strconv.FormatUint(uint64(packageID), 10),
+		normalize(kostAds.AdsDesc),
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// adsSubmissionProcessStatuses is the statuses of the ads that is still being processed, the same ads can't be submitted again
+var adsSubmissionProcessStatuses = []uint{AdsStatusSubmitted, AdsStatusApproved, AdsStatusScheduled}
+
+// ReleaseAdsSubmissionLock is a function to release the submission lock of the same ads within the given transaction
+// once that ads is no longer being processed or is out of the duplicate window, so it can be submitted again,
+// the lock of the same ads still being processed is kept so the new ads is rejected by the unique submission lock,
+// the concurrent submission waits for the row lock here and is rejected as well once the first one is committed
+func (kost *Kost) ReleaseAdsSubmissionLock(tx *gorm.DB, submissionHash string) error {
+
+	return tx.Model(&database.DBKostAds{}).
+		Where("submission_lock = ? AND (created <= ? OR status NOT IN ?)",
+			submissionHash,
+			time.Now().Local().Add(-time.Duration(AdsSubmission.DuplicateWindowHours)*time.Hour),
+			adsSubmissionProcessStatuses).
+		Update("submission_lock", nil).Error
+}
+
+// AdsSubmissionError is a function to map the error of adding the new ads, the taken submission lock is reported as a duplicate
+func AdsSubmissionError(err error) error {
+
+	if strings.Contains(err.Error(), "Error 1062") && strings.Contains(err.Error(), "submission_lock") {
+		return WrapError(ErrCodeConflict, err, "Iklan yang sama sudah diajukan dan sedang diproses")
+	}
+
+	return err
+}
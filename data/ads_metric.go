@@ -0,0 +1,583 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxAdsMetricRows is the max number of rows of a single ads metric import
+const maxAdsMetricRows = 50000
+
+// adsMetricColumns maps the normalized csv header of the instagram and tiktok exports to the metric column
+var adsMetricColumns = map[string]string{
+	"adscode":         "ads_code",
+	"kodeiklan":       "ads_code",
+	"code":            "ads_code",
+	"channel":         "channel",
+	"platform":        "channel",
+	"postref":         "post_ref",
+	"postid":          "post_ref",
+	"posturl":         "post_ref",
+	"permalink":       "post_ref",
+	"mediaid":         "post_ref",
+	"videoid":         "post_ref",
+	"videolink":       "post_ref",
+	"link":            "post_ref",
+	"date":            "metric_date",
+	"metricdate":      "metric_date",
+	"reportdate":      "metric_date",
+	"tanggal":         "metric_date",
+	"reach":           "reach",
+	"accountsreached": "reach",
+	"jangkauan":       "reach",
+	"impressions":     "impressions",
+	"tayangan":        "impressions",
+	"views":           "views",
+	"videoviews":      "views",
+	"plays":           "views",
+	"totalplay":       "views",
+	"likes":           "likes",
+	"suka":            "likes",
+	"comments":        "comments",
+	"komentar":        "comments",
+	"shares":          "shares",
+	"bagikan":         "shares",
+	"saves":           "saves",
+	"saved":           "saves",
+	"disimpan":        "saves",
+	"clicks":          "clicks",
+	"linkclicks":      "clicks",
+	"websiteclicks":   "clicks",
+	"klik":            "clicks",
+}
+
+// adsMetricValueColumns are the metric columns holding a number
+var adsMetricValueColumns = []string{"reach", "impressions", "views", "likes", "comments", "shares", "saves", "clicks"}
+
+// adsMetricDateLayouts are the accepted metric date formats
+var adsMetricDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"02/01/2006",
+	"02-01-2006",
+	"2 January 2006",
+	"January 2, 2006",
+}
+
+// nonHeaderPattern matches every character ignored when normalizing the csv header
+var nonHeaderPattern = regexp.MustCompile(`[^a-z0-9]`)
+
+// thousandGroupedPattern matches the number grouped by thousand, e.g. 1,234,567 or 1.234.567
+var thousandGroupedPattern = regexp.MustCompile(`^[0-9]{1,3}([.,][0-9]{3})+$`)
+
+// parseAdsMetricValue parses the metric number of the exports, the thousand separators and decimals are tolerated
+func parseAdsMetricValue(value string) (int64, error) {
+
+	value = strings.TrimSpace(strings.Replace(value, " ", "", -1))
+	if value == "" || value == "-" {
+		return 0, nil
+	}
+
+	if thousandGroupedPattern.MatchString(value) {
+		value = strings.NewReplacer(",", "", ".", "").Replace(value)
+	}
+
+	number, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || number < 0 {
+		return 0, NewError(ErrCodeValidation, "Nilai %s tidak valid", value)
+	}
+
+	return int64(math.Round(number)), nil
+}
+
+// parseAdsMetricDate parses the metric date of the exports
+func parseAdsMetricDate(value string) (time.Time, error) {
+
+	value = strings.TrimSpace(value)
+	for _, layout := range adsMetricDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local), nil
+		}
+	}
+
+	return time.Time{}, NewError(ErrCodeValidation, "Tanggal %s tidak valid", value)
+}
+
+// detectCSVDelimiter picks the delimiter used by the given header line, the spreadsheet apps of some locales use semicolon
+func detectCSVDelimiter(headerLine string) rune {
+
+	delimiter := ','
+	maxCount := strings.Count(headerLine, ",")
+	for _, candidate := range []rune{';', '\t'} {
+		if count := strings.Count(headerLine, string(candidate)); count > maxCount {
+			delimiter = candidate
+			maxCount = count
+		}
+	}
+
+	return delimiter
+}
+
+// ImportAdsMetrics is a function to import the ads performance metrics from the given csv,
+// the rows are mapped to the ads by the ads code and the snapshot of the same post and date is overwritten
+func (kost *Kost) ImportAdsMetrics(currentUser *database.MasterUser, src io.Reader, defaultChannel string) (*entities.AdsMetricImportResult, error) {
+
+	if defaultChannel != "" {
+		if _, ok := AdsSlotCapacity[defaultChannel]; !ok {
+			return nil, NewError(ErrCodeValidation, "Channel iklan %s tidak valid", defaultChannel)
+		}
+	}
+
+	// the import is capped by the request body size, so the whole file is read at once
+	content, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, RequestBodyError(err)
+	}
+
+	// skip the utf-8 bom of the spreadsheet exports
+	content = bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, NewError(ErrCodeValidation, "File metrik kosong")
+	}
+
+	// detect the delimiter from the header line
+	headerLine := content
+	if index := bytes.IndexByte(content, '\n'); index != -1 {
+		headerLine = content[:index]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = detectCSVDelimiter(string(headerLine))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, NewError(ErrCodeValidation, "Header file metrik tidak valid")
+	}
+
+	// map the header to the metric columns, the unknown columns are ignored
+	columnIndexes := make(map[string]int)
+	for index, name := range header {
+		if column, ok := adsMetricColumns[nonHeaderPattern.ReplaceAllString(strings.ToLower(name), "")]; ok {
+			if _, exists := columnIndexes[column]; !exists {
+				columnIndexes[column] = index
+			}
+		}
+	}
+
+	if _, ok := columnIndexes["ads_code"]; !ok {
+		return nil, NewError(ErrCodeValidation, "Kolom ads_code tidak ditemukan")
+	}
+
+	if _, ok := columnIndexes["channel"]; !ok && defaultChannel == "" {
+		return nil, NewError(ErrCodeValidation, "Kolom channel tidak ditemukan, isi channel pada request")
+	}
+
+	hasMetricColumn := false
+	for _, column := range adsMetricValueColumns {
+		if _, ok := columnIndexes[column]; ok {
+			hasMetricColumn = true
+		}
+	}
+
+	if !hasMetricColumn {
+		return nil, NewError(ErrCodeValidation, "Tidak ada kolom metrik yang dikenali")
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, WrapError(ErrCodeValidation, err, "Isi file metrik tidak valid")
+	}
+
+	if len(records) > maxAdsMetricRows {
+		return nil, NewError(ErrCodeValidation, "Maksimal %d baris per import", maxAdsMetricRows)
+	}
+
+	cell := func(record []string, column string) string {
+		index, ok := columnIndexes[column]
+		if !ok || index >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[index])
+	}
+
+	// look for the ads of every ads code in the file at once
+	var adsCodes []string
+	for _, record := range records {
+		adsCodes = append(adsCodes, cell(record, "ads_code"))
+	}
+
+	var targetAds []database.DBKostAds
+	if len(adsCodes) > 0 {
+		if err := config.DB.Select("id, ads_code").Where("ads_code IN ?", adsCodes).Find(&targetAds).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	adsIDs := make(map[string]uint)
+	for _, ads := range targetAds {
+		adsIDs[ads.AdsCode] = ads.ID
+	}
+
+	result := &entities.AdsMetricImportResult{Failed: []entities.AdsMetricImportError{}}
+	var metrics []database.DBKostAdsMetric
+	today := time.Now().Local()
+
+	for index, record := range records {
+
+		// the header is the first row of the file
+		row := index + 2
+		fail := func(message string) {
+			result.Failed = append(result.Failed, entities.AdsMetricImportError{Row: row, Message: message})
+		}
+
+		adsCode := cell(record, "ads_code")
+		adsID, ok := adsIDs[adsCode]
+		if !ok {
+			fail(fmt.Sprintf("Iklan %s tidak ditemukan", adsCode))
+			continue
+		}
+
+		channel := strings.ToLower(cell(record, "channel"))
+		if channel == "" {
+			channel = defaultChannel
+		}
+
+		if _, ok := AdsSlotCapacity[channel]; !ok {
+			fail(fmt.Sprintf("Channel iklan %s tidak valid", channel))
+			continue
+		}
+
+		metricDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+		if value := cell(record, "metric_date"); value != "" {
+			if metricDate, err = parseAdsMetricDate(value); err != nil {
+				fail(err.Error())
+				continue
+			}
+		}
+
+		values := make(map[string]int64)
+		var valueErr error
+		for _, column := range adsMetricValueColumns {
+			if values[column], valueErr = parseAdsMetricValue(cell(record, column)); valueErr != nil {
+				break
+			}
+		}
+
+		if valueErr != nil {
+			fail(valueErr.Error())
+			continue
+		}
+
+		metrics = append(metrics, database.DBKostAdsMetric{
+			AdsID:       adsID,
+			Channel:     channel,
+			PostRef:     cell(record, "post_ref"),
+			MetricDate:  metricDate,
+			Reach:       values["reach"],
+			Impressions: values["impressions"],
+			Views:       values["views"],
+			Likes:       values["likes"],
+			Comments:    values["comments"],
+			Shares:      values["shares"],
+			Saves:       values["saves"],
+			Clicks:      values["clicks"],
+			IsActive:    true,
+			Created:     time.Now().Local(),
+			CreatedBy:   currentUser.Username,
+			Modified:    time.Now().Local(),
+			ModifiedBy:  currentUser.Username,
+		})
+	}
+
+	if len(metrics) == 0 {
+		return result, nil
+	}
+
+	// insert the metrics with transaction scope, the existing snapshot of the same post and date is updated
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+
+		if dbErr := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "ads_id"}, {Name: "channel"}, {Name: "post_ref"}, {Name: "metric_date"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"reach", "impressions", "views", "likes", "comments", "shares", "saves", "clicks", "modified", "modified_by",
+			}),
+		}).CreateInBatches(&metrics, 500).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	result.Imported = len(metrics)
+
+	return result, nil
+}
+
+// addAdsMetricValues adds the given metric snapshot to the given metric values
+func addAdsMetricValues(values *entities.AdsMetricValues, metric *database.DBKostAdsMetric) {
+
+	values.Reach += metric.Reach
+	values.Impressions += metric.Impressions
+	values.Views += metric.Views
+	values.Likes += metric.Likes
+	values.Comments += metric.Comments
+	values.Shares += metric.Shares
+	values.Saves += metric.Saves
+	values.Clicks += metric.Clicks
+}
+
+// sumAdsMetricValues adds the given metric values to the given metric totals
+func sumAdsMetricValues(totals *entities.AdsMetricValues, values *entities.AdsMetricValues) {
+
+	totals.Reach += values.Reach
+	totals.Impressions += values.Impressions
+	totals.Views += values.Views
+	totals.Likes += values.Likes
+	totals.Comments += values.Comments
+	totals.Shares += values.Shares
+	totals.Saves += values.Saves
+	totals.Clicks += values.Clicks
+}
+
+// fillAdsEngagementRate sets the engagement rate of the given metric values, in percent of the reach
+func fillAdsEngagementRate(values *entities.AdsMetricValues) {
+
+	values.EngagementRate = 0
+	if values.Reach > 0 {
+		engagement := float64(values.Likes + values.Comments + values.Shares + values.Saves)
+		values.EngagementRate = math.Round(engagement/float64(values.Reach)*10000) / 100
+	}
+}
+
+// latestAdsPostMetrics picks the latest snapshot of every post from the given metrics ordered by the metric date
+func latestAdsPostMetrics(metrics []database.DBKostAdsMetric) []entities.AdsPostMetric {
+
+	var postKeys []string
+	latest := make(map[string]*database.DBKostAdsMetric)
+	for i := range metrics {
+		key := metrics[i].Channel + "\x00" + metrics[i].PostRef
+		if _, ok := latest[key]; !ok {
+			postKeys = append(postKeys, key)
+		}
+
+		latest[key] = &metrics[i]
+	}
+
+	var posts []entities.AdsPostMetric
+	for _, key := range postKeys {
+		post := entities.AdsPostMetric{
+			Channel:        latest[key].Channel,
+			PostRef:        latest[key].PostRef,
+			LastMetricDate: latest[key].MetricDate.Format(adsScheduleDateLayout),
+		}
+
+		addAdsMetricValues(&post.AdsMetricValues, latest[key])
+		fillAdsEngagementRate(&post.AdsMetricValues)
+		posts = append(posts, post)
+	}
+
+	return posts
+}
+
+// GetAdsMetricReport is a function to get the performance report of the given ads,
+// the metrics are cumulative snapshots so the totals are the sum of the latest snapshot of every post
+func (kost *Kost) GetAdsMetricReport(adsID uint) (*entities.AdsMetricReport, error) {
+
+	// look for the target ads in the db
+	var targetAds database.DBKostAds
+	if err := config.DB.Where("id = ?", adsID).First(&targetAds).Error; err != nil {
+		return nil, err
+	}
+
+	report := &entities.AdsMetricReport{
+		AdsID:    targetAds.ID,
+		AdsCode:  targetAds.AdsCode,
+		AdsOwner: targetAds.AdsOwner,
+		Posts:    []entities.AdsPostMetric{},
+		Timeline: []entities.AdsMetricTimelinePoint{},
+	}
+
+	adsPackage, err := kost.GetAdsPackageByAds(config.DB, adsID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if err == nil {
+		report.AdsPackageID = adsPackage.ID
+		report.PackageName = adsPackage.PackageName
+		report.Channel = adsPackage.Channel
+	}
+
+	var metrics []database.DBKostAdsMetric
+	if err := config.DB.Where("ads_id = ? AND is_active = ?", adsID, true).Order("metric_date, id").Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+
+	if posts := latestAdsPostMetrics(metrics); posts != nil {
+		report.Posts = posts
+	}
+
+	for i := range report.Posts {
+		sumAdsMetricValues(&report.Totals, &report.Posts[i].AdsMetricValues)
+	}
+
+	fillAdsEngagementRate(&report.Totals)
+
+	// build the timeline, the post without a snapshot on a date keeps its previous snapshot
+	carried := make(map[string]*database.DBKostAdsMetric)
+	for i := 0; i < len(metrics); {
+
+		metricDate := metrics[i].MetricDate.Format(adsScheduleDateLayout)
+		for ; i < len(metrics) && metrics[i].MetricDate.Format(adsScheduleDateLayout) == metricDate; i++ {
+			carried[metrics[i].Channel+"\x00"+metrics[i].PostRef] = &metrics[i]
+		}
+
+		point := entities.AdsMetricTimelinePoint{MetricDate: metricDate}
+		for _, metric := range carried {
+			addAdsMetricValues(&point.AdsMetricValues, metric)
+		}
+
+		fillAdsEngagementRate(&point.AdsMetricValues)
+		report.Timeline = append(report.Timeline, point)
+	}
+
+	return report, nil
+}
+
+// GetAdsPackageMetricReport is a function to get the performance report of the ads sold with the given package,
+// only the snapshots within the given date range are counted if any
+func (kost *Kost) GetAdsPackageMetricReport(packageID uint, from *time.Time, to *time.Time) (*entities.AdsPackageMetricReport, error) {
+
+	// look for the target ads package in the db
+	var adsPackage database.MasterAdsPackage
+	if err := config.DB.Where("id = ?", packageID).First(&adsPackage).Error; err != nil {
+		return nil, err
+	}
+
+	// look for the ads of the package
+	var packageAds []database.DBKostAds
+	if err := config.DB.
+		Select("db_kost_ads.id, db_kost_ads.ads_code, db_kost_ads.ads_owner").
+		Joins(adsPackageJoin).
+		Where("master_ads_packages.id = ?", packageID).
+		Order("db_kost_ads.id").
+		Find(&packageAds).Error; err != nil {
+		return nil, err
+	}
+
+	report := &entities.AdsPackageMetricReport{
+		AdsPackageID: adsPackage.ID,
+		PackageName:  adsPackage.PackageName,
+		Channel:      adsPackage.Channel,
+		AdsCount:     len(packageAds),
+		Ads:          []entities.AdsMetricSummary{},
+	}
+
+	if len(packageAds) == 0 {
+		return report, nil
+	}
+
+	var adsIDs []uint
+	for _, ads := range packageAds {
+		adsIDs = append(adsIDs, ads.ID)
+	}
+
+	model := config.DB.Where("ads_id IN ? AND is_active = ?", adsIDs, true)
+	if from != nil {
+		model = model.Where("metric_date >= ?", from.Format(adsScheduleDateLayout))
+	}
+
+	if to != nil {
+		model = model.Where("metric_date <= ?", to.Format(adsScheduleDateLayout))
+	}
+
+	var metrics []database.DBKostAdsMetric
+	if err := model.Order("metric_date, id").Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+
+	// group the snapshots per ads
+	adsMetrics := make(map[uint][]database.DBKostAdsMetric)
+	for _, metric := range metrics {
+		adsMetrics[metric.AdsID] = append(adsMetrics[metric.AdsID], metric)
+	}
+
+	reportedAds := 0
+	for _, ads := range packageAds {
+
+		summary := entities.AdsMetricSummary{
+			AdsID:    ads.ID,
+			AdsCode:  ads.AdsCode,
+			AdsOwner: ads.AdsOwner,
+		}
+
+		posts := latestAdsPostMetrics(adsMetrics[ads.ID])
+		summary.PostCount = len(posts)
+		for i := range posts {
+			sumAdsMetricValues(&summary.Totals, &posts[i].AdsMetricValues)
+		}
+
+		fillAdsEngagementRate(&summary.Totals)
+
+		if summary.PostCount > 0 {
+			reportedAds++
+			sumAdsMetricValues(&report.Totals, &summary.Totals)
+		}
+
+		report.Ads = append(report.Ads, summary)
+	}
+
+	fillAdsEngagementRate(&report.Totals)
+
+	// the average only counts the ads that already have metrics
+	if reportedAds > 0 {
+		count := int64(reportedAds)
+		report.Average = entities.AdsMetricValues{
+			Reach:       report.Totals.Reach / count,
+			Impressions: report.Totals.Impressions / count,
+			Views:       report.Totals.Views / count,
+			Likes:       report.Totals.Likes / count,
+			Comments:    report.Totals.Comments / count,
+			Shares:      report.Totals.Shares / count,
+			Saves:       report.Totals.Saves / count,
+			Clicks:      report.Totals.Clicks / count,
+		}
+
+		report.Average.EngagementRate = report.Totals.EngagementRate
+	}
+
+	// the best performing ads come first
+	sort.SliceStable(report.Ads, func(i, j int) bool {
+		return report.Ads[i].Totals.Reach > report.Ads[j].Totals.Reach
+	})
+
+	return report, nil
+}
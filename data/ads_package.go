@@ -0,0 +1,311 @@
+package data
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// the ads package tiers
+const (
+	AdsTierFree    = "free"
+	AdsTierPremium = "premium"
+)
+
+// adsPackageJoin joins the ads with its package, the legacy ads are linked to their package by SeedAdsPackages
+const adsPackageJoin = "inner join master_ads_packages on master_ads_packages.id = db_kost_ads.ads_package_id"
+
+// legacyAdsPackages is the ads types sent by the clients before the catalogue, they are seeded so those clients keep working,
+// the legacy ads had no price so the admin sets it in the catalogue
+var legacyAdsPackages = []database.MasterAdsPackage{
+	{PackageName: "Iklan Instagram Free", Channel: AdsChannelIGFeed, Tier: AdsTierFree, DurationDays: 30, IncludedPosts: 1},
+	{PackageName: "Iklan Instagram Premium (Rekomendasi)", Channel: AdsChannelIGFeed, Tier: AdsTierPremium, DurationDays: 30, IncludedPosts: 1},
+	{PackageName: "Iklan Tiktok Free", Channel: AdsChannelTiktok, Tier: AdsTierFree, DurationDays: 30, IncludedPosts: 1},
+	{PackageName: "Iklan Tiktok Premium (Rekomendasi)", Channel: AdsChannelTiktok, Tier: AdsTierPremium, DurationDays: 30, IncludedPosts: 1},
+}
+
+// legacyDefaultAdsPackage is the package of the legacy ads type that is not in the catalogue,
+// the ads that were not tiktok ads were posted on instagram before the catalogue
+const legacyDefaultAdsPackage = "Iklan Instagram Free"
+
+// SeedAdsPackages is a function to add the missing legacy ads types to the catalogue and link the legacy ads to their package,
+// it is safe to run on every start as the existing packages are never overwritten and only the unlinked ads are updated
+func (kost *Kost) SeedAdsPackages() error {
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// the seeded packages are priced in the first currency, the admin sets the actual price later
+		var currencyUOM database.MasterUOM
+		if err := tx.Where("uom_type = ?", "currency").Order("id").First(&currencyUOM).Error; err != nil {
+			return err
+		}
+
+		for _, legacyAdsPackage := range legacyAdsPackages {
+
+			newAdsPackage := legacyAdsPackage
+			newAdsPackage.PriceUOM = currencyUOM.ID
+			newAdsPackage.IsActive = true
+			newAdsPackage.Created = time.Now().Local()
+			newAdsPackage.CreatedBy = "system"
+			newAdsPackage.Modified = time.Now().Local()
+			newAdsPackage.ModifiedBy = "system"
+
+			if err := tx.Where("package_name = ?", newAdsPackage.PackageName).FirstOrCreate(&newAdsPackage).Error; err != nil {
+				return err
+			}
+		}
+
+		// link the legacy ads to their package by the ads type once, so renaming the package later keeps them linked
+		if err := tx.Exec("UPDATE db_kost_ads INNER JOIN master_ads_packages ON master_ads_packages.package_name = db_kost_ads.ads_type" +
+			" SET db_kost_ads.ads_package_id = master_ads_packages.id WHERE db_kost_ads.ads_package_id = 0").Error; err != nil {
+			return err
+		}
+
+		var defaultAdsPackage database.MasterAdsPackage
+		if err := tx.Where("package_name = ?", legacyDefaultAdsPackage).First(&defaultAdsPackage).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&database.DBKostAds{}).Where("ads_package_id = ?", 0).Update("ads_package_id", defaultAdsPackage.ID).Error
+	})
+}
+
+// ValidateAdsPackage is a function to validate the given ads package, the given package id is excluded from the unique name check
+func (kost *Kost) ValidateAdsPackage(adsPackage *entities.MasterAdsPackage, packageID uint) error {
+
+	adsPackage.PackageName = strings.TrimSpace(adsPackage.PackageName)
+	if adsPackage.PackageName == "" {
+		return NewError(ErrCodeValidation, "Nama paket iklan harus diisi")
+	}
+
+	if _, ok := AdsSlotCapacity[adsPackage.Channel]; !ok {
+		return NewError(ErrCodeValidation, "Channel iklan %s tidak valid", adsPackage.Channel)
+	}
+
+	if adsPackage.Tier != AdsTierFree && adsPackage.Tier != AdsTierPremium {
+		return NewError(ErrCodeValidation, "Tier paket iklan %s tidak valid", adsPackage.Tier)
+	}
+
+	if adsPackage.Price < 0 || (adsPackage.Tier == AdsTierFree && adsPackage.Price != 0) {
+		return NewError(ErrCodeValidation, "Harga paket iklan tidak valid")
+	}
+
+	if adsPackage.DurationDays == 0 || adsPackage.IncludedPosts == 0 {
+		return NewError(ErrCodeValidation, "Durasi dan jumlah posting paket iklan harus lebih dari 0")
+	}
+
+	// look for the requested uom from the database
+	var targetPriceUOM database.MasterUOM
+	if err := config.DB.Where("id = ?", adsPackage.PriceUOM).First(&targetPriceUOM).Error; err != nil {
+		return err
+	}
+
+	// check the uom type, if not currency return error
+	if targetPriceUOM.UOMType != "currency" {
+		return NewError(ErrCodeValidation, "Tipe UOM tidak valid")
+	}
+
+	// the package name must be unique as the legacy clients pick the package by its name
+	var count int64
+	if err := config.DB.Model(&database.MasterAdsPackage{}).Where("package_name = ? AND id <> ?", adsPackage.PackageName, packageID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return NewError(ErrCodeConflict, "Nama paket iklan %s sudah digunakan", adsPackage.PackageName)
+	}
+
+	return nil
+}
+
+// AddAdsPackage is a function to add a new ads package to the catalogue
+func (kost *Kost) AddAdsPackage(currentUser *database.MasterUser, adsPackage *entities.MasterAdsPackage) (*database.MasterAdsPackage, error) {
+
+	if err := kost.ValidateAdsPackage(adsPackage, 0); err != nil {
+		return nil, err
+	}
+
+	newAdsPackage := &database.MasterAdsPackage{
+		PackageName:   adsPackage.PackageName,
+		Channel:       adsPackage.Channel,
+		Tier:          adsPackage.Tier,
+		Price:         adsPackage.Price,
+		PriceUOM:      adsPackage.PriceUOM,
+		DurationDays:  adsPackage.DurationDays,
+		IncludedPosts: adsPackage.IncludedPosts,
+		IsActive:      true,
+		Created:       time.Now().Local(),
+		CreatedBy:     currentUser.Username,
+		Modified:      time.Now().Local(),
+		ModifiedBy:    currentUser.Username,
+	}
+
+	// insert the new ads package to the database
+	if err := config.DB.Create(newAdsPackage).Error; err != nil {
+		return nil, err
+	}
+
+	return newAdsPackage, nil
+}
+
+// UpdateAdsPackage is a function to update the given ads package of the catalogue
+func (kost *Kost) UpdateAdsPackage(currentUser *database.MasterUser, packageID uint, adsPackage *entities.MasterAdsPackage) (*database.MasterAdsPackage, error) {
+
+	// look for the target ads package in the db
+	targetAdsPackage := &database.MasterAdsPackage{}
+	if err := config.DB.Where("id = ?", packageID).First(targetAdsPackage).Error; err != nil {
+		return nil, err
+	}
+
+	if err := kost.ValidateAdsPackage(adsPackage, packageID); err != nil {
+		return nil, err
+	}
+
+	targetAdsPackage.PackageName = adsPackage.PackageName
+	targetAdsPackage.Channel = adsPackage.Channel
+	targetAdsPackage.Tier = adsPackage.Tier
+	targetAdsPackage.Price = adsPackage.Price
+	targetAdsPackage.PriceUOM = adsPackage.PriceUOM
+	targetAdsPackage.DurationDays = adsPackage.DurationDays
+	targetAdsPackage.IncludedPosts = adsPackage.IncludedPosts
+	targetAdsPackage.IsActive = adsPackage.IsActive
+	targetAdsPackage.Modified = time.Now().Local()
+	targetAdsPackage.ModifiedBy = currentUser.Username
+
+	// update the ads package
+	if err := config.DB.Save(targetAdsPackage).Error; err != nil {
+		return nil, err
+	}
+
+	return targetAdsPackage, nil
+}
+
+// DeactivateAdsPackage is a function to deactivate the given ads package so it can't be picked anymore
+func (kost *Kost) DeactivateAdsPackage(currentUser *database.MasterUser, packageID uint) error {
+
+	result := config.DB.Model(&database.MasterAdsPackage{}).Where("id = ?", packageID).Updates(map[string]interface{}{
+		"is_active":   false,
+		"modified":    time.Now().Local(),
+		"modified_by": currentUser.Username,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAdsPackageList is a function to get the ads package catalogue
+func (kost *Kost) GetAdsPackageList(includeInactive bool) ([]entities.MasterAdsPackage, error) {
+
+	model := config.DB.
+		Model(&database.MasterAdsPackage{}).
+		Select("master_ads_packages.id" +
+			",master_ads_packages.package_name" +
+			",master_ads_packages.channel" +
+			",master_ads_packages.tier" +
+			",master_ads_packages.price" +
+			",master_ads_packages.price_uom" +
+			",master_uoms.uom_desc as price_uom_desc" +
+			",master_ads_packages.duration_days" +
+			",master_ads_packages.included_posts" +
+			",master_ads_packages.is_active").
+		Joins("inner join master_uoms on master_uoms.id = master_ads_packages.price_uom")
+
+	if !includeInactive {
+		model = model.Where("master_ads_packages.is_active = ?", true)
+	}
+
+	var adsPackages []entities.MasterAdsPackage
+	if err := model.Order("master_ads_packages.channel, master_ads_packages.price").Scan(&adsPackages).Error; err != nil {
+		return nil, err
+	}
+
+	return adsPackages, nil
+}
+
+// ResolveAdsPackage is a function to get the active ads package picked by the advertiser,
+// the legacy clients that only send the ads type are resolved by the package name,
+// the unknown ads type falls back to the default legacy package as every such ads was accepted before the catalogue
+func (kost *Kost) ResolveAdsPackage(packageID uint, adsType string) (*database.MasterAdsPackage, error) {
+
+	model := config.DB.Where("is_active = ?", true)
+	if packageID != 0 {
+		model = model.Where("id = ?", packageID)
+	} else {
+		model = model.Where("package_name IN ?", []string{adsType, legacyDefaultAdsPackage}).
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: "package_name = ? DESC", Vars: []interface{}{adsType}}})
+	}
+
+	adsPackage := &database.MasterAdsPackage{}
+	if err := model.First(adsPackage).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NewError(ErrCodeNotFound, "Paket iklan tidak ditemukan")
+		}
+
+		return nil, err
+	}
+
+	return adsPackage, nil
+}
+
+// GetAdsPackageByAds is a function to get the package of the given ads
+func (kost *Kost) GetAdsPackageByAds(tx *gorm.DB, adsID uint) (*database.MasterAdsPackage, error) {
+
+	adsPackage := &database.MasterAdsPackage{}
+	if err := tx.
+		Model(&database.MasterAdsPackage{}).
+		Select("master_ads_packages.*").
+		Joins("inner join db_kost_ads on db_kost_ads.id = ? AND master_ads_packages.id = db_kost_ads.ads_package_id", adsID).
+		First(adsPackage).Error; err != nil {
+		return nil, err
+	}
+
+	return adsPackage, nil
+}
+
+// GetKostAdsListByChannels is a function to get the kost ads list of the given channels and statuses
+func (kost *Kost) GetKostAdsListByChannels(channels []string, statuses []uint) ([]entities.KostAds, error) {
+
+	var kostAds []entities.KostAds
+	if err := config.DB.
+		Model(&database.DBKostAds{}).
+		Select("db_kost_ads.id"+
+			",db_kost_ads.status "+
+			",db_kost_ads.status_reason"+
+			",db_kost_ads.ads_code"+
+			",master_ads_packages.id as ads_package_id"+
+			",master_ads_packages.channel as ads_channel"+
+			",db_kost_ads.ads_type"+
+			",db_kost_ads.ads_kost_type"+
+			",db_kost_ads.ads_owner"+
+			",db_kost_ads.ads_owner_ig"+
+			",db_kost_ads.ads_phone_number"+
+			",db_kost_ads.ads_pic_whatsapp"+
+			",db_kost_ads.ads_property_address"+
+			",db_kost_ads.ads_property_city"+
+			",db_kost_ads.ads_property_price"+
+			",db_kost_ads.ads_desc"+
+			",db_kost_ads.ads_gender"+
+			",db_kost_ads.ads_pet_allowed"+
+			",db_kost_ads.ads_post_schedule_request"+
+			",db_kost_ads.ads_hashtag"+
+			",db_kost_ads.is_active").
+		Joins(adsPackageJoin).
+		Where("master_ads_packages.channel IN ? AND db_kost_ads.status IN ?", channels, statuses).
+		Scan(&kostAds).Error; err != nil {
+		return nil, err
+	}
+
+	return kostAds, nil
+}
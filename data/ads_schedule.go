@@ -0,0 +1,339 @@
+package data
+
+import (
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// the ads posting channels
+const (
+	AdsChannelIGFeed  = "ig_feed"
+	AdsChannelIGStory = "ig_story"
+	AdsChannelTiktok  = "tiktok"
+)
+
+// AdsChannels is the ordered list of the ads posting channels
+var AdsChannels = []string{AdsChannelIGFeed, AdsChannelIGStory, AdsChannelTiktok}
+
+// AdsSlotCapacity is the ads posting slot capacity per day of every channel, it is overridden by the app configuration
+var AdsSlotCapacity = map[string]int{
+	AdsChannelIGFeed:  3,
+	AdsChannelIGStory: 5,
+	AdsChannelTiktok:  2,
+}
+
+// adsScheduleDateLayout is the date format of the ads posting schedule
+const adsScheduleDateLayout = "2006-01-02"
+
+// ParseAdsSchedule is a function to validate the given ads schedule and parse its dates
+func ParseAdsSchedule(schedule entities.AdsSchedule) ([]time.Time, error) {
+
+	if _, ok := AdsSlotCapacity[schedule.Channel]; !ok {
+		return nil, NewError(ErrCodeValidation, "Channel iklan %s tidak valid", schedule.Channel)
+	}
+
+	year, month, day := time.Now().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+
+	var dates []time.Time
+	var seenDates = make(map[string]bool)
+	for _, rawDate := range schedule.Dates {
+
+		date, err := time.ParseInLocation(adsScheduleDateLayout, rawDate, time.Local)
+		if err != nil {
+			return nil, NewError(ErrCodeValidation, "Tanggal %s tidak valid, gunakan format YYYY-MM-DD", rawDate)
+		}
+
+		if date.Before(today) {
+			return nil, NewError(ErrCodeValidation, "Tanggal %s sudah lewat", rawDate)
+		}
+
+		// skip the duplicated dates
+		if seenDates[rawDate] {
+			continue
+		}
+
+		seenDates[rawDate] = true
+		dates = append(dates, date)
+	}
+
+	return dates, nil
+}
+
+// AddAdsScheduleRequests is a function to add the requested posting dates of the given ads within the given transaction
+func (kost *Kost) AddAdsScheduleRequests(tx *gorm.DB, adsID uint, schedules []entities.AdsSchedule, username string) error {
+
+	var scheduleRequests []database.DBKostAdsScheduleRequest
+	for _, schedule := range schedules {
+
+		dates, err := ParseAdsSchedule(schedule)
+		if err != nil {
+			return err
+		}
+
+		for _, date := range dates {
+			scheduleRequests = append(scheduleRequests, database.DBKostAdsScheduleRequest{
+				AdsID:         adsID,
+				Channel:       schedule.Channel,
+				RequestedDate: date,
+				IsActive:      true,
+				Created:       time.Now().Local(),
+				CreatedBy:     username,
+				Modified:      time.Now().Local(),
+				ModifiedBy:    username,
+			})
+		}
+	}
+
+	// nothing to insert if the ads has no requested dates
+	if len(scheduleRequests) == 0 {
+		return nil
+	}
+
+	return tx.Create(&scheduleRequests).Error
+}
+
+// ScheduleAds is a function to allocate the posting slots of the given ads on the given channel dates,
+// the requested dates of the advertiser are used if no date is given, the conflicting dates are returned
+// and nothing is allocated if any of the dates is full or already allocated to the ads
+func (kost *Kost) ScheduleAds(currentUser *database.MasterUser, adsID uint, schedule entities.AdsSchedule) ([]database.DBKostAdsSlot, []string, error) {
+
+	var newSlots []database.DBKostAdsSlot
+	var conflicts []string
+
+	dates, err := ParseAdsSchedule(schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// allocate the slots with transaction scope
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// look for the target ads in the db
+		var targetAds database.DBKostAds
+		if dbErr := tx.Where("id = ?", adsID).First(&targetAds).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// only the approved or already scheduled ads can get a posting slot
+		if targetAds.Status != AdsStatusApproved && targetAds.Status != AdsStatusScheduled {
+			return NewError(ErrCodeValidation, "Iklan dengan status %s tidak bisa dijadwalkan", AdsStatusNames[targetAds.Status])
+		}
+
+		// the ads can only be posted on the channel of its package, up to the included posts of the package
+		adsPackage, dbErr := kost.GetAdsPackageByAds(tx, targetAds.ID)
+		if dbErr != nil {
+			return dbErr
+		}
+
+		if adsPackage.Channel != schedule.Channel {
+			return NewError(ErrCodeValidation, "Paket iklan %s hanya bisa diposting di channel %s", adsPackage.PackageName, adsPackage.Channel)
+		}
+
+		// fallback to the dates requested by the advertiser
+		if len(dates) == 0 {
+
+			var scheduleRequests []database.DBKostAdsScheduleRequest
+			if dbErr := tx.Where("ads_id = ? AND channel = ? AND is_active = ?", targetAds.ID, schedule.Channel, true).Order("requested_date").Find(&scheduleRequests).Error; dbErr != nil {
+				return dbErr
+			}
+
+			for _, request := range scheduleRequests {
+				dates = append(dates, request.RequestedDate)
+			}
+		}
+
+		if len(dates) == 0 {
+			return NewError(ErrCodeValidation, "Tanggal posting iklan harus diisi")
+		}
+
+		var allocatedCount int64
+		if dbErr := tx.Model(&database.DBKostAdsSlot{}).Where("ads_id = ?", targetAds.ID).Count(&allocatedCount).Error; dbErr != nil {
+			return dbErr
+		}
+
+		if uint(allocatedCount)+uint(len(dates)) > adsPackage.IncludedPosts {
+			return NewError(ErrCodeValidation, "Paket iklan %s hanya mendapat %d posting", adsPackage.PackageName, adsPackage.IncludedPosts)
+		}
+
+		for _, date := range dates {
+
+			// lock the allocated slots of the channel date so two admins can't take the same slot
+			var allocatedSlots []database.DBKostAdsSlot
+			if dbErr := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("channel = ? AND slot_date = ?", schedule.Channel, date.Format(adsScheduleDateLayout)).
+				Find(&allocatedSlots).Error; dbErr != nil {
+				return dbErr
+			}
+
+			var takenNumbers = make(map[uint]bool)
+			var alreadyAllocated bool
+			for _, slot := range allocatedSlots {
+				takenNumbers[slot.SlotNumber] = true
+				if slot.AdsID == targetAds.ID {
+					alreadyAllocated = true
+				}
+			}
+
+			if alreadyAllocated {
+				conflicts = append(conflicts, date.Format(adsScheduleDateLayout)+": iklan sudah dijadwalkan di tanggal ini")
+				continue
+			}
+
+			// look for the first free slot number of the channel date
+			var slotNumber uint
+			for number := uint(1); number <= uint(AdsSlotCapacity[schedule.Channel]); number++ {
+				if !takenNumbers[number] {
+					slotNumber = number
+					break
+				}
+			}
+
+			if slotNumber == 0 {
+				conflicts = append(conflicts, date.Format(adsScheduleDateLayout)+": slot "+schedule.Channel+" sudah penuh")
+				continue
+			}
+
+			newSlots = append(newSlots, database.DBKostAdsSlot{
+				AdsID:      targetAds.ID,
+				Channel:    schedule.Channel,
+				SlotDate:   date,
+				SlotNumber: slotNumber,
+				IsActive:   true,
+				Created:    time.Now().Local(),
+				CreatedBy:  currentUser.Username,
+				Modified:   time.Now().Local(),
+				ModifiedBy: currentUser.Username,
+			})
+		}
+
+		// allocate nothing if any of the dates conflicts
+		if len(conflicts) > 0 {
+			return nil
+		}
+
+		// insert the new slots to the database
+		if dbErr := tx.Create(&newSlots).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// the approved ads becomes scheduled once it gets its slots
+		if targetAds.Status == AdsStatusApproved {
+			return kost.transitionAdsStatus(tx, currentUser, &targetAds, AdsStatusScheduled, "")
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, nil, err
+	}
+
+	if len(conflicts) > 0 {
+
+		return nil, conflicts, nil
+	}
+
+	return newSlots, nil, nil
+}
+
+// releaseAdsSlots removes the allocated posting slots of the given ads within the given transaction
+func (kost *Kost) releaseAdsSlots(tx *gorm.DB, adsID uint) error {
+
+	return tx.Where("ads_id = ?", adsID).Delete(&database.DBKostAdsSlot{}).Error
+}
+
+// GetAdsCalendar is a function to get the weekly ads posting calendar of the week containing the given date,
+// the week starts on monday
+func (kost *Kost) GetAdsCalendar(date time.Time) ([]entities.AdsCalendarDay, error) {
+
+	// look for the monday of the given week
+	year, month, day := date.Date()
+	weekStart := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	weekStart = weekStart.AddDate(0, 0, -((int(weekStart.Weekday()) + 6) % 7))
+	weekEnd := weekStart.AddDate(0, 0, 6)
+
+	// make a custom model
+	type AllocatedSlot struct {
+		Channel    string
+		SlotDate   time.Time
+		SlotNumber uint
+		AdsID      uint
+		AdsCode    string
+		AdsOwner   string
+		Status     uint
+	}
+
+	// look for the allocated slots of the week in the db
+	var allocatedSlots []AllocatedSlot
+	if err := config.DB.
+		Model(&database.DBKostAdsSlot{}).
+		Select("db_kost_ads_slots.channel"+
+			",db_kost_ads_slots.slot_date"+
+			",db_kost_ads_slots.slot_number"+
+			",db_kost_ads_slots.ads_id"+
+			",db_kost_ads.ads_code"+
+			",db_kost_ads.ads_owner"+
+			",db_kost_ads.status").
+		Joins("inner join db_kost_ads on db_kost_ads.id = db_kost_ads_slots.ads_id").
+		Where("db_kost_ads_slots.slot_date BETWEEN ? AND ?", weekStart.Format(adsScheduleDateLayout), weekEnd.Format(adsScheduleDateLayout)).
+		Order("db_kost_ads_slots.slot_date, db_kost_ads_slots.channel, db_kost_ads_slots.slot_number").
+		Scan(&allocatedSlots).Error; err != nil {
+
+		return nil, err
+	}
+
+	// build the empty calendar of the week
+	var calendar []entities.AdsCalendarDay
+	var dayIndexes = make(map[string]int)
+	for i := 0; i < 7; i++ {
+
+		calendarDay := entities.AdsCalendarDay{
+			Date: weekStart.AddDate(0, 0, i).Format(adsScheduleDateLayout),
+		}
+
+		dayIndexes[calendarDay.Date] = i
+
+		for _, channel := range AdsChannels {
+			calendarDay.Channels = append(calendarDay.Channels, entities.AdsCalendarChannel{
+				Channel:  channel,
+				Capacity: AdsSlotCapacity[channel],
+				Slots:    []entities.AdsCalendarSlot{},
+			})
+		}
+
+		calendar = append(calendar, calendarDay)
+	}
+
+	// put the allocated slots into the calendar
+	for _, slot := range allocatedSlots {
+
+		dayIndex, ok := dayIndexes[slot.SlotDate.Format(adsScheduleDateLayout)]
+		if !ok {
+			continue
+		}
+
+		for channelIndex, channel := range AdsChannels {
+			if channel == slot.Channel {
+				channelSlots := &calendar[dayIndex].Channels[channelIndex].Slots
+				*channelSlots = append(*channelSlots, entities.AdsCalendarSlot{
+					SlotNumber: slot.SlotNumber,
+					AdsID:      slot.AdsID,
+					AdsCode:    slot.AdsCode,
+					AdsOwner:   slot.AdsOwner,
+					AdsStatus:  AdsStatusNames[slot.Status],
+				})
+			}
+		}
+	}
+
+	return calendar, nil
+}
@@ -0,0 +1,174 @@
+package data
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"gorm.io/gorm"
+)
+
+// the ads moderation lifecycle statuses
+const (
+	AdsStatusSubmitted uint = iota // submitted by the advertiser, waiting for the admin review
+	AdsStatusApproved              // approved by the admin, ready to be scheduled
+	AdsStatusScheduled             // scheduled to be posted
+	AdsStatusPosted                // posted on the social media
+	AdsStatusRejected              // rejected by the admin
+	AdsStatusExpired               // the ads period is over
+)
+
+// AdsStatusNames maps the ads status to its name
+var AdsStatusNames = map[uint]string{
+	AdsStatusSubmitted: "submitted",
+	AdsStatusApproved:  "approved",
+	AdsStatusScheduled: "scheduled",
+	AdsStatusPosted:    "posted",
+	AdsStatusRejected:  "rejected",
+	AdsStatusExpired:   "expired",
+}
+
+// adsStatusTransitions lists the allowed next statuses of every ads status
+var adsStatusTransitions = map[uint][]uint{
+	AdsStatusSubmitted: {AdsStatusApproved, AdsStatusRejected},
+	AdsStatusApproved:  {AdsStatusScheduled, AdsStatusRejected, AdsStatusExpired},
+	AdsStatusScheduled: {AdsStatusPosted, AdsStatusApproved, AdsStatusRejected, AdsStatusExpired},
+	AdsStatusPosted:    {AdsStatusExpired},
+	AdsStatusRejected:  {},
+	AdsStatusExpired:   {},
+}
+
+// PublicAdsStatuses is the statuses of the ads shown to the public, the submitted and rejected ads stay private
+// as they carry the advertiser contacts and the unreviewed media
+var PublicAdsStatuses = []uint{AdsStatusApproved, AdsStatusScheduled}
+
+// RequirePublicAds is a function to make sure the given ads is shown to the public, the private ads are reported as not found
+func (kost *Kost) RequirePublicAds(adsID uint) error {
+
+	var count int64
+	if err := config.DB.Model(&database.DBKostAds{}).Where("id = ? AND status IN ?", adsID, PublicAdsStatuses).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return NewError(ErrCodeNotFound, "Iklan tidak ditemukan")
+	}
+
+	return nil
+}
+
+// ParseAdsStatus is a function to get the ads status by the given status name
+func ParseAdsStatus(name string) (uint, error) {
+
+	for status, statusName := range AdsStatusNames {
+		if statusName == strings.ToLower(strings.TrimSpace(name)) {
+			return status, nil
+		}
+	}
+
+	return 0, NewError(ErrCodeValidation, "Status iklan %s tidak valid", name)
+}
+
+// ParseAdsStatusList is a function to get the ads statuses by the given comma separated status names
+func ParseAdsStatusList(names string) ([]uint, error) {
+
+	var statuses []uint
+	for _, name := range strings.Split(names, ",") {
+
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		status, err := ParseAdsStatus(name)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// IsValidAdsStatusTransition checks whether the ads can move from the given status to the next one
+func IsValidAdsStatusTransition(from uint, to uint) bool {
+
+	for _, next := range adsStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionAdsStatus is a function to move the given ads to the next status and log the transition
+func (kost *Kost) TransitionAdsStatus(currentUser *database.MasterUser, adsID uint, toStatus uint, reason string) (*database.DBKostAds, error) {
+
+	var targetAds database.DBKostAds
+
+	// update the ads status with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// look for the target ads in the db
+		if dbErr := tx.Where("id = ?", adsID).First(&targetAds).Error; dbErr != nil {
+			return dbErr
+		}
+
+		return kost.transitionAdsStatus(tx, currentUser, &targetAds, toStatus, reason)
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	return &targetAds, nil
+}
+
+// transitionAdsStatus moves the given ads to the next status and logs the transition within the given transaction
+func (kost *Kost) transitionAdsStatus(tx *gorm.DB, currentUser *database.MasterUser, targetAds *database.DBKostAds, toStatus uint, reason string) error {
+
+	if !IsValidAdsStatusTransition(targetAds.Status, toStatus) {
+		return NewError(ErrCodeValidation, "Status iklan tidak bisa diubah dari %s ke %s", AdsStatusNames[targetAds.Status], AdsStatusNames[toStatus])
+	}
+
+	// the advertiser must know why the ads is rejected
+	if toStatus == AdsStatusRejected && strings.TrimSpace(reason) == "" {
+		return NewError(ErrCodeValidation, "Alasan penolakan iklan harus diisi")
+	}
+
+	// release the allocated posting slots when the ads leaves the scheduled status without being posted
+	if targetAds.Status == AdsStatusScheduled && toStatus != AdsStatusPosted {
+		if dbErr := kost.releaseAdsSlots(tx, targetAds.ID); dbErr != nil {
+			return dbErr
+		}
+	}
+
+	statusLog := database.DBKostAdsStatusLog{
+		AdsID:      targetAds.ID,
+		FromStatus: targetAds.Status,
+		ToStatus:   toStatus,
+		Reason:     reason,
+		IsActive:   true,
+		Created:    time.Now().Local(),
+		CreatedBy:  currentUser.Username,
+		Modified:   time.Now().Local(),
+		ModifiedBy: currentUser.Username,
+	}
+
+	// insert the status log to the database
+	if dbErr := tx.Create(&statusLog).Error; dbErr != nil {
+		return dbErr
+	}
+
+	targetAds.Status = toStatus
+	targetAds.StatusReason = reason
+	targetAds.Modified = time.Now().Local()
+	targetAds.ModifiedBy = currentUser.Username
+
+	// update the ads
+	return tx.Save(targetAds).Error
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsValidAdsStatusTransition(t *testing.T) {
+
+	tests := []struct {
+		name string
+		from uint
+		to   uint
+		want bool
+	}{
+		{name: "submitted to approved", from: AdsStatusSubmitted, to: AdsStatusApproved, want: true},
+		{name: "submitted to rejected", from: AdsStatusSubmitted, to: AdsStatusRejected, want: true},
+		{name: "submitted can't skip the review", from: AdsStatusSubmitted, to: AdsStatusScheduled, want: false},
+		{name: "submitted can't be posted", from: AdsStatusSubmitted, to: AdsStatusPosted, want: false},
+		{name: "approved to scheduled", from: AdsStatusApproved, to: AdsStatusScheduled, want: true},
+		{name: "approved to expired", from: AdsStatusApproved, to: AdsStatusExpired, want: true},
+		{name: "approved can't be posted before scheduled", from: AdsStatusApproved, to: AdsStatusPosted, want: false},
+		{name: "scheduled to posted", from: AdsStatusScheduled, to: AdsStatusPosted, want: true},
+		{name: "scheduled back to approved", from: AdsStatusScheduled, to: AdsStatusApproved, want: true},
+		{name: "scheduled to rejected", from: AdsStatusScheduled, to: AdsStatusRejected, want: true},
+		{name: "posted to expired", from: AdsStatusPosted, to: AdsStatusExpired, want: true},
+		{name: "posted can't be rejected", from: AdsStatusPosted, to: AdsStatusRejected, want: false},
+		{name: "rejected is final", from: AdsStatusRejected, to: AdsStatusApproved, want: false},
+		{name: "expired is final", from: AdsStatusExpired, to: AdsStatusScheduled, want: false},
+		{name: "same status", from: AdsStatusApproved, to: AdsStatusApproved, want: false},
+		{name: "unknown status", from: 42, to: AdsStatusApproved, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsValidAdsStatusTransition(test.from, test.to); got != test.want {
+				t.Errorf("IsValidAdsStatusTransition(%s, %s) = %v, want %v", AdsStatusNames[test.from], AdsStatusNames[test.to], got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseAdsStatusList(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		names   string
+		want    []uint
+		wantErr bool
+	}{
+		{name: "empty", names: "", want: nil},
+		{name: "single", names: "approved", want: []uint{AdsStatusApproved}},
+		{name: "trimmed and case insensitive", names: " Scheduled , POSTED ", want: []uint{AdsStatusScheduled, AdsStatusPosted}},
+		{name: "empty entries are skipped", names: "submitted,,rejected,", want: []uint{AdsStatusSubmitted, AdsStatusRejected}},
+		{name: "unknown status", names: "approved,paid", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			got, err := ParseAdsStatusList(test.names)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseAdsStatusList(%q) error = %v, wantErr %v", test.names, err, test.wantErr)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseAdsStatusList(%q) = %v, want %v", test.names, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package data
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+)
+
+// APIKeyScope is a resource an api key is allowed to access
+type APIKeyScope string
+
+// the scopes of the api keys
+const (
+	ScopeReadAds         APIKeyScope = "read:ads"          // read the ads calendar, captions, bundles and metrics
+	ScopeReadKost        APIKeyScope = "read:kost"         // read the kost list and the kost details
+	ScopeWriteAdsMetrics APIKeyScope = "write:ads-metrics" // import the ads performance metrics
+)
+
+// APIKeyScopes is the available scopes of the api keys
+var APIKeyScopes = map[APIKeyScope]bool{
+	ScopeReadAds:         true,
+	ScopeReadKost:        true,
+	ScopeWriteAdsMetrics: true,
+}
+
+// APIKeyHeader is the request header carrying the api key
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyContextKey is a key used for the authenticated api key in the context
+type apiKeyContextKey struct{}
+
+// WithAPIKey returns a copy of the given context holding the given authenticated api key
+func WithAPIKey(ctx context.Context, apiKey *database.MasterAPIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, apiKey)
+}
+
+// APIKeyFromContext gets the authenticated api key from the given context, nil if the request is not authenticated by an api key
+func APIKeyFromContext(ctx context.Context) *database.MasterAPIKey {
+
+	apiKey, _ := ctx.Value(apiKeyContextKey{}).(*database.MasterAPIKey)
+
+	return apiKey
+}
+
+// GetAPIKey gets the api key from the header of the given request, empty if there is none
+func GetAPIKey(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(APIKeyHeader))
+}
+
+// HasAPIKeyScope checks whether the given api key is granted every given scope
+func HasAPIKeyScope(apiKey *database.MasterAPIKey, scopes ...APIKeyScope) bool {
+
+	granted := map[string]bool{}
+	for _, scope := range strings.Split(apiKey.Scopes, ",") {
+		granted[scope] = true
+	}
+
+	for _, scope := range scopes {
+		if !granted[string(scope)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IssueAPIKey is a function to issue a new api key with the given scopes,
+// the key is the key id and its secret joined by a dot and only its hash is stored
+func (kost *Kost) IssueAPIKey(currentUser *database.MasterUser, apiKeyReq *entities.APIKey) (*entities.IssuedAPIKey, error) {
+
+	apiKeyReq.KeyName = strings.TrimSpace(apiKeyReq.KeyName)
+	if apiKeyReq.KeyName == "" {
+		return nil, NewError(ErrCodeValidation, "Nama API key harus diisi")
+	}
+
+	if len(apiKeyReq.Scopes) == 0 {
+		return nil, NewError(ErrCodeValidation, "Scope API key harus diisi")
+	}
+
+	// drop the duplicated scopes and keep them in a stable order
+	scopeSet := map[string]bool{}
+	for _, scope := range apiKeyReq.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !APIKeyScopes[APIKeyScope(scope)] {
+			return nil, NewError(ErrCodeValidation, "Scope API key %s tidak valid", scope)
+		}
+
+		scopeSet[scope] = true
+	}
+
+	scopes := make([]string, 0, len(scopeSet))
+	for scope := range scopeSet {
+		scopes = append(scopes, scope)
+	}
+
+	sort.Strings(scopes)
+
+	if apiKeyReq.ExpiresAt != nil && !apiKeyReq.ExpiresAt.After(time.Now()) {
+		return nil, NewError(ErrCodeValidation, "Tanggal kedaluwarsa API key harus setelah hari ini")
+	}
+
+	keyID, err := generateAuthSecret(9)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateAuthSecret(32)
+	if err != nil {
+		return nil, err
+	}
+
+	newAPIKey := &database.MasterAPIKey{
+		KeyID:      keyID,
+		KeyName:    apiKeyReq.KeyName,
+		KeyHash:    hashAuthSecret(secret),
+		Scopes:     strings.Join(scopes, ","),
+		IsActive:   true,
+		Created:    time.Now().Local(),
+		CreatedBy:  currentUser.Username,
+		Modified:   time.Now().Local(),
+		ModifiedBy: currentUser.Username,
+	}
+
+	if apiKeyReq.ExpiresAt != nil {
+		expiresAt := apiKeyReq.ExpiresAt.Local()
+		newAPIKey.ExpiresAt = &expiresAt
+	}
+
+	// insert the new api key to the database
+	if err := config.DB.Create(newAPIKey).Error; err != nil {
+		return nil, err
+	}
+
+	return &entities.IssuedAPIKey{
+		ID:        newAPIKey.ID,
+		KeyID:     newAPIKey.KeyID,
+		KeyName:   newAPIKey.KeyName,
+		Key:       newAPIKey.KeyID + "." + secret,
+		Scopes:    scopes,
+		ExpiresAt: newAPIKey.ExpiresAt,
+	}, nil
+}
+
+// RevokeAPIKey is a function to revoke the given api key, the key is rejected right away
+func (kost *Kost) RevokeAPIKey(currentUser *database.MasterUser, id uint) error {
+
+	now := time.Now().Local()
+	result := config.DB.Model(&database.MasterAPIKey{}).Where("id = ? AND revoked_at IS NULL", id).Updates(map[string]interface{}{
+		"revoked_at":  now,
+		"is_active":   false,
+		"modified":    now,
+		"modified_by": currentUser.Username,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAPIKeyList is a function to get the issued api key list, the key hashes are never returned
+func (kost *Kost) GetAPIKeyList(includeRevoked bool) ([]database.MasterAPIKey, error) {
+
+	model := config.DB.Model(&database.MasterAPIKey{})
+	if !includeRevoked {
+		model = model.Where("revoked_at IS NULL")
+	}
+
+	apiKeys := []database.MasterAPIKey{}
+	if err := model.Order("id DESC").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+// AuthenticateAPIKey is a function to look for the active api key matching the given key
+func (kost *Kost) AuthenticateAPIKey(key string) (*database.MasterAPIKey, error) {
+
+	keyParts := strings.SplitN(key, ".", 2)
+	if len(keyParts) != 2 || keyParts[0] == "" || keyParts[1] == "" {
+		return nil, NewError(ErrCodeUnauthorized, "API key tidak valid")
+	}
+
+	var apiKey database.MasterAPIKey
+	if err := config.DB.Where("key_id = ? AND is_active = ? AND revoked_at IS NULL", keyParts[0], true).First(&apiKey).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NewError(ErrCodeUnauthorized, "API key tidak valid")
+		}
+
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAuthSecret(keyParts[1])), []byte(apiKey.KeyHash)) != 1 {
+		return nil, NewError(ErrCodeUnauthorized, "API key tidak valid")
+	}
+
+	now := time.Now().Local()
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now) {
+		return nil, NewError(ErrCodeUnauthorized, "API key sudah kedaluwarsa")
+	}
+
+	// the last usage is only informative, so failing to record it doesn't reject the request
+	if err := config.DB.Model(&apiKey).Update("last_used", now).Error; err != nil {
+		kost.logger.Error("Unable to record the api key usage", "key_id", apiKey.KeyID, "error", err.Error())
+	}
+
+	return &apiKey, nil
+}
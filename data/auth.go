@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// claimsContextKey is a key used for the validated token claims in the context
+type claimsContextKey struct{}
+
+// WithClaims returns a copy of the given context holding the given validated token claims
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext gets the validated token claims from the given context, nil if the request is not authenticated yet
+func ClaimsFromContext(ctx context.Context) *Claims {
+
+	claims, _ := ctx.Value(claimsContextKey{}).(*Claims)
+
+	return claims
+}
+
+// GetBearerToken gets the token from the authorization header of the given request, empty if there is none
+func GetBearerToken(r *http.Request) string {
+
+	authorization := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(authorization[7:])
+}
+
+// ParseToken is a function to parse and validate the given jwt signed with the service signing key
+func ParseToken(tokenString string) (*Claims, error) {
+
+	// Initialize a new instance of claims
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Error while parsing the token with claims")
+		}
+
+		return []byte(MySigningKey), nil
+	})
+
+	if err != nil {
+		if IsTokenExpired(err) {
+			return nil, WrapError(ErrCodeUnauthorized, err, "Token sudah kedaluwarsa")
+		}
+
+		return nil, WrapError(ErrCodeUnauthorized, err, "Token tidak valid")
+	}
+
+	if !token.Valid {
+		return nil, NewError(ErrCodeUnauthorized, "Token tidak valid")
+	}
+
+	return claims, nil
+}
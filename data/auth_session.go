@@ -0,0 +1,345 @@
+package data
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccessTokenTTL is the lifetime of the access token
+var AccessTokenTTL = 15 * time.Minute
+
+// RefreshTokenTTL is the lifetime of the refresh token, every refresh rotates it and starts a new lifetime
+var RefreshTokenTTL = 7 * 24 * time.Hour
+
+// SessionMaxLifetime is the absolute lifetime of a session since the login, the user must login again after it
+var SessionMaxLifetime = 30 * 24 * time.Hour
+
+// refreshTokenReuseGrace is how long the just rotated refresh token can still be used, so the parallel requests
+// refreshing the same expired access token get the already rotated token pair instead of revoking the session
+const refreshTokenReuseGrace = 30 * time.Second
+
+// revokedAuthSessions is the in memory revocation list checked on every request,
+// it holds the revoked session ids until their absolute expiry
+var revokedAuthSessions = struct {
+	sync.RWMutex
+	sessions map[string]time.Time
+}{sessions: map[string]time.Time{}}
+
+// IsTokenExpired checks whether the given token parsing error is caused by the expired token only
+func IsTokenExpired(err error) bool {
+
+	var validationErr *jwt.ValidationError
+
+	return errors.As(err, &validationErr) && validationErr.Errors == jwt.ValidationErrorExpired
+}
+
+// SignAccessToken is a function to sign a new access token of the given claims expiring at the given time
+func SignAccessToken(claims *Claims, expiresAt time.Time) (string, error) {
+
+	claims.StandardClaims.IssuedAt = time.Now().Unix()
+	claims.StandardClaims.ExpiresAt = expiresAt.Unix()
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(MySigningKey))
+}
+
+// generateAuthSecret generates a random url safe secret of the given byte size
+func generateAuthSecret(size int) (string, error) {
+
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashAuthSecret gets the stored hash of the given refresh token or api key secret
+func hashAuthSecret(secret string) string {
+
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// rotateAuthSecret derives the next refresh token secret from the given one, the rotated secret can only be derived
+// with the app secret and deriving it again from the previous secret gives the same rotated token pair
+func rotateAuthSecret(sessionID, secret string) string {
+
+	mac := hmac.New(sha256.New, []byte(MySigningKey))
+	mac.Write([]byte(sessionID + "." + secret))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// earliestTime gets the earlier of the given times
+func earliestTime(a, b time.Time) time.Time {
+
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+// newAuthToken signs the access token and builds the token pair of the given session,
+// the refresh token is the session id and its secret joined by a dot
+func newAuthToken(authSession *database.DBAuthSession, secret string) (*entities.AuthToken, *Claims, error) {
+
+	now := time.Now()
+	accessExpiresAt := earliestTime(now.Add(AccessTokenTTL), authSession.AbsoluteExpiresAt)
+
+	claims := &Claims{
+		Username:  authSession.Username,
+		UserID:    authSession.UserID,
+		RoleID:    authSession.RoleID,
+		SessionID: authSession.SessionID,
+	}
+
+	accessToken, err := SignAccessToken(claims, accessExpiresAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &entities.AuthToken{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(accessExpiresAt.Sub(now).Seconds()),
+		RefreshToken:     authSession.SessionID + "." + secret,
+		RefreshExpiresIn: int64(authSession.RefreshExpiresAt.Sub(now).Seconds()),
+	}, claims, nil
+}
+
+// IssueAuthSession is a function to start a new session of the given user and issue its first token pair
+func (kost *Kost) IssueAuthSession(user *database.MasterUser) (*entities.AuthToken, *Claims, error) {
+
+	sessionID, err := generateAuthSecret(18)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := generateAuthSecret(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().Local()
+	absoluteExpiresAt := now.Add(SessionMaxLifetime)
+
+	authSession := &database.DBAuthSession{
+		SessionID:         sessionID,
+		UserID:            user.ID,
+		RoleID:            user.RoleID,
+		Username:          user.Username,
+		RefreshTokenHash:  hashAuthSecret(secret),
+		RefreshExpiresAt:  earliestTime(now.Add(RefreshTokenTTL), absoluteExpiresAt),
+		AbsoluteExpiresAt: absoluteExpiresAt,
+		LastRefreshed:     now,
+		Created:           now,
+		CreatedBy:         user.Username,
+		Modified:          now,
+		ModifiedBy:        user.Username,
+	}
+
+	if err := config.DB.Create(authSession).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return newAuthToken(authSession, secret)
+}
+
+// RefreshAuthSession is a function to issue a new token pair from the given refresh token, the refresh token is rotated
+// and reusing an already rotated one revokes the whole session unless it was just rotated by a parallel request,
+// the user is reloaded so the role changes are applied and the session of the inactive user is revoked
+func (kost *Kost) RefreshAuthSession(refreshToken string) (*entities.AuthToken, *Claims, error) {
+
+	tokenParts := strings.SplitN(strings.TrimSpace(refreshToken), ".", 2)
+	if len(tokenParts) != 2 || tokenParts[0] == "" || tokenParts[1] == "" {
+		return nil, nil, NewError(ErrCodeUnauthorized, "Refresh token tidak valid")
+	}
+
+	sessionID, secret := tokenParts[0], tokenParts[1]
+
+	var authToken *entities.AuthToken
+	var claims *Claims
+	var revokeReason string
+
+	// rotate the refresh token with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// lock the session so the concurrent refreshes are applied one after another
+		var authSession database.DBAuthSession
+		if dbErr := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("session_id = ?", sessionID).First(&authSession).Error; dbErr != nil {
+			if dbErr == gorm.ErrRecordNotFound {
+				return NewError(ErrCodeUnauthorized, "Refresh token tidak valid")
+			}
+
+			return dbErr
+		}
+
+		now := time.Now().Local()
+		if authSession.RevokedAt != nil {
+			return NewError(ErrCodeUnauthorized, "Sesi sudah berakhir, silakan login kembali")
+		}
+
+		if !authSession.AbsoluteExpiresAt.After(now) || !authSession.RefreshExpiresAt.After(now) {
+			return NewError(ErrCodeUnauthorized, "Sesi sudah kedaluwarsa, silakan login kembali")
+		}
+
+		// reload the user so the demoted or deactivated user doesn't keep its old role until the session expires
+		var user database.MasterUser
+		if dbErr := tx.Where("id = ?", authSession.UserID).First(&user).Error; dbErr != nil && dbErr != gorm.ErrRecordNotFound {
+			return dbErr
+		}
+
+		if user.ID == 0 || !user.IsActive {
+			revokeReason = "inactive user"
+
+			return NewError(ErrCodeUnauthorized, "Sesi sudah berakhir, silakan login kembali")
+		}
+
+		authSession.RoleID = user.RoleID
+		authSession.Username = user.Username
+
+		newSecret := rotateAuthSecret(sessionID, secret)
+		if subtle.ConstantTimeCompare([]byte(hashAuthSecret(secret)), []byte(authSession.RefreshTokenHash)) != 1 {
+
+			// the refresh token just rotated by a parallel request gets the same rotated token pair
+			if now.Sub(authSession.LastRefreshed) <= refreshTokenReuseGrace &&
+				subtle.ConstantTimeCompare([]byte(hashAuthSecret(newSecret)), []byte(authSession.RefreshTokenHash)) == 1 {
+
+				var tokenErr error
+				authToken, claims, tokenErr = newAuthToken(&authSession, newSecret)
+
+				return tokenErr
+			}
+
+			revokeReason = "refresh token reuse"
+
+			return NewError(ErrCodeUnauthorized, "Refresh token sudah tidak berlaku, silakan login kembali")
+		}
+
+		authSession.RefreshTokenHash = hashAuthSecret(newSecret)
+		authSession.RefreshExpiresAt = earliestTime(now.Add(RefreshTokenTTL), authSession.AbsoluteExpiresAt)
+		authSession.LastRefreshed = now
+		authSession.Modified = now
+		authSession.ModifiedBy = authSession.Username
+
+		if dbErr := tx.Save(&authSession).Error; dbErr != nil {
+			return dbErr
+		}
+
+		var tokenErr error
+		authToken, claims, tokenErr = newAuthToken(&authSession, newSecret)
+
+		return tokenErr
+	})
+
+	// the rotated refresh token is being reused so it may be stolen, or the user is no longer active
+	if revokeReason != "" {
+		if revokeErr := kost.RevokeAuthSession(sessionID, revokeReason, "System"); revokeErr != nil {
+			kost.logger.Error("Unable to revoke the auth session", "reason", revokeReason, "error", revokeErr.Error())
+		}
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return authToken, claims, nil
+}
+
+// RevokeAuthSession is a function to revoke the given session, its access tokens are rejected right away
+// and its refresh token can't be used anymore
+func (kost *Kost) RevokeAuthSession(sessionID, reason, revokedBy string) error {
+
+	var authSession database.DBAuthSession
+	if err := config.DB.Where("session_id = ?", sessionID).First(&authSession).Error; err != nil {
+		return err
+	}
+
+	now := time.Now().Local()
+	if authSession.RevokedAt == nil {
+		if err := config.DB.Model(&authSession).Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"revoked_reason": reason,
+			"modified":       now,
+			"modified_by":    revokedBy,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	revokedAuthSessions.Lock()
+	revokedAuthSessions.sessions[sessionID] = authSession.AbsoluteExpiresAt
+	revokedAuthSessions.Unlock()
+
+	return nil
+}
+
+// IsAuthSessionRevoked checks whether the given session is in the revocation list
+func IsAuthSessionRevoked(sessionID string) bool {
+
+	revokedAuthSessions.RLock()
+	_, revoked := revokedAuthSessions.sessions[sessionID]
+	revokedAuthSessions.RUnlock()
+
+	return revoked
+}
+
+// SyncRevokedAuthSessions is a function to reload the revocation list from the db,
+// so the sessions revoked by the other instances are rejected as well
+func (kost *Kost) SyncRevokedAuthSessions() (int, error) {
+
+	var authSessions []database.DBAuthSession
+	if err := config.DB.Select("session_id, absolute_expires_at").
+		Where("revoked_at IS NOT NULL AND absolute_expires_at > ?", time.Now().Local()).
+		Find(&authSessions).Error; err != nil {
+		return 0, err
+	}
+
+	sessions := make(map[string]time.Time, len(authSessions))
+	for _, authSession := range authSessions {
+		sessions[authSession.SessionID] = authSession.AbsoluteExpiresAt
+	}
+
+	revokedAuthSessions.Lock()
+	revokedAuthSessions.sessions = sessions
+	revokedAuthSessions.Unlock()
+
+	return len(sessions), nil
+}
+
+// RunAuthRevocationSyncJob is a function to periodically reload the revocation list until the given context is done
+func (kost *Kost) RunAuthRevocationSyncJob(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := kost.SyncRevokedAuthSessions(); err != nil {
+			kost.logger.Error("Unable to sync the revoked auth sessions", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
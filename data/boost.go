@@ -0,0 +1,457 @@
+package data
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// the kost boost statuses, the applied status comes first as the boosts granted before the owner requests are applied
+const (
+	KostBoostStatusApplied  uint = iota // applied to the kost
+	KostBoostStatusPending              // requested by the owner, waiting for the admin to confirm the payment
+	KostBoostStatusRejected             // rejected by the admin
+)
+
+// KostBoostStatusNames maps the kost boost status to its name
+var KostBoostStatusNames = map[uint]string{
+	KostBoostStatusApplied:  "applied",
+	KostBoostStatusPending:  "pending",
+	KostBoostStatusRejected: "rejected",
+}
+
+// ParseKostBoostStatus is a function to get the kost boost status by the given status name
+func ParseKostBoostStatus(name string) (uint, error) {
+
+	for status, statusName := range KostBoostStatusNames {
+		if statusName == strings.ToLower(strings.TrimSpace(name)) {
+			return status, nil
+		}
+	}
+
+	return 0, NewError(ErrCodeValidation, "Status boost %s tidak valid", name)
+}
+
+// BoostedKostOrder is the order clause ranking the kosts with an unexpired boost first by their up rate,
+// the rest are ordered by id as before
+func BoostedKostOrder() clause.OrderBy {
+	return clause.OrderBy{
+		Expression: clause.Expr{
+			SQL:  "CASE WHEN db_kosts.up_rate_expired > ? THEN db_kosts.up_rate ELSE 0 END DESC, db_kosts.id",
+			Vars: []interface{}{time.Now().Local()},
+		},
+	}
+}
+
+// ActiveUpRate is a function to get the up rate of the given kost, the expired boost counts as zero
+func ActiveUpRate(targetKost *entities.Kost) uint64 {
+
+	if targetKost.UpRateExpired.After(time.Now().Local()) {
+		return targetKost.UpRate
+	}
+
+	return 0
+}
+
+// ValidateBoostPackage is a function to validate the given boost package, the given package id is excluded from the unique name check
+func (kost *Kost) ValidateBoostPackage(boostPackage *entities.MasterBoostPackage, packageID uint) error {
+
+	boostPackage.PackageName = strings.TrimSpace(boostPackage.PackageName)
+	if boostPackage.PackageName == "" {
+		return NewError(ErrCodeValidation, "Nama paket boost harus diisi")
+	}
+
+	if boostPackage.UpRate == 0 || boostPackage.DurationDays == 0 {
+		return NewError(ErrCodeValidation, "Up rate dan durasi paket boost harus lebih dari 0")
+	}
+
+	if boostPackage.Price < 0 {
+		return NewError(ErrCodeValidation, "Harga paket boost tidak valid")
+	}
+
+	// look for the requested uom from the database
+	var targetPriceUOM database.MasterUOM
+	if err := config.DB.Where("id = ?", boostPackage.PriceUOM).First(&targetPriceUOM).Error; err != nil {
+		return err
+	}
+
+	// check the uom type, if not currency return error
+	if targetPriceUOM.UOMType != "currency" {
+		return NewError(ErrCodeValidation, "Tipe UOM tidak valid")
+	}
+
+	var count int64
+	if err := config.DB.Model(&database.MasterBoostPackage{}).Where("package_name = ? AND id <> ?", boostPackage.PackageName, packageID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return NewError(ErrCodeConflict, "Nama paket boost %s sudah digunakan", boostPackage.PackageName)
+	}
+
+	return nil
+}
+
+// AddBoostPackage is a function to add a new boost package to the catalogue
+func (kost *Kost) AddBoostPackage(currentUser *database.MasterUser, boostPackage *entities.MasterBoostPackage) (*database.MasterBoostPackage, error) {
+
+	if err := kost.ValidateBoostPackage(boostPackage, 0); err != nil {
+		return nil, err
+	}
+
+	newBoostPackage := &database.MasterBoostPackage{
+		PackageName:  boostPackage.PackageName,
+		UpRate:       boostPackage.UpRate,
+		DurationDays: boostPackage.DurationDays,
+		Price:        boostPackage.Price,
+		PriceUOM:     boostPackage.PriceUOM,
+		IsActive:     true,
+		Created:      time.Now().Local(),
+		CreatedBy:    currentUser.Username,
+		Modified:     time.Now().Local(),
+		ModifiedBy:   currentUser.Username,
+	}
+
+	// insert the new boost package to the database
+	if err := config.DB.Create(newBoostPackage).Error; err != nil {
+		return nil, err
+	}
+
+	return newBoostPackage, nil
+}
+
+// UpdateBoostPackage is a function to update the given boost package of the catalogue, the already granted boosts are kept as is
+func (kost *Kost) UpdateBoostPackage(currentUser *database.MasterUser, packageID uint, boostPackage *entities.MasterBoostPackage) (*database.MasterBoostPackage, error) {
+
+	// look for the target boost package in the db
+	targetBoostPackage := &database.MasterBoostPackage{}
+	if err := config.DB.Where("id = ?", packageID).First(targetBoostPackage).Error; err != nil {
+		return nil, err
+	}
+
+	if err := kost.ValidateBoostPackage(boostPackage, packageID); err != nil {
+		return nil, err
+	}
+
+	targetBoostPackage.PackageName = boostPackage.PackageName
+	targetBoostPackage.UpRate = boostPackage.UpRate
+	targetBoostPackage.DurationDays = boostPackage.DurationDays
+	targetBoostPackage.Price = boostPackage.Price
+	targetBoostPackage.PriceUOM = boostPackage.PriceUOM
+	targetBoostPackage.IsActive = boostPackage.IsActive
+	targetBoostPackage.Modified = time.Now().Local()
+	targetBoostPackage.ModifiedBy = currentUser.Username
+
+	// update the boost package
+	if err := config.DB.Save(targetBoostPackage).Error; err != nil {
+		return nil, err
+	}
+
+	return targetBoostPackage, nil
+}
+
+// GetBoostPackageList is a function to get the boost package catalogue
+func (kost *Kost) GetBoostPackageList(includeInactive bool) ([]entities.MasterBoostPackage, error) {
+
+	model := config.DB.
+		Model(&database.MasterBoostPackage{}).
+		Select("master_boost_packages.id" +
+			",master_boost_packages.package_name" +
+			",master_boost_packages.up_rate" +
+			",master_boost_packages.duration_days" +
+			",master_boost_packages.price" +
+			",master_boost_packages.price_uom" +
+			",master_uoms.uom_desc as price_uom_desc" +
+			",master_boost_packages.is_active").
+		Joins("inner join master_uoms on master_uoms.id = master_boost_packages.price_uom")
+
+	if !includeInactive {
+		model = model.Where("master_boost_packages.is_active = ?", true)
+	}
+
+	var boostPackages []entities.MasterBoostPackage
+	if err := model.Order("master_boost_packages.price").Scan(&boostPackages).Error; err != nil {
+		return nil, err
+	}
+
+	return boostPackages, nil
+}
+
+// ApplyKostBoost is a function to grant the given boost package to the given kost right away, e.g. as a promo,
+// granting a boost while the previous one is still running extends it and keeps the higher up rate
+func (kost *Kost) ApplyKostBoost(currentUser *database.MasterUser, kostID uint, packageID uint) (*database.DBKostBoost, error) {
+
+	var newKostBoost database.DBKostBoost
+
+	// apply the boost with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		boostPackage, dbErr := getActiveBoostPackage(tx, packageID)
+		if dbErr != nil {
+			return dbErr
+		}
+
+		newKostBoost = newKostBoostFromPackage(currentUser, kostID, boostPackage)
+
+		return kost.applyKostBoost(tx, currentUser, &newKostBoost)
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	return &newKostBoost, nil
+}
+
+// RequestKostBoost is a function to request the given boost package for the given kost by its owner,
+// the boost stays pending and the up rate is untouched until the admin confirms it once the owner paid for it
+func (kost *Kost) RequestKostBoost(currentUser *database.MasterUser, kostID uint, packageID uint) (*database.DBKostBoost, error) {
+
+	var newKostBoost database.DBKostBoost
+
+	// request the boost with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		boostPackage, dbErr := getActiveBoostPackage(tx, packageID)
+		if dbErr != nil {
+			return dbErr
+		}
+
+		// lock the kost so the concurrent requests of the same kost are checked one after another
+		var targetKost database.DBKost
+		if dbErr := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", kostID).First(&targetKost).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// only a single request per kost is waiting for the payment at a time
+		var count int64
+		if dbErr := tx.Model(&database.DBKostBoost{}).Where("kost_id = ? AND status = ?", kostID, KostBoostStatusPending).Count(&count).Error; dbErr != nil {
+			return dbErr
+		}
+
+		if count > 0 {
+			return NewError(ErrCodeConflict, "Pengajuan boost kost ini masih menunggu konfirmasi")
+		}
+
+		newKostBoost = newKostBoostFromPackage(currentUser, kostID, boostPackage)
+		newKostBoost.Status = KostBoostStatusPending
+		newKostBoost.IsActive = false
+
+		return tx.Create(&newKostBoost).Error
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	return &newKostBoost, nil
+}
+
+// ConfirmKostBoost is a function to confirm the given pending boost once the owner paid for it, the boost is applied
+// from the confirmation time with the price and duration of the package at the time it was requested
+func (kost *Kost) ConfirmKostBoost(currentUser *database.MasterUser, boostID uint) (*database.DBKostBoost, error) {
+
+	var targetKostBoost database.DBKostBoost
+
+	// confirm the boost with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		if dbErr := getPendingKostBoost(tx, boostID, &targetKostBoost); dbErr != nil {
+			return dbErr
+		}
+
+		return kost.applyKostBoost(tx, currentUser, &targetKostBoost)
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	return &targetKostBoost, nil
+}
+
+// RejectKostBoost is a function to reject the given pending boost, e.g. when the owner never paid for it
+func (kost *Kost) RejectKostBoost(currentUser *database.MasterUser, boostID uint) (*database.DBKostBoost, error) {
+
+	var targetKostBoost database.DBKostBoost
+
+	// reject the boost with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		if dbErr := getPendingKostBoost(tx, boostID, &targetKostBoost); dbErr != nil {
+			return dbErr
+		}
+
+		targetKostBoost.Status = KostBoostStatusRejected
+		targetKostBoost.Modified = time.Now().Local()
+		targetKostBoost.ModifiedBy = currentUser.Username
+
+		return tx.Save(&targetKostBoost).Error
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	return &targetKostBoost, nil
+}
+
+// GetKostBoostList is a function to get the kost boosts of the given status, the oldest first
+func (kost *Kost) GetKostBoostList(status uint) ([]database.DBKostBoost, error) {
+
+	var kostBoosts []database.DBKostBoost
+	if err := config.DB.Where("status = ?", status).Order("created").Find(&kostBoosts).Error; err != nil {
+		return nil, err
+	}
+
+	return kostBoosts, nil
+}
+
+// getActiveBoostPackage looks for the given active boost package within the given transaction
+func getActiveBoostPackage(tx *gorm.DB, packageID uint) (*database.MasterBoostPackage, error) {
+
+	var boostPackage database.MasterBoostPackage
+	if err := tx.Where("id = ? AND is_active = ?", packageID, true).First(&boostPackage).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NewError(ErrCodeNotFound, "Paket boost tidak ditemukan")
+		}
+
+		return nil, err
+	}
+
+	return &boostPackage, nil
+}
+
+// getPendingKostBoost locks the given pending boost within the given transaction
+func getPendingKostBoost(tx *gorm.DB, boostID uint, targetKostBoost *database.DBKostBoost) error {
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", boostID).First(targetKostBoost).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return NewError(ErrCodeNotFound, "Pengajuan boost tidak ditemukan")
+		}
+
+		return err
+	}
+
+	if targetKostBoost.Status != KostBoostStatusPending {
+		return NewError(ErrCodeConflict, "Pengajuan boost sudah diproses")
+	}
+
+	return nil
+}
+
+// newKostBoostFromPackage builds the kost boost of the given package starting now, the price and the window
+// are copied so the later package changes don't affect it
+func newKostBoostFromPackage(currentUser *database.MasterUser, kostID uint, boostPackage *database.MasterBoostPackage) database.DBKostBoost {
+
+	now := time.Now().Local()
+
+	return database.DBKostBoost{
+		KostID:         kostID,
+		BoostPackageID: boostPackage.ID,
+		UpRate:         boostPackage.UpRate,
+		Price:          boostPackage.Price,
+		PriceUOM:       boostPackage.PriceUOM,
+		StartDate:      now,
+		ExpiredDate:    now.AddDate(0, 0, int(boostPackage.DurationDays)),
+		Status:         KostBoostStatusApplied,
+		IsActive:       true,
+		Created:        now,
+		CreatedBy:      currentUser.Username,
+		Modified:       now,
+		ModifiedBy:     currentUser.Username,
+	}
+}
+
+// applyKostBoost saves the given boost as applied and raises the up rate of its kost within the given transaction,
+// the boost window keeps its length but starts now, or when the running boost of the kost ends
+func (kost *Kost) applyKostBoost(tx *gorm.DB, currentUser *database.MasterUser, kostBoost *database.DBKostBoost) error {
+
+	// lock the kost so the concurrent grants are applied one after another
+	var targetKost database.DBKost
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", kostBoost.KostID).First(&targetKost).Error; err != nil {
+		return err
+	}
+
+	now := time.Now().Local()
+	duration := kostBoost.ExpiredDate.Sub(kostBoost.StartDate)
+	startDate := now
+	upRate := kostBoost.UpRate
+
+	// the running boost is extended instead of replaced
+	if targetKost.UpRate > 0 && targetKost.UpRateExpired.After(now) {
+		startDate = targetKost.UpRateExpired
+		if targetKost.UpRate > upRate {
+			upRate = targetKost.UpRate
+		}
+	}
+
+	kostBoost.StartDate = startDate
+	kostBoost.ExpiredDate = startDate.Add(duration)
+	kostBoost.Status = KostBoostStatusApplied
+	kostBoost.IsActive = true
+	kostBoost.Modified = now
+	kostBoost.ModifiedBy = currentUser.Username
+
+	// the granted boost is created while the confirmed one is updated
+	if err := tx.Save(kostBoost).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&database.DBKost{}).Where("id = ?", kostBoost.KostID).Updates(map[string]interface{}{
+		"up_rate":         upRate,
+		"up_rate_expired": kostBoost.ExpiredDate,
+		"modified":        now,
+		"modified_by":     currentUser.Username,
+	}).Error
+}
+
+// ResetExpiredBoosts is a function to reset the up rate of the kosts whose boost is already expired
+func (kost *Kost) ResetExpiredBoosts() (int64, error) {
+
+	result := config.DB.Model(&database.DBKost{}).
+		Where("up_rate > ? AND up_rate_expired <= ?", 0, time.Now().Local()).
+		Updates(map[string]interface{}{
+			"up_rate":     0,
+			"modified":    time.Now().Local(),
+			"modified_by": "System",
+		})
+
+	return result.RowsAffected, result.Error
+}
+
+// RunBoostExpiryJob is a function to periodically reset the expired boosts until the given context is done
+func (kost *Kost) RunBoostExpiryJob(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		resetCount, err := kost.ResetExpiredBoosts()
+		if err != nil {
+			kost.logger.Error("Unable to reset the expired kost boosts", "error", err.Error())
+		} else if resetCount > 0 {
+			kost.logger.Info("Reset the expired kost boosts", "count", resetCount)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
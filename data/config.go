@@ -5,6 +5,7 @@ import (
 
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -23,6 +24,36 @@ func ConfigInit(config *entities.Configuration) error {
 		environment = "production"
 	}
 
+	// the default ads posting slot capacity per day of every channel
+	viper.SetDefault("ads.igfeeddailyslots", 3)
+	viper.SetDefault("ads.igstorydailyslots", 5)
+	viper.SetDefault("ads.tiktokdailyslots", 2)
+
+	// the default abuse protection settings of the ads submission
+	viper.SetDefault("ads.submission.maxrequestsizemb", 50)
+	viper.SetDefault("ads.submission.maxfiles", 10)
+	viper.SetDefault("ads.submission.periphourly", 5)
+	viper.SetDefault("ads.submission.perphonedaily", 3)
+	viper.SetDefault("ads.submission.powdifficulty", 18)
+	viper.SetDefault("ads.submission.duplicatewindowhours", 24)
+
+	// the default token lifetimes, the session can't be refreshed past its max days since the login
+	viper.SetDefault("jwt.accesstokenminutes", 15)
+	viper.SetDefault("jwt.refreshtokendays", 7)
+	viper.SetDefault("jwt.sessionmaxdays", 30)
+
+	// the default role ids of the master users, they must match the role_id values set by the auth service,
+	// the admin role id has no default and must be set in the config file or by the ROLES_ADMIN env
+	if err := viper.BindEnv("roles.admin"); err != nil {
+		return err
+	}
+	viper.SetDefault("roles.owner", 1)
+	viper.SetDefault("roles.tenant", 2)
+	viper.SetDefault("roles.socialteam", 3)
+
+	// the request rate limit is enabled by default, the rules are set in the config file
+	viper.SetDefault("ratelimit.enabled", true)
+
 	viper.SetConfigName("config." + environment)
 	viper.AddConfigPath("./config")
 	viper.AutomaticEnv()
@@ -39,6 +70,51 @@ func ConfigInit(config *entities.Configuration) error {
 		return err
 	}
 
+	// set the ads posting slot capacity per channel
+	AdsSlotCapacity = map[string]int{
+		AdsChannelIGFeed:  config.Ads.IGFeedDailySlots,
+		AdsChannelIGStory: config.Ads.IGStoryDailySlots,
+		AdsChannelTiktok:  config.Ads.TiktokDailySlots,
+	}
+
+	// override the caption character limit of the configured channels
+	for channel, limit := range config.Ads.CaptionLimits {
+		if _, ok := AdsCaptionLimit[channel]; ok && limit > 0 {
+			AdsCaptionLimit[channel] = limit
+		}
+	}
+
+	// set the abuse protection settings of the ads submission, its rate limits are kept by the rate limit store
+	AdsSubmission = config.Ads.Submission
+	RateLimitRules[RateLimitAdsIP] = entities.RateLimitRule{Burst: AdsSubmission.PerIPHourly, Window: time.Hour}
+	RateLimitRules[RateLimitAdsPhone] = entities.RateLimitRule{Burst: AdsSubmission.PerPhoneDaily, Window: 24 * time.Hour}
+
+	// set the token lifetimes
+	AccessTokenTTL = time.Duration(config.Jwt.AccessTokenMinutes) * time.Minute
+	RefreshTokenTTL = time.Duration(config.Jwt.RefreshTokenDays) * 24 * time.Hour
+	SessionMaxLifetime = time.Duration(config.Jwt.SessionMaxDays) * 24 * time.Hour
+
+	// set the reverse proxies whose X-Forwarded-For header is trusted to get the client ip
+	if err := SetTrustedProxies(config.API.TrustedProxies); err != nil {
+		return err
+	}
+
+	// set the role ids of the master users the permissions are granted to
+	if err := SetRoles(config.Roles); err != nil {
+		return err
+	}
+
+	// override the rate limit of the configured routes
+	RateLimitEnabled = config.RateLimit.Enabled
+	for name, rule := range config.RateLimit.Rules {
+		RateLimitRules[name] = rule
+	}
+
+	// parse the caption templates so the invalid ones are caught on start up
+	if err := SetAdsCaptionTemplates(config.Ads.CaptionTemplates); err != nil {
+		return err
+	}
+
 	// define whether its for production or development
 	if config.API.Environment == "development" {
 		MySigningKey = config.Jwt.Secret
@@ -0,0 +1,120 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrorCode is the machine readable code of the error returned to the client
+type ErrorCode string
+
+// the codes of the errors returned to the client
+const (
+	ErrCodeBadRequest      ErrorCode = "bad_request"       // the request can't be read
+	ErrCodeValidation      ErrorCode = "validation_failed" // the request is read but its content is not valid
+	ErrCodeUnauthorized    ErrorCode = "unauthorized"      // the request is not authenticated
+	ErrCodeForbidden       ErrorCode = "forbidden"         // the authenticated client is not allowed to do the action
+	ErrCodeNotFound        ErrorCode = "not_found"         // the requested data doesn't exist
+	ErrCodeConflict        ErrorCode = "conflict"          // the request conflicts with the existing data
+	ErrCodePayloadTooLarge ErrorCode = "payload_too_large" // the request body exceeds its size cap
+	ErrCodeTooManyRequests ErrorCode = "too_many_requests" // the client is rate limited
+	ErrCodeInternal        ErrorCode = "internal_error"    // the server fails to process the request
+)
+
+// errorCodeStatus is the http status of the error codes
+var errorCodeStatus = map[ErrorCode]int{
+	ErrCodeBadRequest:      http.StatusBadRequest,
+	ErrCodeValidation:      http.StatusBadRequest,
+	ErrCodeUnauthorized:    http.StatusUnauthorized,
+	ErrCodeForbidden:       http.StatusForbidden,
+	ErrCodeNotFound:        http.StatusNotFound,
+	ErrCodeConflict:        http.StatusConflict,
+	ErrCodePayloadTooLarge: http.StatusRequestEntityTooLarge,
+	ErrCodeTooManyRequests: http.StatusTooManyRequests,
+	ErrCodeInternal:        http.StatusInternalServerError,
+}
+
+// AppError is an error safe to be returned to the client, its message format is in indonesian
+// and is the key of its translations as well
+type AppError struct {
+	Code   ErrorCode
+	Status int
+	Format string
+	Args   []interface{}
+
+	// Fields is the errors of the invalid request payload fields, if any
+	Fields []FieldError
+
+	// Err is the cause of the error, it is logged but never returned to the client
+	Err error
+}
+
+// NewError is a function to create new AppError struct with the given code and message
+func NewError(code ErrorCode, format string, args ...interface{}) *AppError {
+	return &AppError{
+		Code:   code,
+		Status: errorCodeStatus[code],
+		Format: format,
+		Args:   args,
+	}
+}
+
+// WrapError is a function to create new AppError struct hiding the given cause behind the given message
+func WrapError(code ErrorCode, err error, format string, args ...interface{}) *AppError {
+
+	appErr := NewError(code, format, args...)
+	appErr.Err = err
+
+	return appErr
+}
+
+// Error gets the indonesian message of the error
+func (appErr *AppError) Error() string {
+	return fmt.Sprintf(appErr.Format, appErr.Args...)
+}
+
+// Unwrap gets the cause of the error
+func (appErr *AppError) Unwrap() error {
+	return appErr.Err
+}
+
+// Localize gets the message of the error in the given language
+func (appErr *AppError) Localize(language string) string {
+	return Translate(language, appErr.Format, appErr.Args...)
+}
+
+// ToAppError is a function to classify the given error so it can be returned to the client,
+// the missing record is reported as not found and the unknown errors, e.g. the sql errors, are hidden as internal errors
+func ToAppError(err error) *AppError {
+
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return WrapError(ErrCodeNotFound, err, "Data tidak ditemukan")
+	}
+
+	return WrapError(ErrCodeInternal, err, "Terjadi kesalahan pada server, silahkan coba lagi nanti")
+}
+
+// RequestBodyError is a function to map the given request body read error to the error returned to the client,
+// the body exceeding the size cap is reported as too large
+func RequestBodyError(err error) error {
+
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	if strings.Contains(err.Error(), "request body too large") {
+		return WrapError(ErrCodePayloadTooLarge, err, "Ukuran request terlalu besar")
+	}
+
+	return WrapError(ErrCodeBadRequest, err, "Format request tidak valid")
+}
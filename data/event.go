@@ -0,0 +1,394 @@
+package data
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+)
+
+// ValidateEvent is a function to validate the given event
+func (kost *Kost) ValidateEvent(event *entities.Event) error {
+
+	event.EventName = strings.TrimSpace(event.EventName)
+	if event.EventName == "" {
+		return NewError(ErrCodeValidation, "Nama event harus diisi")
+	}
+
+	if event.StartDate.IsZero() || event.EndDate.IsZero() {
+		return NewError(ErrCodeValidation, "Tanggal mulai dan tanggal selesai event harus diisi")
+	}
+
+	if !event.EndDate.After(event.StartDate) {
+		return NewError(ErrCodeValidation, "Tanggal selesai event harus setelah tanggal mulai")
+	}
+
+	return nil
+}
+
+// EventListModes is the available modes of the event list
+var EventListModes = map[string]bool{
+	"running":  true,
+	"upcoming": true,
+	"past":     true,
+}
+
+// isEventRunning checks whether the given event window is running on the given time
+func isEventRunning(startDate, endDate, now time.Time) bool {
+	return !startDate.After(now) && endDate.After(now)
+}
+
+// AddEvent is a function to add a new event
+func (kost *Kost) AddEvent(currentUser *database.MasterUser, event *entities.Event) (*database.MasterEvent, error) {
+
+	if err := kost.ValidateEvent(event); err != nil {
+		return nil, err
+	}
+
+	endDate := event.EndDate.Local()
+	newEvent := &database.MasterEvent{
+		EventName:    event.EventName,
+		EventDesc:    event.EventDesc,
+		StartDate:    event.StartDate.Local(),
+		EndDate:      &endDate,
+		IsOwnerOptIn: event.IsOwnerOptIn,
+		IsActive:     isEventRunning(event.StartDate, event.EndDate, time.Now()),
+		Created:      time.Now().Local(),
+		CreatedBy:    currentUser.Username,
+		Modified:     time.Now().Local(),
+		ModifiedBy:   currentUser.Username,
+	}
+
+	// insert the new event to the database
+	if err := config.DB.Create(newEvent).Error; err != nil {
+		return nil, err
+	}
+
+	return newEvent, nil
+}
+
+// UpdateEvent is a function to update the given event
+func (kost *Kost) UpdateEvent(currentUser *database.MasterUser, eventID uint, event *entities.Event) (*database.MasterEvent, error) {
+
+	// look for the target event in the db
+	targetEvent := &database.MasterEvent{}
+	if err := config.DB.Where("id = ?", eventID).First(targetEvent).Error; err != nil {
+		return nil, err
+	}
+
+	if err := kost.ValidateEvent(event); err != nil {
+		return nil, err
+	}
+
+	targetEvent.EventName = event.EventName
+	targetEvent.EventDesc = event.EventDesc
+	targetEvent.StartDate = event.StartDate.Local()
+	endDate := event.EndDate.Local()
+	targetEvent.EndDate = &endDate
+	targetEvent.IsOwnerOptIn = event.IsOwnerOptIn
+	// the event is activated right away when its new window is already running
+	targetEvent.IsActive = !targetEvent.IsCancelled && isEventRunning(event.StartDate, event.EndDate, time.Now())
+	targetEvent.Modified = time.Now().Local()
+	targetEvent.ModifiedBy = currentUser.Username
+
+	// update the event
+	if err := config.DB.Save(targetEvent).Error; err != nil {
+		return nil, err
+	}
+
+	return targetEvent, nil
+}
+
+// DeactivateEvent is a function to soft delete the given event, the event is cancelled so the scheduler won't activate it again
+func (kost *Kost) DeactivateEvent(currentUser *database.MasterUser, eventID uint) error {
+
+	result := config.DB.Model(&database.MasterEvent{}).Where("id = ?", eventID).Updates(map[string]interface{}{
+		"is_cancelled": true,
+		"is_active":    false,
+		"modified":     time.Now().Local(),
+		"modified_by":  currentUser.Username,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetEventThumbnail is a function to set the thumbnail of the given event from the given pict variants
+func (kost *Kost) SetEventThumbnail(currentUser *database.MasterUser, eventID uint, variants *entities.PictVariants) (*database.MasterEvent, error) {
+
+	// look for the target event in the db
+	targetEvent := &database.MasterEvent{}
+	if err := config.DB.Where("id = ?", eventID).First(targetEvent).Error; err != nil {
+		return nil, err
+	}
+
+	targetEvent.Thumbnail = variants.MediumJpegURL
+	targetEvent.ThumbnailWebpURL = variants.MediumWebpURL
+	targetEvent.ThumbnailBlurhash = variants.Blurhash
+	targetEvent.Modified = time.Now().Local()
+	targetEvent.ModifiedBy = currentUser.Username
+
+	if err := config.DB.Save(targetEvent).Error; err != nil {
+		return nil, err
+	}
+
+	return targetEvent, nil
+}
+
+// EnrollEventKost is a function to enroll the given kost to the given event,
+// the owners can only enroll their own kost to the opt in events while the admin can assign any kost
+func (kost *Kost) EnrollEventKost(currentUser *database.MasterUser, eventID uint, kostID uint) (*database.MasterEventDetail, error) {
+
+	isAdmin := HasPermission(currentUser.RoleID, PermManageEvent)
+
+	// look for the target event in the db, the upcoming events can be enrolled in advance
+	var targetEvent database.MasterEvent
+	if err := config.DB.Where("id = ? AND is_cancelled = ?", eventID, false).First(&targetEvent).Error; err != nil {
+		return nil, err
+	}
+
+	// the legacy event without an end date never ends
+	if targetEvent.EndDate != nil && !targetEvent.EndDate.After(time.Now()) {
+		return nil, NewError(ErrCodeValidation, "Event sudah berakhir")
+	}
+
+	if !isAdmin && !targetEvent.IsOwnerOptIn {
+		return nil, NewError(ErrCodeForbidden, "Kost hanya bisa didaftarkan ke event ini oleh admin")
+	}
+
+	// look for the target kost in the db
+	var targetKost database.DBKost
+	if err := config.DB.Where("id = ? AND is_active = ?", kostID, true).First(&targetKost).Error; err != nil {
+		return nil, err
+	}
+
+	if !isAdmin && targetKost.OwnerID != currentUser.ID {
+		return nil, NewError(ErrCodeForbidden, "Hanya pemilik kost yang bisa mendaftarkan kost ini")
+	}
+
+	// the withdrawn kost is enrolled again by reactivating its event detail
+	var eventDetail database.MasterEventDetail
+	err := config.DB.Where("event_id = ? AND kost_id = ?", eventID, kostID).First(&eventDetail).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if err == nil {
+		if eventDetail.IsActive {
+			return nil, NewError(ErrCodeConflict, "Kost sudah terdaftar pada event ini")
+		}
+
+		eventDetail.IsActive = true
+		eventDetail.Modified = time.Now().Local()
+		eventDetail.ModifiedBy = currentUser.Username
+
+		if err := config.DB.Save(&eventDetail).Error; err != nil {
+			return nil, err
+		}
+
+		return &eventDetail, nil
+	}
+
+	eventDetail = database.MasterEventDetail{
+		EventID:    eventID,
+		KostID:     kostID,
+		IsActive:   true,
+		Created:    time.Now().Local(),
+		CreatedBy:  currentUser.Username,
+		Modified:   time.Now().Local(),
+		ModifiedBy: currentUser.Username,
+	}
+
+	if err := config.DB.Create(&eventDetail).Error; err != nil {
+		return nil, err
+	}
+
+	return &eventDetail, nil
+}
+
+// WithdrawEventKost is a function to withdraw the given kost from the given event by its owner or the admin
+func (kost *Kost) WithdrawEventKost(currentUser *database.MasterUser, eventID uint, kostID uint) error {
+
+	// look for the target kost in the db
+	var targetKost database.DBKost
+	if err := config.DB.Where("id = ?", kostID).First(&targetKost).Error; err != nil {
+		return err
+	}
+
+	if !HasPermission(currentUser.RoleID, PermManageEvent) && targetKost.OwnerID != currentUser.ID {
+		return NewError(ErrCodeForbidden, "Hanya pemilik kost yang bisa mengeluarkan kost ini dari event")
+	}
+
+	result := config.DB.Model(&database.MasterEventDetail{}).
+		Where("event_id = ? AND kost_id = ? AND is_active = ?", eventID, kostID, true).
+		Updates(map[string]interface{}{
+			"is_active":   false,
+			"modified":    time.Now().Local(),
+			"modified_by": currentUser.Username,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return NewError(ErrCodeValidation, "Kost tidak terdaftar pada event ini")
+	}
+
+	return nil
+}
+
+// GetEventKostList is a function to get the enrolled kost list of the given event, the boosted kosts are ranked first
+func (kost *Kost) GetEventKostList(eventID uint, page int) (*entities.EventKostList, error) {
+
+	model := config.DB.
+		Model(&database.DBKost{}).
+		Joins("inner join master_event_details on master_event_details.kost_id = db_kosts.id").
+		Where("master_event_details.event_id = ? AND master_event_details.is_active = ? AND db_kosts.is_active = ?", eventID, true, true)
+
+	eventKostList := &entities.EventKostList{KostList: []entities.Kost{}}
+	if err := model.Count(&eventKostList.KostCount).Error; err != nil {
+		return nil, err
+	}
+
+	// 10 is the default limit
+	if err := model.
+		Select("db_kosts.id" +
+			",db_kosts.owner_id " +
+			",db_kosts.type_id" +
+			",db_kosts.status" +
+			",db_kosts.kost_code" +
+			",db_kosts.kost_name" +
+			",db_kosts.kost_desc" +
+			",db_kosts.country" +
+			",db_kosts.city" +
+			",db_kosts.address" +
+			",db_kosts.latitude" +
+			",db_kosts.longitude" +
+			",db_kosts.up_rate" +
+			",db_kosts.up_rate_expired" +
+			",db_kosts.thumbnail_url" +
+			",db_kosts.is_verified" +
+			",db_kosts.is_active" +
+			",db_kosts.created" +
+			",db_kosts.created_by" +
+			",db_kosts.modified" +
+			",db_kosts.modified_by").
+		Clauses(BoostedKostOrder()).
+		Offset((page - 1) * 10).
+		Limit(10).
+		Scan(&eventKostList.KostList).Error; err != nil {
+		return nil, err
+	}
+
+	return eventKostList, nil
+}
+
+// GetEventList is a function to get the event list of the given mode,
+// running is the events currently running, upcoming is the events not started yet and past is the ended events,
+// the legacy events without an end date are running until the admin gives them one
+func (kost *Kost) GetEventList(mode string) ([]database.MasterEvent, error) {
+
+	now := time.Now().Local()
+	model := config.DB.Where("is_cancelled = ?", false)
+
+	switch mode {
+	case "running":
+		// the window is checked as well so the list stays correct between the scheduler runs
+		model = model.Where("is_active = ? AND start_date <= ? AND (end_date IS NULL OR end_date > ?)", true, now, now).Order("end_date IS NULL, end_date")
+	case "upcoming":
+		model = model.Where("start_date > ?", now).Order("start_date")
+	case "past":
+		model = model.Where("end_date IS NOT NULL AND end_date <= ?", now).Order("end_date DESC")
+	default:
+		return nil, NewError(ErrCodeValidation, "Mode event tidak valid")
+	}
+
+	eventList := []database.MasterEvent{}
+	if err := model.Find(&eventList).Error; err != nil {
+		return nil, err
+	}
+
+	return eventList, nil
+}
+
+// SyncEventSchedule is a function to activate the events whose window has started and deactivate the ended ones
+func (kost *Kost) SyncEventSchedule() (int64, int64, error) {
+
+	now := time.Now().Local()
+
+	activated := config.DB.Model(&database.MasterEvent{}).
+		Where("is_cancelled = ? AND is_active = ? AND start_date <= ? AND (end_date IS NULL OR end_date > ?)", false, false, now, now).
+		Updates(map[string]interface{}{
+			"is_active":   true,
+			"modified":    now,
+			"modified_by": "System",
+		})
+	if activated.Error != nil {
+		return 0, 0, activated.Error
+	}
+
+	// the events rescheduled to the future are deactivated as well
+	deactivated := config.DB.Model(&database.MasterEvent{}).
+		Where("is_active = ? AND (end_date <= ? OR start_date > ?)", true, now, now).
+		Updates(map[string]interface{}{
+			"is_active":   false,
+			"modified":    now,
+			"modified_by": "System",
+		})
+	if deactivated.Error != nil {
+		return activated.RowsAffected, 0, deactivated.Error
+	}
+
+	return activated.RowsAffected, deactivated.RowsAffected, nil
+}
+
+// BackfillEventSchedule is a function to give the events created before their schedule a window, the event starts when it
+// was created and its end date is left open so the running events stay listed, the inactive ones are cancelled instead
+// so the scheduler never activates them, it is safe to run on every start as only the events without a start date are updated
+func (kost *Kost) BackfillEventSchedule() error {
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Model(&database.MasterEvent{}).
+			Where("start_date IS NULL AND is_active = ?", false).
+			Update("is_cancelled", true).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("UPDATE master_events SET start_date = created, end_date = NULL WHERE start_date IS NULL").Error
+	})
+}
+
+// RunEventScheduleJob is a function to periodically sync the event activation with its window until the given context is done
+func (kost *Kost) RunEventScheduleJob(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		activatedCount, deactivatedCount, err := kost.SyncEventSchedule()
+		if err != nil {
+			kost.logger.Error("Unable to sync the event schedule", "error", err.Error())
+		} else if activatedCount > 0 || deactivatedCount > 0 {
+			kost.logger.Info("Synced the event schedule", "activated", activatedCount, "deactivated", deactivatedCount)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package data
+
+import (
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"gorm.io/gorm"
+)
+
+// GetNextKostPictOrder is a function to get the next gallery sort order of the given kost
+func (kost *Kost) GetNextKostPictOrder(kostID uint) (uint, error) {
+
+	var lastOrder uint
+	if err := config.DB.Raw("SELECT COALESCE(MAX(sort_order), 0) FROM db_kost_picts WHERE kost_id = ? AND is_active = ?", kostID, true).Scan(&lastOrder).Error; err != nil {
+
+		return 0, err
+	}
+
+	return lastOrder + 1, nil
+}
+
+// GetNextKostRoomPictOrder is a function to get the next gallery sort order of the given kost room
+func (kost *Kost) GetNextKostRoomPictOrder(roomID uint) (uint, error) {
+
+	var lastOrder uint
+	if err := config.DB.Raw("SELECT COALESCE(MAX(sort_order), 0) FROM db_kost_room_picts WHERE room_id = ? AND is_active = ?", roomID, true).Scan(&lastOrder).Error; err != nil {
+
+		return 0, err
+	}
+
+	return lastOrder + 1, nil
+}
+
+// ReorderKostPicts is a function to reorder the kost pict gallery based on the given ordered pict ids
+func (kost *Kost) ReorderKostPicts(currentUser *database.MasterUser, kostID uint, pictIDs []uint) error {
+
+	// reorder the kost picts with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// look for the active kost picts of the given kost
+		var kostPicts []database.DBKostPict
+		if dbErr := tx.Where("kost_id = ? AND is_active = ?", kostID, true).Find(&kostPicts).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// the given pict ids must contain the whole active gallery
+		if len(kostPicts) != len(pictIDs) {
+			return NewError(ErrCodeValidation, "Urutan foto harus berisi semua foto kost")
+		}
+
+		var galleryIDs = make(map[uint]bool)
+		for _, pict := range kostPicts {
+			galleryIDs[pict.ID] = true
+		}
+
+		for index, pictID := range pictIDs {
+
+			// every pict id must belong to the given kost and only appear once
+			if !galleryIDs[pictID] {
+				return NewError(ErrCodeValidation, "Foto %d tidak ditemukan pada galeri kost", pictID)
+			}
+
+			delete(galleryIDs, pictID)
+
+			if dbErr := tx.Model(&database.DBKostPict{}).Where("id = ?", pictID).Updates(map[string]interface{}{
+				"sort_order":  index + 1,
+				"modified":    time.Now().Local(),
+				"modified_by": currentUser.Username,
+			}).Error; dbErr != nil {
+				return dbErr
+			}
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return err
+	}
+
+	return nil
+}
+
+// ReorderKostRoomPicts is a function to reorder the kost room pict gallery based on the given ordered pict ids
+func (kost *Kost) ReorderKostRoomPicts(currentUser *database.MasterUser, roomID uint, pictIDs []uint) error {
+
+	// reorder the kost room picts with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// look for the active kost room picts of the given room
+		var roomPicts []database.DBKostRoomPict
+		if dbErr := tx.Where("room_id = ? AND is_active = ?", roomID, true).Find(&roomPicts).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// the given pict ids must contain the whole active gallery
+		if len(roomPicts) != len(pictIDs) {
+			return NewError(ErrCodeValidation, "Urutan foto harus berisi semua foto kamar")
+		}
+
+		var galleryIDs = make(map[uint]bool)
+		for _, pict := range roomPicts {
+			galleryIDs[pict.ID] = true
+		}
+
+		for index, pictID := range pictIDs {
+
+			// every pict id must belong to the given room and only appear once
+			if !galleryIDs[pictID] {
+				return NewError(ErrCodeValidation, "Foto %d tidak ditemukan pada galeri kamar", pictID)
+			}
+
+			delete(galleryIDs, pictID)
+
+			if dbErr := tx.Model(&database.DBKostRoomPict{}).Where("id = ?", pictID).Updates(map[string]interface{}{
+				"sort_order":  index + 1,
+				"modified":    time.Now().Local(),
+				"modified_by": currentUser.Username,
+			}).Error; dbErr != nil {
+				return dbErr
+			}
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return err
+	}
+
+	return nil
+}
+
+// RemoveKostPict is a function to soft delete the given kost pict and reassign the kost thumbnail if needed
+func (kost *Kost) RemoveKostPict(currentUser *database.MasterUser, targetKost *database.DBKost, pictID uint) error {
+
+	// remove the kost pict with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// look for the target pict, it must belong to the given kost
+		var targetPict database.DBKostPict
+		if dbErr := tx.Where("id = ? AND kost_id = ? AND is_active = ?", pictID, targetKost.ID, true).First(&targetPict).Error; dbErr != nil {
+			return dbErr
+		}
+
+		targetPict.IsActive = false
+		targetPict.Modified = time.Now().Local()
+		targetPict.ModifiedBy = currentUser.Username
+
+		if dbErr := tx.Save(&targetPict).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// if the removed pict is the kost cover, pick the next one
+		if isCoverPict(targetKost.ThumbnailURL, targetPict.URL, targetPict.ThumbnailWebpURL, targetPict.ThumbnailJpegURL) {
+			return kost.ReassignKostThumbnail(tx, currentUser, targetKost)
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return err
+	}
+
+	return nil
+}
+
+// RemoveKostRoomPict is a function to soft delete the given kost room pict and reassign the kost thumbnail if needed
+func (kost *Kost) RemoveKostRoomPict(currentUser *database.MasterUser, targetKost *database.DBKost, roomID uint, pictID uint) error {
+
+	// remove the kost room pict with transaction scope
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// look for the target pict, it must belong to the given room
+		var targetPict database.DBKostRoomPict
+		if dbErr := tx.Where("id = ? AND room_id = ? AND is_active = ?", pictID, roomID, true).First(&targetPict).Error; dbErr != nil {
+			return dbErr
+		}
+
+		targetPict.IsActive = false
+		targetPict.Modified = time.Now().Local()
+		targetPict.ModifiedBy = currentUser.Username
+
+		if dbErr := tx.Save(&targetPict).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// if the removed pict is the kost cover, pick the next one
+		if isCoverPict(targetKost.ThumbnailURL, targetPict.URL, targetPict.ThumbnailWebpURL, targetPict.ThumbnailJpegURL) {
+			return kost.ReassignKostThumbnail(tx, currentUser, targetKost)
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return err
+	}
+
+	return nil
+}
+
+// ReassignKostThumbnail is a function to set the kost thumbnail to the first active pict of its gallery,
+// the kost picts come first and the kost room picts are used as the fallback
+func (kost *Kost) ReassignKostThumbnail(tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost) error {
+
+	var thumbnailURL string
+
+	// look for the first active kost pict by the gallery order
+	var kostPict database.DBKostPict
+	dbErr := tx.Where("kost_id = ? AND is_active = ?", targetKost.ID, true).Order("sort_order, id").First(&kostPict).Error
+	if dbErr == nil {
+		thumbnailURL = kostPict.URL
+	} else if dbErr == gorm.ErrRecordNotFound {
+
+		// look for the first active kost room pict by the gallery order instead
+		var roomPict database.DBKostRoomPict
+		dbErr = tx.
+			Joins("inner join db_kost_rooms on db_kost_rooms.id = db_kost_room_picts.room_id").
+			Where("db_kost_rooms.kost_id = ? AND db_kost_room_picts.is_active = ?", targetKost.ID, true).
+			Order("db_kost_room_picts.sort_order, db_kost_room_picts.id").
+			First(&roomPict).Error
+		if dbErr == nil {
+			thumbnailURL = roomPict.URL
+		} else if dbErr != gorm.ErrRecordNotFound {
+			return dbErr
+		}
+	} else {
+		return dbErr
+	}
+
+	targetKost.ThumbnailURL = thumbnailURL
+	targetKost.Modified = time.Now().Local()
+	targetKost.ModifiedBy = currentUser.Username
+
+	return tx.Model(&database.DBKost{}).Where("id = ?", targetKost.ID).Updates(map[string]interface{}{
+		"thumbnail_url": targetKost.ThumbnailURL,
+		"modified":      targetKost.Modified,
+		"modified_by":   targetKost.ModifiedBy,
+	}).Error
+}
+
+// isCoverPict checks whether the given kost thumbnail url points to one of the given pict urls
+func isCoverPict(thumbnailURL string, pictURLs ...string) bool {
+
+	if thumbnailURL == "" {
+		return false
+	}
+
+	for _, url := range pictURLs {
+		if url != "" && url == thumbnailURL {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package data
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// the supported languages of the messages returned to the client
+const (
+	LanguageID = "id"
+	LanguageEN = "en"
+)
+
+// DefaultLanguage is the language used when the client accepts none of the supported languages
+var DefaultLanguage = LanguageID
+
+// GetLanguage is a function to pick the first supported language of the Accept-Language header of the given request
+func GetLanguage(r *http.Request) string {
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+
+		// drop the quality value and the region, e.g. en-US;q=0.8 is en
+		tag := strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])
+		tag = strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+
+		if tag == LanguageID || tag == LanguageEN {
+			return tag
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// Translate is a function to format the given indonesian message in the given language,
+// the message is kept in indonesian when it has no translation
+func Translate(language, format string, args ...interface{}) string {
+
+	if translated, ok := messageTranslations[language][format]; ok {
+		format = translated
+	}
+
+	return fmt.Sprintf(format, args...)
+}
@@ -2,12 +2,12 @@ package data
 
 import (
 	"crypto/rand"
-	"fmt"
 	"io"
 	"math"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"time"
 
@@ -20,46 +20,81 @@ import (
 	"gorm.io/gorm"
 )
 
-// Claims determine the current user token holder
+// Claims determine the current user token holder,
+// the user id and role id are only filled by the newer tokens so 0 user id means the user must be looked up by the username
 type Claims struct {
-	Username string
+	Username  string
+	UserID    uint
+	RoleID    uint
+	SessionID string
 	jwt.StandardClaims
 }
 
 // Kost defines a struct for kost flow
 type Kost struct {
-	logger hclog.Logger
+	logger  hclog.Logger
+	storage Storage
 }
 
 // NewKost is a function to create new Kost struct
-func NewKost(newLogger hclog.Logger) *Kost {
-	return &Kost{newLogger}
+func NewKost(newLogger hclog.Logger, newStorage Storage) *Kost {
+	return &Kost{newLogger, newStorage}
 }
 
 // GetCurrentUser will get the current user login info
-func (kost *Kost) GetCurrentUser(rw http.ResponseWriter, r *http.Request, store *mysqlstore.MySQLStore) (*database.MasterUser, error) {
+func (kost *Kost) GetCurrentUser(r *http.Request, store *mysqlstore.MySQLStore) (*database.MasterUser, error) {
+
+	// the internal consumer authenticated by an api key acts as the service user
+	if apiKey := APIKeyFromContext(r.Context()); apiKey != nil {
+		return &database.MasterUser{
+			RoleID:   RoleService,
+			Username: "api-key:" + apiKey.KeyID,
+		}, nil
+	}
+
+	// the claims validated by the auth middleware are used before falling back to the session
+	if claims := ClaimsFromContext(r.Context()); claims != nil {
+
+		// the newer tokens carry the user id and role, so the user is resolved without any db lookup
+		if claims.UserID != 0 {
+			return &database.MasterUser{
+				ID:       claims.UserID,
+				RoleID:   claims.RoleID,
+				Username: claims.Username,
+			}, nil
+		}
+
+		var currentUser database.MasterUser
+		if err := config.DB.Where("username = ?", claims.Username).First(&currentUser).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, WrapError(ErrCodeUnauthorized, err, "Kamu harus login terlebih dahulu")
+			}
+
+			return nil, err
+		}
+
+		return &currentUser, nil
+	}
 
 	// Get a session (existing/new)
 	session, err := store.Get(r, "session-name")
 	if err != nil {
-		rw.WriteHeader(http.StatusInternalServerError)
-
 		return nil, err
 	}
 
 	// check the logged in user from the session
 	// if user available, get the user info from the session
 	if session.Values["userLoggedin"] == nil {
-		rw.WriteHeader(http.StatusUnauthorized)
-
-		return nil, fmt.Errorf("Error 401")
+		return nil, NewError(ErrCodeUnauthorized, "Kamu harus login terlebih dahulu")
 	}
 
 	// work with database
 	// look for the current user logged in in the db
 	var currentUser database.MasterUser
 	if err := config.DB.Where("username = ?", session.Values["userLoggedin"].(string)).First(&currentUser).Error; err != nil {
-		rw.WriteHeader(http.StatusUnauthorized)
+		if err == gorm.ErrRecordNotFound {
+			return nil, WrapError(ErrCodeUnauthorized, err, "Kamu harus login terlebih dahulu")
+		}
 
 		return nil, err
 	}
@@ -210,11 +245,267 @@ func (kost *Kost) GenerateCode(codeType, country, city string) (string, error) {
 
 }
 
-// AddRoom is a function to add kost room based on the given kost id
-func (kost *Kost) AddRoom(currentUser *database.MasterUser, kostID uint, targetKostRoom *entities.KostRoom) error {
+// CreateKost is a function to add the given new kost along with its periods, picts, benchmark, accessibility,
+// around, rooms and facilities within the given transaction, the kost is waiting for the admin approval
+func (kost *Kost) CreateKost(db *gorm.DB, currentUser *database.MasterUser, kostReq *entities.Kost) (*database.DBKost, error) {
+
+	// proceed to create the new kost with transaction scope
+	var newKost database.DBKost
+	err := db.Transaction(func(tx *gorm.DB) error {
+
+		// set variables
+		var dbErr error
+
+		newKost.OwnerID = currentUser.ID
+		newKost.TypeID = kostReq.TypeID // kategori kos kosan atau kontrakan atau dll
+		newKost.Status = KostStatusPending
+		newKost.KostCode, dbErr = kost.GenerateCode("K", kostReq.Country[0:1], kostReq.City[0:1])
+
+		if dbErr != nil {
+			return dbErr
+		}
+
+		newKost.KostName = kostReq.KostName
+		newKost.KostDesc = kostReq.KostDesc
+		newKost.Country = kostReq.Country
+		newKost.City = kostReq.City
+		newKost.Address = kostReq.Address
+		newKost.Latitude = kostReq.Latitude
+		newKost.Longitude = kostReq.Longitude
+		newKost.ThumbnailURL = kostReq.Rooms[0].RoomPicts[0].URL
+		newKost.UpRate = 0
+		newKost.UpRateExpired = time.Now().Local()
+		newKost.IsVerified = false
+		newKost.IsActive = true
+		newKost.Created = time.Now().Local()
+		newKost.CreatedBy = currentUser.Username
+		newKost.Modified = time.Now().Local()
+		newKost.ModifiedBy = currentUser.Username
+
+		if dbErr = tx.Create(&newKost).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// proceed to create the new kost periods with transaction scope
+		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
+
+			// create the variable specific to the nested transaction
+			var dbErr2 error
+			var kostPeriod = kostReq.KostPeriods
+
+			// add the kost id to the slices
+			for i := range kostPeriod {
+				(&kostPeriod[i]).KostID = newKost.ID
+				(&kostPeriod[i]).IsActive = true
+				(&kostPeriod[i]).Created = time.Now().Local()
+				(&kostPeriod[i]).CreatedBy = currentUser.Username
+				(&kostPeriod[i]).Modified = time.Now().Local()
+				(&kostPeriod[i]).ModifiedBy = currentUser.Username
+			}
+
+			// nothing to insert if the section is empty
+			if len(kostPeriod) == 0 {
+				return nil
+			}
+
+			// insert the new kost periods to database
+			if dbErr2 = tx2.Create(&kostPeriod).Error; dbErr2 != nil {
+				return dbErr2
+			}
+
+			// return nil will commit the whole nested transaction
+			return nil
+		})
+
+		// if transaction error, return the error
+		if dbErr != nil {
+			return dbErr
+		}
+
+		// proceed to create the new kost picts with transaction scope
+		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
+
+			// create the variable specific to the nested transaction
+			var dbErr2 error
+			var kostPicts = kostReq.KostPicts
+
+			// add the kost id to the slices
+			for i := range kostPicts {
+				(&kostPicts[i]).KostID = newKost.ID
+				(&kostPicts[i]).SortOrder = uint(i + 1)
+				(&kostPicts[i]).IsActive = true
+				(&kostPicts[i]).Created = time.Now().Local()
+				(&kostPicts[i]).CreatedBy = currentUser.Username
+				(&kostPicts[i]).Modified = time.Now().Local()
+				(&kostPicts[i]).ModifiedBy = currentUser.Username
+			}
+
+			// nothing to insert if the section is empty
+			if len(kostPicts) == 0 {
+				return nil
+			}
+
+			// insert the new kost picts to database
+			if dbErr2 = tx2.Create(&kostPicts).Error; dbErr2 != nil {
+				return dbErr2
+			}
+
+			// return nil will commit the whole nested transaction
+			return nil
+		})
+
+		// if transaction error, return the error
+		if dbErr != nil {
+			return dbErr
+		}
+
+		// proceed to create the new kost benchmark with transaction scope
+		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
+
+			// create the variable specific to the nested transaction
+			var dbErr2 error
+			var kostBenchmark = kostReq.KostBenchmark
+
+			// add the kost id to the slices
+			for i := range kostBenchmark {
+				(&kostBenchmark[i]).KostID = newKost.ID
+				(&kostBenchmark[i]).IsActive = true
+				(&kostBenchmark[i]).Created = time.Now().Local()
+				(&kostBenchmark[i]).CreatedBy = currentUser.Username
+				(&kostBenchmark[i]).Modified = time.Now().Local()
+				(&kostBenchmark[i]).ModifiedBy = currentUser.Username
+			}
+
+			// nothing to insert if the section is empty
+			if len(kostBenchmark) == 0 {
+				return nil
+			}
+
+			// insert the new kost picts to database
+			if dbErr2 = tx2.Create(&kostBenchmark).Error; dbErr2 != nil {
+				return dbErr2
+			}
+
+			// return nil will commit the whole nested transaction
+			return nil
+		})
+
+		// if transaction error, return the error
+		if dbErr != nil {
+			return dbErr
+		}
+
+		// proceed to create the new kost accessibility with transaction scope
+		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
+
+			// create the variable specific to the nested transaction
+			var dbErr2 error
+			var kostAccess = kostReq.KostAccess
+
+			// add the kost id to the slices
+			for i := range kostAccess {
+				(&kostAccess[i]).KostID = newKost.ID
+				(&kostAccess[i]).IsActive = true
+				(&kostAccess[i]).Created = time.Now().Local()
+				(&kostAccess[i]).CreatedBy = currentUser.Username
+				(&kostAccess[i]).Modified = time.Now().Local()
+				(&kostAccess[i]).ModifiedBy = currentUser.Username
+			}
+
+			// nothing to insert if the section is empty
+			if len(kostAccess) == 0 {
+				return nil
+			}
+
+			// insert the new kost picts to database
+			if dbErr2 = tx2.Create(&kostAccess).Error; dbErr2 != nil {
+				return dbErr2
+			}
+
+			// return nil will commit the whole nested transaction
+			return nil
+		})
+
+		// if transaction error, return the error
+		if dbErr != nil {
+			return dbErr
+		}
+
+		// proceed to create the new around kost with transaction scope
+		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
+
+			// create the variable specific to the nested transaction
+			var dbErr2 error
+			var kostAround = kostReq.KostAround
+
+			// add the kost id to the slices
+			for i := range kostAround {
+				(&kostAround[i]).KostID = newKost.ID
+				(&kostAround[i]).IsActive = true
+				(&kostAround[i]).Created = time.Now().Local()
+				(&kostAround[i]).CreatedBy = currentUser.Username
+				(&kostAround[i]).Modified = time.Now().Local()
+				(&kostAround[i]).ModifiedBy = currentUser.Username
+			}
+
+			// nothing to insert if the section is empty
+			if len(kostAround) == 0 {
+				return nil
+			}
+
+			// insert the new kost picts to database
+			if dbErr2 = tx2.Create(&kostAround).Error; dbErr2 != nil {
+				return dbErr2
+			}
+
+			// return nil will commit the whole nested transaction
+			return nil
+		})
+
+		// if transaction error, return the error
+		if dbErr != nil {
+			return dbErr
+		}
+
+		// loop the room slices
+		for _, room := range kostReq.Rooms {
+
+			// add the kostReq room slices into the database
+			dbErr = kost.AddRoom(tx, currentUser, newKost.ID, &room)
+
+			// if transaction error, return the error
+			if dbErr != nil {
+				return dbErr
+			}
+
+		}
+
+		// add the kost facilities to the database
+		dbErr = kost.AddFacilities(tx, currentUser, newKost.ID, kostReq.Facilities)
+
+		// if transaction error, return the error
+		if dbErr != nil {
+			return dbErr
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+
+	})
+
+	// if transaction error
+	if err != nil {
+		return nil, err
+	}
+
+	return &newKost, nil
+}
+
+// AddRoom is a function to add kost room based on the given kost id, the room is added within the given transaction
+func (kost *Kost) AddRoom(db *gorm.DB, currentUser *database.MasterUser, kostID uint, targetKostRoom *entities.KostRoom) error {
 
 	// add the kostReq room into the database with transaction scope
-	err := config.DB.Transaction(func(tx *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
 
 		// set variables
 		var newKostRoom database.DBKostRoom
@@ -233,7 +524,7 @@ func (kost *Kost) AddRoom(currentUser *database.MasterUser, kostID uint, targetK
 
 		// check the uom type, if not currency return error
 		if targetPriceUOM.UOMType != "currency" {
-			return fmt.Errorf("Invalid UOM Type")
+			return NewError(ErrCodeValidation, "Tipe UOM tidak valid")
 		}
 
 		newKostRoom.RoomPriceUOM = targetKostRoom.RoomPriceUOM
@@ -248,7 +539,7 @@ func (kost *Kost) AddRoom(currentUser *database.MasterUser, kostID uint, targetK
 
 		// check the uom type, if not length return error
 		if targetAreaUOM.UOMType != "length" {
-			return fmt.Errorf("Invalid UOM Type")
+			return NewError(ErrCodeValidation, "Tipe UOM tidak valid")
 		}
 
 		newKostRoom.RoomAreaUOM = targetKostRoom.RoomAreaUOM
@@ -282,6 +573,11 @@ func (kost *Kost) AddRoom(currentUser *database.MasterUser, kostID uint, targetK
 				(&roomDetails[i]).ModifiedBy = currentUser.Username
 			}
 
+			// nothing to insert if the room has no details yet, e.g. the room of a draft kost
+			if len(roomDetails) == 0 {
+				return nil
+			}
+
 			// insert the new room details to database
 			if dbErr2 = tx2.Create(&roomDetails).Error; dbErr2 != nil {
 				return dbErr2
@@ -305,6 +601,7 @@ func (kost *Kost) AddRoom(currentUser *database.MasterUser, kostID uint, targetK
 			// add the room id to the slices
 			for i := range roomPicts {
 				(&roomPicts[i]).RoomID = newKostRoom.ID
+				(&roomPicts[i]).SortOrder = uint(i + 1)
 				(&roomPicts[i]).IsActive = true
 				(&roomPicts[i]).Created = time.Now().Local()
 				(&roomPicts[i]).CreatedBy = currentUser.Username
@@ -312,6 +609,11 @@ func (kost *Kost) AddRoom(currentUser *database.MasterUser, kostID uint, targetK
 				(&roomPicts[i]).ModifiedBy = currentUser.Username
 			}
 
+			// nothing to insert if the room has no picts yet, e.g. the room of a draft kost
+			if len(roomPicts) == 0 {
+				return nil
+			}
+
 			// insert the new room picts to database
 			if dbErr2 = tx2.Create(&roomPicts).Error; dbErr2 != nil {
 				return dbErr2
@@ -341,11 +643,16 @@ func (kost *Kost) AddRoom(currentUser *database.MasterUser, kostID uint, targetK
 	return nil
 }
 
-// AddFacilities is a function to add kost facilities based on the given kost id
-func (kost *Kost) AddFacilities(currentUser *database.MasterUser, kostID uint, targetFacilities []database.DBKostFacilities) error {
+// AddFacilities is a function to add kost facilities based on the given kost id within the given transaction
+func (kost *Kost) AddFacilities(db *gorm.DB, currentUser *database.MasterUser, kostID uint, targetFacilities []database.DBKostFacilities) error {
+
+	// nothing to insert if the kost has no facilities
+	if len(targetFacilities) == 0 {
+		return nil
+	}
 
 	// add the room facilities into the database with transaction scope
-	err := config.DB.Transaction(func(tx *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
 
 		// set variables
 		var dbErr error
@@ -534,10 +841,13 @@ func (kost *Kost) GetKostList(page int) ([]entities.Kost, int64, error) {
 
 	// look for the current kost list in the db
 	// 10 is the default limit
+	// the boosted kosts are ranked first, the drafts and the inactive kosts are hidden
 	var kostList []entities.Kost
 	if err := config.DB.
 		Limit((10 * page)).
 		Model(&database.DBKost{}).
+		Clauses(BoostedKostOrder()).
+		Where("db_kosts.status <> ? AND db_kosts.is_active = ?", KostStatusDraft, true).
 		Select("db_kosts.id" +
 			",db_kosts.owner_id " +
 			",db_kosts.type_id" +
@@ -566,6 +876,7 @@ func (kost *Kost) GetKostList(page int) ([]entities.Kost, int64, error) {
 	var count int64
 	if err := config.DB.
 		Model(&database.DBKost{}).
+		Where("db_kosts.status <> ? AND db_kosts.is_active = ?", KostStatusDraft, true).
 		Select("db_kosts.id" +
 			",db_kosts.owner_id " +
 			",db_kosts.type_id" +
@@ -609,7 +920,8 @@ func (kost *Kost) GetNearbyKostList(latitude, longitude string, page int) ([]ent
 	// look for the current kost list in the db
 	var count int64
 	var nearbyKostList []database.DBKost
-	if err := config.DB.Limit(limit).Where("is_active = ? AND city = ?", true, geoLocation.GeoData[0].County).Find(&nearbyKostList).Error; err != nil {
+	// the boosted kosts are picked first so they are not cut by the limit
+	if err := config.DB.Limit(limit).Clauses(BoostedKostOrder()).Where("is_active = ? AND city = ?", true, geoLocation.GeoData[0].County).Find(&nearbyKostList).Error; err != nil {
 
 		return nil, 0, err
 	}
@@ -686,6 +998,11 @@ func (kost *Kost) GetNearbyKostList(latitude, longitude string, page int) ([]ent
 
 	}
 
+	// rank the boosted kosts first, keeping the distance order of the equal up rate
+	sort.SliceStable(listKost, func(i, j int) bool {
+		return ActiveUpRate(&listKost[i]) > ActiveUpRate(&listKost[j])
+	})
+
 	return listKost, count, nil
 }
 
@@ -725,11 +1042,75 @@ func (kost *Kost) GetKostRoomDetailsByKost(kostID uint, page int) ([]database.DB
 	return kostRoomDetails, nil
 }
 
+// GetKostRoomDetailInfo is a function to get the info of the given kost room detail along with its current booker
+func (kost *Kost) GetKostRoomDetailInfo(roomDetail database.DBKostRoomDetail) (*entities.KostRoomDetail, error) {
+
+	kostRoom, err := kost.GetKostRoom(roomDetail.RoomID)
+	if err != nil {
+
+		return nil, err
+	}
+
+	currency, err := kost.GetUOMDesc(kostRoom.RoomPriceUOM)
+	if err != nil {
+
+		return nil, err
+	}
+
+	kostRoomDetailInfo := &entities.KostRoomDetail{
+		ID:         roomDetail.ID,
+		KostID:     roomDetail.KostID,
+		RoomID:     roomDetail.RoomID,
+		RoomDesc:   kostRoom.RoomDesc,
+		RoomNumber: roomDetail.RoomNumber,
+		FloorLevel: roomDetail.FloorLevel,
+		Price:      kostRoom.RoomPrice,
+		Currency:   currency,
+		IsActive:   roomDetail.IsActive,
+	}
+
+	kostRoomDetailBook, err := kost.GetKostRoomBooked(roomDetail.ID)
+	if err != nil {
+
+		return nil, err
+	}
+
+	// the room detail is vacant if nobody books it
+	if kostRoomDetailBook == nil {
+
+		return kostRoomDetailInfo, nil
+	}
+
+	period, err := kost.GetMasterPeriod(kostRoomDetailBook.PeriodID)
+	if err != nil {
+
+		return nil, err
+	}
+
+	booker, err := kost.GetMasterUser(kostRoomDetailBook.BookerID)
+	if err != nil {
+
+		return nil, err
+	}
+
+	// TODO: status ntr diomongin lagi mau gimana
+	kostRoomDetailInfo.Status = kostRoomDetailBook.Status
+	kostRoomDetailInfo.Booker = &database.MasterUser{
+		ID:             booker.ID,
+		DisplayName:    booker.DisplayName,
+		ProfilePicture: booker.ProfilePicture,
+	}
+	kostRoomDetailInfo.PrevPayment = kostRoomDetailBook.BookDate
+	kostRoomDetailInfo.NextPayment = kostRoomDetailBook.BookDate.AddDate(0, 0, int(period.PeriodValue))
+
+	return kostRoomDetailInfo, nil
+}
+
 // GetKostRoomPicts is a function to get kost room picts based on the given room id
 func (kost *Kost) GetKostRoomPicts(roomID uint) ([]database.DBKostRoomPict, error) {
 
 	var kostRoomPicts []database.DBKostRoomPict
-	if err := config.DB.Where("room_id = ?", roomID).Find(&kostRoomPicts).Error; err != nil {
+	if err := config.DB.Where("room_id = ? AND is_active = ?", roomID, true).Order("sort_order, id").Find(&kostRoomPicts).Error; err != nil {
 
 		return nil, err
 	}
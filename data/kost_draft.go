@@ -0,0 +1,568 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+)
+
+// the statuses of the kost
+const (
+	KostStatusPending  uint = 0 // submitted and waiting for the admin approval
+	KostStatusApproved uint = 1
+	KostStatusRejected uint = 2
+	KostStatusDraft    uint = 3 // still being filled by the owner, hidden from the kost list
+)
+
+// kostDraftSection is a section of the kost draft filled on its own step,
+// saving a section replaces the previously saved content of the section
+type kostDraftSection struct {
+	validate func(validator *Validator, kostReq *entities.Kost)
+	save     func(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error
+}
+
+// kostDraftSections is the sections of the kost draft by their name, the kost picts are managed by the gallery instead
+var kostDraftSections = map[string]kostDraftSection{
+	"info":       {validate: validateKostDraftInfo, save: saveKostDraftInfo},
+	"rooms":      {validate: validateKostDraftRooms, save: saveKostDraftRooms},
+	"periods":    {validate: validateKostDraftPeriods, save: saveKostDraftPeriods},
+	"facilities": {validate: validateKostDraftFacilities, save: saveKostDraftFacilities},
+	"benchmark":  {validate: validateKostDraftBenchmark, save: saveKostDraftBenchmark},
+	"access":     {validate: validateKostDraftAccess, save: saveKostDraftAccess},
+	"around":     {validate: validateKostDraftAround, save: saveKostDraftAround},
+}
+
+// kostSectionCounts is the number of the active rows of the kost sections
+type kostSectionCounts struct {
+	periods          int64
+	picts            int64
+	facilities       int64
+	benchmark        int64
+	access           int64
+	around           int64
+	rooms            int64
+	roomsWithoutPict int64
+}
+
+// kostChecklistItem is an item of the kost draft checklist, the weight is its share of the completeness score
+// and the required items must be done before the draft can be submitted
+type kostChecklistItem struct {
+	key      string
+	section  string
+	label    string
+	weight   int
+	required bool
+	done     func(targetKost *database.DBKost, counts *kostSectionCounts) bool
+}
+
+// kostChecklist is the checklist of the kost draft by the order it is shown to the owner
+var kostChecklist = []kostChecklistItem{
+	{key: "kost_name", section: "info", label: "Nama kost", weight: 10, required: true, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return strings.TrimSpace(targetKost.KostName) != ""
+	}},
+	{key: "type_id", section: "info", label: "Tipe kost", weight: 5, required: true, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return targetKost.TypeID != 0
+	}},
+	{key: "address", section: "info", label: "Alamat kost", weight: 10, required: true, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return strings.TrimSpace(targetKost.Country) != "" && strings.TrimSpace(targetKost.City) != "" && strings.TrimSpace(targetKost.Address) != ""
+	}},
+	{key: "location", section: "info", label: "Titik lokasi kost", weight: 10, required: true, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return strings.TrimSpace(targetKost.Latitude) != "" && strings.TrimSpace(targetKost.Longitude) != ""
+	}},
+	{key: "kost_desc", section: "info", label: "Deskripsi kost", weight: 5, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return strings.TrimSpace(targetKost.KostDesc) != ""
+	}},
+	{key: "periods", section: "periods", label: "Periode sewa", weight: 10, required: true, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.periods > 0
+	}},
+	{key: "rooms", section: "rooms", label: "Kamar kost", weight: 15, required: true, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.rooms > 0
+	}},
+	{key: "room_picts", section: "rooms", label: "Foto setiap kamar", weight: 10, required: true, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.rooms > 0 && counts.roomsWithoutPict == 0
+	}},
+	{key: "picts", section: "picts", label: "Foto kost", weight: 10, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.picts > 0
+	}},
+	{key: "facilities", section: "facilities", label: "Fasilitas kost", weight: 5, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.facilities > 0
+	}},
+	{key: "benchmark", section: "benchmark", label: "Patokan lokasi kost", weight: 5, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.benchmark > 0
+	}},
+	{key: "access", section: "access", label: "Akses kost", weight: 5, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.access > 0
+	}},
+	{key: "around", section: "around", label: "Lingkungan sekitar kost", weight: 5, done: func(targetKost *database.DBKost, counts *kostSectionCounts) bool {
+		return counts.around > 0
+	}},
+}
+
+// validateKostDraftInfo declares the rules of the kost info section, only the kost name is required
+// as the draft can be saved before the rest of the info is known
+func validateKostDraftInfo(validator *Validator, kostReq *entities.Kost) {
+
+	validator.Required("kost_name", kostReq.KostName)
+
+	if kostReq.TypeID != 0 {
+		validator.Master("type_id", "kost-types", kostReq.TypeID)
+	}
+
+	if strings.TrimSpace(kostReq.Latitude) != "" || strings.TrimSpace(kostReq.Longitude) != "" {
+		validator.Coordinate("latitude", kostReq.Latitude, 90)
+		validator.Coordinate("longitude", kostReq.Longitude, 180)
+	}
+}
+
+// validateKostDraftRooms declares the rules of the kost rooms section
+func validateKostDraftRooms(validator *Validator, kostReq *entities.Kost) {
+
+	// the room picts can be uploaded later through the room gallery
+	for i := range kostReq.Rooms {
+		validateKostRoom(validator, fmt.Sprintf("rooms[%d].", i), &kostReq.Rooms[i], false)
+	}
+}
+
+// validateKostDraftPeriods declares the rules of the kost periods section
+func validateKostDraftPeriods(validator *Validator, kostReq *entities.Kost) {
+	for i, kostPeriod := range kostReq.KostPeriods {
+		validator.Master(fmt.Sprintf("kost_periods[%d].period_id", i), "periods", kostPeriod.PeriodID)
+	}
+}
+
+// validateKostDraftFacilities declares the rules of the kost facilities section
+func validateKostDraftFacilities(validator *Validator, kostReq *entities.Kost) {
+	for i, facility := range kostReq.Facilities {
+		validator.Master(fmt.Sprintf("facilities[%d].fac_id", i), "facilities", facility.FacID)
+	}
+}
+
+// validateKostDraftBenchmark declares the rules of the kost benchmark section
+func validateKostDraftBenchmark(validator *Validator, kostReq *entities.Kost) {
+	for i, benchmark := range kostReq.KostBenchmark {
+		validator.Required(fmt.Sprintf("kost_benchmark[%d].benchmark_desc", i), benchmark.BenchmarkDesc)
+	}
+}
+
+// validateKostDraftAccess declares the rules of the kost accessibility section
+func validateKostDraftAccess(validator *Validator, kostReq *entities.Kost) {
+	for i, access := range kostReq.KostAccess {
+		validator.Required(fmt.Sprintf("kost_access[%d].accessibility_desc", i), access.AccessibilityDesc)
+	}
+}
+
+// validateKostDraftAround declares the rules of the around kost section
+func validateKostDraftAround(validator *Validator, kostReq *entities.Kost) {
+	for i, around := range kostReq.KostAround {
+		validator.Master(fmt.Sprintf("kost_around[%d].icon_id", i), "icons", around.IconID)
+		validator.Required(fmt.Sprintf("kost_around[%d].around_desc", i), around.AroundDesc)
+	}
+}
+
+// saveKostDraftInfo saves the kost info section
+func saveKostDraftInfo(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error {
+
+	return tx.Model(&database.DBKost{}).Where("id = ?", targetKost.ID).Updates(map[string]interface{}{
+		"type_id":     kostReq.TypeID,
+		"kost_name":   strings.TrimSpace(kostReq.KostName),
+		"kost_desc":   kostReq.KostDesc,
+		"country":     strings.TrimSpace(kostReq.Country),
+		"city":        strings.TrimSpace(kostReq.City),
+		"address":     strings.TrimSpace(kostReq.Address),
+		"latitude":    strings.TrimSpace(kostReq.Latitude),
+		"longitude":   strings.TrimSpace(kostReq.Longitude),
+		"modified":    time.Now().Local(),
+		"modified_by": currentUser.Username,
+	}).Error
+}
+
+// saveKostDraftRooms replaces the kost rooms section, the replaced rooms are removed along with their details,
+// picts and facilities as the draft rooms have never been listed
+func saveKostDraftRooms(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error {
+
+	var roomIDs []uint
+	if err := tx.Model(&database.DBKostRoom{}).Where("kost_id = ?", targetKost.ID).Pluck("id", &roomIDs).Error; err != nil {
+		return err
+	}
+
+	if len(roomIDs) > 0 {
+		if err := tx.Where("room_id IN ?", roomIDs).Delete(&database.DBKostRoomDetail{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("room_id IN ?", roomIDs).Delete(&database.DBKostRoomPict{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("room_id IN ?", roomIDs).Delete(&database.DBKostRoomFacilities{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("id IN ?", roomIDs).Delete(&database.DBKostRoom{}).Error; err != nil {
+			return err
+		}
+	}
+
+	for i := range kostReq.Rooms {
+		for j := range kostReq.Rooms[i].RoomDetails {
+			kostReq.Rooms[i].RoomDetails[j].KostID = targetKost.ID
+		}
+
+		if err := kost.AddRoom(tx, currentUser, targetKost.ID, &kostReq.Rooms[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveKostDraftPeriods replaces the kost periods section
+func saveKostDraftPeriods(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error {
+
+	if err := tx.Where("kost_id = ?", targetKost.ID).Delete(&database.DBKostPeriod{}).Error; err != nil {
+		return err
+	}
+
+	kostPeriods := kostReq.KostPeriods
+	if len(kostPeriods) == 0 {
+		return nil
+	}
+
+	for i := range kostPeriods {
+		kostPeriods[i].ID = 0
+		kostPeriods[i].KostID = targetKost.ID
+		kostPeriods[i].IsActive = true
+		kostPeriods[i].Created = time.Now().Local()
+		kostPeriods[i].CreatedBy = currentUser.Username
+		kostPeriods[i].Modified = time.Now().Local()
+		kostPeriods[i].ModifiedBy = currentUser.Username
+	}
+
+	return tx.Create(&kostPeriods).Error
+}
+
+// saveKostDraftFacilities replaces the kost facilities section
+func saveKostDraftFacilities(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error {
+
+	if err := tx.Where("kost_id = ?", targetKost.ID).Delete(&database.DBKostFacilities{}).Error; err != nil {
+		return err
+	}
+
+	facilities := kostReq.Facilities
+	if len(facilities) == 0 {
+		return nil
+	}
+
+	for i := range facilities {
+		facilities[i].ID = 0
+		facilities[i].KostID = targetKost.ID
+		facilities[i].IsActive = true
+		facilities[i].Created = time.Now().Local()
+		facilities[i].CreatedBy = currentUser.Username
+		facilities[i].Modified = time.Now().Local()
+		facilities[i].ModifiedBy = currentUser.Username
+	}
+
+	return tx.Create(&facilities).Error
+}
+
+// saveKostDraftBenchmark replaces the kost benchmark section
+func saveKostDraftBenchmark(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error {
+
+	if err := tx.Where("kost_id = ?", targetKost.ID).Delete(&database.DBKostBenchmark{}).Error; err != nil {
+		return err
+	}
+
+	kostBenchmark := kostReq.KostBenchmark
+	if len(kostBenchmark) == 0 {
+		return nil
+	}
+
+	for i := range kostBenchmark {
+		kostBenchmark[i].ID = 0
+		kostBenchmark[i].KostID = targetKost.ID
+		kostBenchmark[i].IsActive = true
+		kostBenchmark[i].Created = time.Now().Local()
+		kostBenchmark[i].CreatedBy = currentUser.Username
+		kostBenchmark[i].Modified = time.Now().Local()
+		kostBenchmark[i].ModifiedBy = currentUser.Username
+	}
+
+	return tx.Create(&kostBenchmark).Error
+}
+
+// saveKostDraftAccess replaces the kost accessibility section
+func saveKostDraftAccess(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error {
+
+	if err := tx.Where("kost_id = ?", targetKost.ID).Delete(&database.DBKostAccess{}).Error; err != nil {
+		return err
+	}
+
+	kostAccess := kostReq.KostAccess
+	if len(kostAccess) == 0 {
+		return nil
+	}
+
+	for i := range kostAccess {
+		kostAccess[i].ID = 0
+		kostAccess[i].KostID = targetKost.ID
+		kostAccess[i].IsActive = true
+		kostAccess[i].Created = time.Now().Local()
+		kostAccess[i].CreatedBy = currentUser.Username
+		kostAccess[i].Modified = time.Now().Local()
+		kostAccess[i].ModifiedBy = currentUser.Username
+	}
+
+	return tx.Create(&kostAccess).Error
+}
+
+// saveKostDraftAround replaces the around kost section
+func saveKostDraftAround(kost *Kost, tx *gorm.DB, currentUser *database.MasterUser, targetKost *database.DBKost, kostReq *entities.Kost) error {
+
+	if err := tx.Where("kost_id = ?", targetKost.ID).Delete(&database.DBKostAround{}).Error; err != nil {
+		return err
+	}
+
+	kostAround := kostReq.KostAround
+	if len(kostAround) == 0 {
+		return nil
+	}
+
+	for i := range kostAround {
+		kostAround[i].ID = 0
+		kostAround[i].KostID = targetKost.ID
+		kostAround[i].IsActive = true
+		kostAround[i].Created = time.Now().Local()
+		kostAround[i].CreatedBy = currentUser.Username
+		kostAround[i].Modified = time.Now().Local()
+		kostAround[i].ModifiedBy = currentUser.Username
+	}
+
+	return tx.Create(&kostAround).Error
+}
+
+// requireKostDraft makes sure the given kost is still a draft, the submitted kost can't be changed section by section
+func requireKostDraft(targetKost *database.DBKost) error {
+
+	if targetKost.Status != KostStatusDraft {
+		return NewError(ErrCodeConflict, "Kost sudah diajukan, hanya draft kost yang bisa diubah")
+	}
+
+	return nil
+}
+
+// AddKostDraft is a function to save a new kost draft of the given user from the given kost info,
+// the draft is hidden from the kost list until it is submitted
+func (kost *Kost) AddKostDraft(currentUser *database.MasterUser, kostReq *entities.Kost) (*database.DBKost, error) {
+
+	validator := NewValidator()
+	validateKostDraftInfo(validator, kostReq)
+	if err := validator.Validate(); err != nil {
+		return nil, err
+	}
+
+	newKost := &database.DBKost{
+		OwnerID:       currentUser.ID,
+		TypeID:        kostReq.TypeID,
+		Status:        KostStatusDraft,
+		KostName:      strings.TrimSpace(kostReq.KostName),
+		KostDesc:      kostReq.KostDesc,
+		Country:       strings.TrimSpace(kostReq.Country),
+		City:          strings.TrimSpace(kostReq.City),
+		Address:       strings.TrimSpace(kostReq.Address),
+		Latitude:      strings.TrimSpace(kostReq.Latitude),
+		Longitude:     strings.TrimSpace(kostReq.Longitude),
+		UpRateExpired: time.Now().Local(),
+		Created:       time.Now().Local(),
+		CreatedBy:     currentUser.Username,
+		Modified:      time.Now().Local(),
+		ModifiedBy:    currentUser.Username,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+
+		if dbErr := tx.Create(newKost).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// the zero value is replaced by the column default on create, so the draft is hidden afterwards
+		newKost.IsActive = false
+
+		return tx.Model(newKost).Update("is_active", false).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newKost, nil
+}
+
+// UpdateKostDraftSection is a function to validate and save the given section of the given kost draft
+func (kost *Kost) UpdateKostDraftSection(currentUser *database.MasterUser, targetKost *database.DBKost, sectionName string, kostReq *entities.Kost) error {
+
+	if err := requireKostDraft(targetKost); err != nil {
+		return err
+	}
+
+	section, ok := kostDraftSections[sectionName]
+	if !ok {
+		return NewError(ErrCodeNotFound, "Bagian draft kost %s tidak ditemukan", sectionName)
+	}
+
+	validator := NewValidator()
+	section.validate(validator, kostReq)
+	if err := validator.Validate(); err != nil {
+		return err
+	}
+
+	// save the section with transaction scope so the replaced content is never half saved
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+
+		if dbErr := section.save(kost, tx, currentUser, targetKost, kostReq); dbErr != nil {
+			return dbErr
+		}
+
+		return tx.Model(&database.DBKost{}).Where("id = ?", targetKost.ID).Updates(map[string]interface{}{
+			"modified":    time.Now().Local(),
+			"modified_by": currentUser.Username,
+		}).Error
+	})
+}
+
+// countKostSections counts the active rows of the sections of the given kost
+func countKostSections(kostID uint) (*kostSectionCounts, error) {
+
+	counts := &kostSectionCounts{}
+	sectionCounts := []struct {
+		model interface{}
+		count *int64
+	}{
+		{&database.DBKostPeriod{}, &counts.periods},
+		{&database.DBKostPict{}, &counts.picts},
+		{&database.DBKostFacilities{}, &counts.facilities},
+		{&database.DBKostBenchmark{}, &counts.benchmark},
+		{&database.DBKostAccess{}, &counts.access},
+		{&database.DBKostAround{}, &counts.around},
+		{&database.DBKostRoom{}, &counts.rooms},
+	}
+
+	for _, sectionCount := range sectionCounts {
+		if err := config.DB.Model(sectionCount.model).Where("kost_id = ? AND is_active = ?", kostID, true).Count(sectionCount.count).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// every room needs at least one pict
+	if err := config.DB.Model(&database.DBKostRoom{}).
+		Where("kost_id = ? AND is_active = ?", kostID, true).
+		Where("NOT EXISTS (SELECT 1 FROM db_kost_room_picts WHERE db_kost_room_picts.room_id = db_kost_rooms.id AND db_kost_room_picts.is_active = ?)", true).
+		Count(&counts.roomsWithoutPict).Error; err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetKostCompleteness is a function to get the completeness score and the checklist of the given kost
+// in the given language, the draft can only be submitted when every required item is done
+func (kost *Kost) GetKostCompleteness(targetKost *database.DBKost, language string) (*entities.KostCompleteness, error) {
+
+	counts, err := countKostSections(targetKost.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	completeness := &entities.KostCompleteness{
+		KostID:    targetKost.ID,
+		Status:    targetKost.Status,
+		CanSubmit: true,
+		Checklist: []entities.KostChecklistItem{},
+	}
+
+	var totalWeight, doneWeight int
+	for _, item := range kostChecklist {
+
+		done := item.done(targetKost, counts)
+		completeness.Checklist = append(completeness.Checklist, entities.KostChecklistItem{
+			Key:      item.key,
+			Section:  item.section,
+			Label:    Translate(language, item.label),
+			Required: item.required,
+			Done:     done,
+		})
+
+		totalWeight += item.weight
+		if done {
+			doneWeight += item.weight
+		} else if item.required {
+			completeness.CanSubmit = false
+		}
+	}
+
+	completeness.Score = doneWeight * 100 / totalWeight
+
+	return completeness, nil
+}
+
+// SubmitKostDraft is a function to submit the given kost draft for the admin approval,
+// the draft gets its kost code and thumbnail and is listed as a new kost
+func (kost *Kost) SubmitKostDraft(currentUser *database.MasterUser, targetKost *database.DBKost) error {
+
+	if err := requireKostDraft(targetKost); err != nil {
+		return err
+	}
+
+	completeness, err := kost.GetKostCompleteness(targetKost, DefaultLanguage)
+	if err != nil {
+		return err
+	}
+
+	// report the missing required items as the invalid fields
+	if !completeness.CanSubmit {
+		incompleteErr := NewError(ErrCodeValidation, "Draft kost belum lengkap")
+		for _, item := range completeness.Checklist {
+			if item.Required && !item.Done {
+				incompleteErr.Fields = append(incompleteErr.Fields, FieldError{Field: item.Key, Format: "Wajib dilengkapi sebelum diajukan"})
+			}
+		}
+
+		return incompleteErr
+	}
+
+	kostCode, err := kost.GenerateCode("K", targetKost.Country[0:1], targetKost.City[0:1])
+	if err != nil {
+		return err
+	}
+
+	// submit the draft with transaction scope
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// only the draft is updated, so the concurrent submissions are applied once
+		result := tx.Model(&database.DBKost{}).Where("id = ? AND status = ?", targetKost.ID, KostStatusDraft).Updates(map[string]interface{}{
+			"status":      KostStatusPending,
+			"kost_code":   kostCode,
+			"is_active":   true,
+			"modified":    time.Now().Local(),
+			"modified_by": currentUser.Username,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return NewError(ErrCodeConflict, "Kost sudah diajukan, hanya draft kost yang bisa diubah")
+		}
+
+		targetKost.Status = KostStatusPending
+		targetKost.KostCode = kostCode
+		targetKost.IsActive = true
+
+		return kost.ReassignKostThumbnail(tx, currentUser, targetKost)
+	})
+}
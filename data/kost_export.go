@@ -0,0 +1,539 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+)
+
+// the formats of the kost export
+const (
+	KostExportCSV  = "csv"
+	KostExportXLSX = "xlsx"
+)
+
+// the layouts of the exported dates
+const (
+	kostExportDateLayout     = "2006-01-02"
+	kostExportDateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// kostExportColumn is a column of the kost export, the numeric column is written as a number in the xlsx file
+type kostExportColumn struct {
+	key     string
+	numeric bool
+}
+
+// kostExportDataset is the columns of a kost export dataset and the function writing its rows one at a time
+type kostExportDataset struct {
+	columns []kostExportColumn
+	rows    func(kost *Kost, filter *entities.KostExportFilter, write func(row map[string]string) error) error
+}
+
+// kostExportDatasets is the available datasets of the kost export by its name,
+// the columns are exported in the order they are listed unless the columns are selected
+var kostExportDatasets = map[string]*kostExportDataset{
+	"kosts": {
+		columns: []kostExportColumn{
+			{key: "kost_id", numeric: true},
+			{key: "owner_id", numeric: true},
+			{key: "kost_code"},
+			{key: "kost_name"},
+			{key: "status", numeric: true},
+			{key: "country"},
+			{key: "city"},
+			{key: "address"},
+			{key: "latitude", numeric: true},
+			{key: "longitude", numeric: true},
+			{key: "is_verified"},
+			{key: "is_active"},
+			{key: "created"},
+		},
+		rows: kostExportKostRows,
+	},
+	"rooms": {
+		columns: []kostExportColumn{
+			{key: "kost_id", numeric: true},
+			{key: "kost_code"},
+			{key: "kost_name"},
+			{key: "room_id", numeric: true},
+			{key: "room_desc"},
+			{key: "room_detail_id", numeric: true},
+			{key: "room_number"},
+			{key: "floor_level", numeric: true},
+			{key: "price", numeric: true},
+			{key: "currency"},
+			{key: "status", numeric: true},
+			{key: "booker"},
+			{key: "prev_payment"},
+			{key: "next_payment"},
+			{key: "is_active"},
+		},
+		rows: kostExportRoomRows,
+	},
+	"bookings": {
+		columns: []kostExportColumn{
+			{key: "book_id", numeric: true},
+			{key: "book_code"},
+			{key: "book_date"},
+			{key: "kost_id", numeric: true},
+			{key: "kost_code"},
+			{key: "kost_name"},
+			{key: "room_desc"},
+			{key: "room_number"},
+			{key: "booker"},
+			{key: "period"},
+			{key: "status", numeric: true},
+			{key: "room_price", numeric: true},
+			{key: "discount_amount", numeric: true},
+			{key: "invoice_amount", numeric: true},
+			{key: "created"},
+		},
+		rows: kostExportBookingRows,
+	},
+}
+
+// csvFormulaPrefixes is the leading characters the spreadsheet apps read as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// KostExport is a kost export ready to be written, its rows are only read from the db while it is written
+type KostExport struct {
+	Dataset string
+	Format  string
+	kost    *Kost
+	dataset *kostExportDataset
+	columns []kostExportColumn
+	filter  *entities.KostExportFilter
+}
+
+// formatExportDate formats the given exported date, the zero date is left empty
+func formatExportDate(date time.Time, layout string) string {
+
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.Format(layout)
+}
+
+// formatExportNumber formats the given exported number without the exponent
+func formatExportNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// kostExportModel gets the query of the given model joined with the kosts of the owner in the given filter,
+// every kost is queried if no owner is given
+func kostExportModel(model *gorm.DB, filter *entities.KostExportFilter) *gorm.DB {
+
+	if filter.OwnerID != 0 {
+		model = model.Where("db_kosts.owner_id = ?", filter.OwnerID)
+	}
+
+	return model
+}
+
+// kostExportDateRange limits the given query to the given date column in the date range of the given filter,
+// the end date is inclusive
+func kostExportDateRange(model *gorm.DB, column string, filter *entities.KostExportFilter) *gorm.DB {
+
+	if filter.From != nil {
+		model = model.Where(column+" >= ?", *filter.From)
+	}
+
+	if filter.To != nil {
+		model = model.Where(column+" < ?", filter.To.AddDate(0, 0, 1))
+	}
+
+	return model
+}
+
+// forEachExportRow runs the given query and scans its rows one at a time into the given destination,
+// the given function is called after every row so the rows are never held in memory
+func forEachExportRow(model *gorm.DB, dest interface{}, fn func() error) error {
+
+	rows, err := model.Rows()
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := config.DB.ScanRows(rows, dest); err != nil {
+			return err
+		}
+
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// kostExportKostRows writes the kost export rows of the kosts created in the date range
+func kostExportKostRows(kost *Kost, filter *entities.KostExportFilter, write func(row map[string]string) error) error {
+
+	model := kostExportModel(config.DB.Model(&database.DBKost{}), filter)
+	model = kostExportDateRange(model, "db_kosts.created", filter)
+
+	var kostItem database.DBKost
+	return forEachExportRow(model.Order("db_kosts.owner_id, db_kosts.id"), &kostItem, func() error {
+		return write(map[string]string{
+			"kost_id":     strconv.FormatUint(uint64(kostItem.ID), 10),
+			"owner_id":    strconv.FormatUint(uint64(kostItem.OwnerID), 10),
+			"kost_code":   kostItem.KostCode,
+			"kost_name":   kostItem.KostName,
+			"status":      strconv.FormatUint(uint64(kostItem.Status), 10),
+			"country":     kostItem.Country,
+			"city":        kostItem.City,
+			"address":     kostItem.Address,
+			"latitude":    kostItem.Latitude,
+			"longitude":   kostItem.Longitude,
+			"is_verified": strconv.FormatBool(kostItem.IsVerified),
+			"is_active":   strconv.FormatBool(kostItem.IsActive),
+			"created":     formatExportDate(kostItem.Created, kostExportDateTimeLayout),
+		})
+	})
+}
+
+// kostExportRoomDetail is a room detail of the rooms export along with its room and kost
+type kostExportRoomDetail struct {
+	KostID       uint
+	KostCode     string
+	KostName     string
+	RoomID       uint
+	RoomDesc     string
+	RoomDetailID uint
+	RoomNumber   string
+	FloorLevel   uint
+	Price        float64
+	Currency     string
+	IsActive     bool
+}
+
+// kostExportRoomBook is a paid room booking along with its period and booker
+type kostExportRoomBook struct {
+	RoomDetailID uint
+	Status       uint
+	BookDate     time.Time
+	PeriodValue  float64
+	Booker       string
+}
+
+// kostExportCurrentBooks gets the current booking of the room details of the given filter by the room detail id,
+// the booking is picked the same way as the room detail info, i.e. the first paid booking that is not over yet
+func kostExportCurrentBooks(kost *Kost, filter *entities.KostExportFilter) (map[uint]kostExportRoomBook, error) {
+
+	longestPeriod, err := kost.GetMasterPeriodLongest()
+	if err != nil {
+		return nil, err
+	}
+
+	dateNow := time.Now()
+	dateBefore := dateNow.AddDate(0, 0, int(-longestPeriod.PeriodValue))
+
+	model := kostExportModel(config.DB.Model(&database.DBTransactionRoomBook{}), filter).
+		Select("db_transaction_room_books.room_detail_id"+
+			",db_transaction_room_books.status"+
+			",db_transaction_room_books.book_date"+
+			",master_periods.period_value"+
+			",master_users.display_name as booker").
+		Joins("inner join db_kosts on db_kosts.id = db_transaction_room_books.kost_id").
+		Joins("inner join master_periods on master_periods.id = db_transaction_room_books.period_id").
+		Joins("inner join master_users on master_users.id = db_transaction_room_books.booker_id").
+		Where("db_transaction_room_books.status = 2 AND db_transaction_room_books.book_date >= ?", dateBefore).
+		Order("db_transaction_room_books.id")
+
+	currentBooks := make(map[uint]kostExportRoomBook)
+
+	var roomBook kostExportRoomBook
+	err = forEachExportRow(model, &roomBook, func() error {
+
+		if _, ok := currentBooks[roomBook.RoomDetailID]; ok {
+			return nil
+		}
+
+		// the booking whose period is already over no longer occupies the room detail
+		dateAfterBook := roomBook.BookDate.AddDate(0, 0, int(roomBook.PeriodValue))
+		if dateAfterBook.After(roomBook.BookDate) && dateAfterBook.Before(dateNow) {
+			return nil
+		}
+
+		currentBooks[roomBook.RoomDetailID] = roomBook
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return currentBooks, nil
+}
+
+// kostExportRoomRows writes the kost export rows of the room details along with their current booker,
+// the room details are the current occupancy so the date range is not applied
+func kostExportRoomRows(kost *Kost, filter *entities.KostExportFilter, write func(row map[string]string) error) error {
+
+	// only the occupied room details have a current booking, so they are looked for at once before the room details
+	currentBooks, err := kostExportCurrentBooks(kost, filter)
+	if err != nil {
+		return err
+	}
+
+	model := kostExportModel(config.DB.Model(&database.DBKostRoomDetail{}), filter).
+		Select("db_kost_room_details.kost_id" +
+			",db_kosts.kost_code" +
+			",db_kosts.kost_name" +
+			",db_kost_room_details.room_id" +
+			",db_kost_rooms.room_desc" +
+			",db_kost_room_details.id as room_detail_id" +
+			",db_kost_room_details.room_number" +
+			",db_kost_room_details.floor_level" +
+			",db_kost_rooms.room_price as price" +
+			",master_uoms.uom_desc as currency" +
+			",db_kost_room_details.is_active").
+		Joins("inner join db_kosts on db_kosts.id = db_kost_room_details.kost_id").
+		Joins("inner join db_kost_rooms on db_kost_rooms.id = db_kost_room_details.room_id").
+		Joins("left join master_uoms on master_uoms.id = db_kost_rooms.room_price_uom").
+		Order("db_kosts.owner_id, db_kost_room_details.kost_id, db_kost_room_details.room_id, db_kost_room_details.id")
+
+	var roomDetail kostExportRoomDetail
+	return forEachExportRow(model, &roomDetail, func() error {
+
+		row := map[string]string{
+			"kost_id":        strconv.FormatUint(uint64(roomDetail.KostID), 10),
+			"kost_code":      roomDetail.KostCode,
+			"kost_name":      roomDetail.KostName,
+			"room_id":        strconv.FormatUint(uint64(roomDetail.RoomID), 10),
+			"room_desc":      roomDetail.RoomDesc,
+			"room_detail_id": strconv.FormatUint(uint64(roomDetail.RoomDetailID), 10),
+			"room_number":    roomDetail.RoomNumber,
+			"floor_level":    strconv.FormatUint(uint64(roomDetail.FloorLevel), 10),
+			"price":          formatExportNumber(roomDetail.Price),
+			"currency":       roomDetail.Currency,
+			"is_active":      strconv.FormatBool(roomDetail.IsActive),
+		}
+
+		// the vacant room detail has no booker
+		if roomBook, ok := currentBooks[roomDetail.RoomDetailID]; ok {
+			row["status"] = strconv.FormatUint(uint64(roomBook.Status), 10)
+			row["booker"] = roomBook.Booker
+			row["prev_payment"] = formatExportDate(roomBook.BookDate, kostExportDateLayout)
+			row["next_payment"] = formatExportDate(roomBook.BookDate.AddDate(0, 0, int(roomBook.PeriodValue)), kostExportDateLayout)
+		}
+
+		return write(row)
+	})
+}
+
+// kostExportBooking is a room booking of the bookings export along with its kost, room, booker and period
+type kostExportBooking struct {
+	ID             uint
+	BookCode       string
+	BookDate       time.Time
+	KostID         uint
+	KostCode       string
+	KostName       string
+	RoomDesc       string
+	RoomNumber     string
+	Booker         string
+	Period         string
+	Status         uint
+	RoomPrice      float64
+	DiscountAmount float64
+	InvoiceAmount  float64
+	Created        time.Time
+}
+
+// kostExportBookingRows writes the kost export rows of the room bookings booked in the date range
+func kostExportBookingRows(kost *Kost, filter *entities.KostExportFilter, write func(row map[string]string) error) error {
+
+	model := kostExportModel(config.DB.Model(&database.DBTransactionRoomBook{}), filter).
+		Select("db_transaction_room_books.id" +
+			",db_transaction_room_books.book_code" +
+			",db_transaction_room_books.book_date" +
+			",db_transaction_room_books.kost_id" +
+			",db_kosts.kost_code" +
+			",db_kosts.kost_name" +
+			",db_kost_rooms.room_desc" +
+			",db_kost_room_details.room_number" +
+			",master_users.display_name as booker" +
+			",master_periods.period_desc as period" +
+			",db_transaction_room_books.status" +
+			",db_transaction_room_books.room_price" +
+			",db_transaction_room_books.discount_amount" +
+			",db_transaction_room_books.invoice_amount" +
+			",db_transaction_room_books.created").
+		Joins("inner join db_kosts on db_kosts.id = db_transaction_room_books.kost_id").
+		Joins("left join db_kost_rooms on db_kost_rooms.id = db_transaction_room_books.room_id").
+		Joins("left join db_kost_room_details on db_kost_room_details.id = db_transaction_room_books.room_detail_id").
+		Joins("left join master_users on master_users.id = db_transaction_room_books.booker_id").
+		Joins("left join master_periods on master_periods.id = db_transaction_room_books.period_id")
+	model = kostExportDateRange(model, "db_transaction_room_books.book_date", filter)
+
+	var roomBook kostExportBooking
+	return forEachExportRow(model.Order("db_transaction_room_books.book_date, db_transaction_room_books.id"), &roomBook, func() error {
+		return write(map[string]string{
+			"book_id":         strconv.FormatUint(uint64(roomBook.ID), 10),
+			"book_code":       roomBook.BookCode,
+			"book_date":       formatExportDate(roomBook.BookDate, kostExportDateLayout),
+			"kost_id":         strconv.FormatUint(uint64(roomBook.KostID), 10),
+			"kost_code":       roomBook.KostCode,
+			"kost_name":       roomBook.KostName,
+			"room_desc":       roomBook.RoomDesc,
+			"room_number":     roomBook.RoomNumber,
+			"booker":          roomBook.Booker,
+			"period":          roomBook.Period,
+			"status":          strconv.FormatUint(uint64(roomBook.Status), 10),
+			"room_price":      formatExportNumber(roomBook.RoomPrice),
+			"discount_amount": formatExportNumber(roomBook.DiscountAmount),
+			"invoice_amount":  formatExportNumber(roomBook.InvoiceAmount),
+			"created":         formatExportDate(roomBook.Created, kostExportDateTimeLayout),
+		})
+	})
+}
+
+// ExportKostData is a function to validate the given kost export of the kosts owned by the given user,
+// the user approving the kosts exports the kosts of the owner in the filter or every kost if no owner is given,
+// the rows are only read once the export is written so they are streamed instead of being held in memory
+func (kost *Kost) ExportKostData(currentUser *database.MasterUser, filter *entities.KostExportFilter) (*KostExport, error) {
+
+	dataset, ok := kostExportDatasets[filter.Dataset]
+	if !ok {
+		return nil, NewError(ErrCodeNotFound, "Data export %s tidak ditemukan", filter.Dataset)
+	}
+
+	if filter.Format == "" {
+		filter.Format = KostExportCSV
+	}
+
+	if filter.Format != KostExportCSV && filter.Format != KostExportXLSX {
+		return nil, NewError(ErrCodeValidation, "Format export %s tidak valid, gunakan csv atau xlsx", filter.Format)
+	}
+
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return nil, NewError(ErrCodeValidation, "Tanggal akhir harus setelah tanggal awal")
+	}
+
+	// pick the selected columns in the selected order, every column is exported if none is selected
+	columns := dataset.columns
+	if len(filter.Columns) > 0 {
+
+		columnErr := NewError(ErrCodeValidation, "Kolom export tidak valid")
+		columns = nil
+		for i, key := range filter.Columns {
+
+			found := false
+			for _, column := range dataset.columns {
+				if column.key == key {
+					columns = append(columns, column)
+					found = true
+				}
+			}
+
+			if !found {
+				columnErr.Fields = append(columnErr.Fields, FieldError{Field: fmt.Sprintf("columns[%d]", i), Format: "Kolom %s tidak tersedia", Args: []interface{}{key}})
+			}
+		}
+
+		if len(columnErr.Fields) > 0 {
+			return nil, columnErr
+		}
+	}
+
+	// the owners only export their own kosts
+	if !HasPermission(currentUser.RoleID, PermApproveKost) {
+		filter.OwnerID = currentUser.ID
+	}
+
+	return &KostExport{Dataset: filter.Dataset, Format: filter.Format, kost: kost, dataset: dataset, columns: columns, filter: filter}, nil
+}
+
+// FileName gets the file name of the kost export on the given date
+func (export *KostExport) FileName(date time.Time) string {
+	return fmt.Sprintf("kost-%s-%s.%s", export.Dataset, date.Format(kostExportDateLayout), export.Format)
+}
+
+// ContentType gets the content type of the kost export
+func (export *KostExport) ContentType() string {
+
+	if export.Format == KostExportXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// Write is a method to write the kost export as csv or xlsx to the given writer, every row is written as soon as it is read
+func (export *KostExport) Write(dst io.Writer) error {
+
+	header := make([]string, len(export.columns))
+	numericColumns := make(map[int]bool)
+	for i, column := range export.columns {
+		header[i] = column.key
+		numericColumns[i] = column.numeric
+	}
+
+	if export.Format == KostExportXLSX {
+		return WriteXLSX(dst, export.Dataset, numericColumns, func(write func(row []string) error) error {
+
+			if err := write(header); err != nil {
+				return err
+			}
+
+			return export.dataset.rows(export.kost, export.filter, func(row map[string]string) error {
+
+				record := make([]string, len(export.columns))
+				for i, column := range export.columns {
+					record[i] = row[column.key]
+				}
+
+				return write(record)
+			})
+		})
+	}
+
+	// the utf-8 bom makes the spreadsheet apps read the csv as utf-8
+	if _, err := io.WriteString(dst, "\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(dst)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := export.dataset.rows(export.kost, export.filter, func(row map[string]string) error {
+
+		record := make([]string, len(export.columns))
+		for i, column := range export.columns {
+			record[i] = row[column.key]
+
+			// the text starting like a formula is quoted so the spreadsheet apps don't run it, the numbers are kept as is
+			if record[i] != "" && strings.ContainsRune(csvFormulaPrefixes, rune(record[i][0])) &&
+				!(column.numeric && xlsxNumberPattern.MatchString(record[i])) {
+				record[i] = "'" + record[i]
+			}
+		}
+
+		return writer.Write(record)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,653 @@
+package data
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+)
+
+// maxKostImportRows is the max number of rows of a single kost import, the import runs within the request
+// so it is kept small enough to be added before the server write timeout
+const maxKostImportRows = 200
+
+// the modes of the kost import
+const (
+	KostImportAtomic  = "atomic"  // every kost is added in a single transaction, nothing is added if any row is invalid
+	KostImportPartial = "partial" // every kost is added on its own, the valid kosts are added even if the others are not
+)
+
+// KostImportTemplate is the columns of the kost import template, every row is a room of the kost identified by the kost ref,
+// the kost columns are read from the first row of the kost and the list columns are separated by | or ;
+var KostImportTemplate = []string{
+	"kost_ref", "kost_name", "kost_type", "kost_desc", "country", "city", "address", "latitude", "longitude",
+	"periods", "facilities", "kost_pict_urls",
+	"room_desc", "room_price", "price_uom", "room_length", "room_width", "area_uom", "max_person", "allowed_gender",
+	"room_numbers", "floor_level", "room_pict_urls",
+}
+
+// kostImportRequiredColumns is the template columns the import file must have
+var kostImportRequiredColumns = []string{
+	"kost_ref", "kost_name", "kost_type", "country", "city", "address", "latitude", "longitude", "periods",
+	"room_desc", "room_price", "price_uom", "room_length", "room_width", "area_uom", "max_person", "room_pict_urls",
+}
+
+// kostImportColumnAliases maps the normalized header in indonesian to the template column
+var kostImportColumnAliases = map[string]string{
+	"ref":              "kost_ref",
+	"referensi":        "kost_ref",
+	"namakost":         "kost_name",
+	"tipekost":         "kost_type",
+	"deskripsi":        "kost_desc",
+	"deskripsikost":    "kost_desc",
+	"negara":           "country",
+	"kota":             "city",
+	"alamat":           "address",
+	"lat":              "latitude",
+	"lng":              "longitude",
+	"long":             "longitude",
+	"periode":          "periods",
+	"periodesewa":      "periods",
+	"fasilitas":        "facilities",
+	"fasilitaskost":    "facilities",
+	"fotokost":         "kost_pict_urls",
+	"namakamar":        "room_desc",
+	"tipekamar":        "room_desc",
+	"hargakamar":       "room_price",
+	"harga":            "room_price",
+	"satuanharga":      "price_uom",
+	"matauang":         "price_uom",
+	"panjangkamar":     "room_length",
+	"lebarkamar":       "room_width",
+	"satuanluas":       "area_uom",
+	"maksimalpenghuni": "max_person",
+	"jeniskelamin":     "allowed_gender",
+	"nomorkamar":       "room_numbers",
+	"units":            "room_numbers",
+	"lantai":           "floor_level",
+	"fotokamar":        "room_pict_urls",
+}
+
+// kostImportFieldColumns maps the json field of the kost payload to the template column,
+// so the validation errors point to the column of the import file
+var kostImportFieldColumns = map[string]string{
+	"type_id":        "kost_type",
+	"kost_periods":   "periods",
+	"kost_picts":     "kost_pict_urls",
+	"room_price_uom": "price_uom",
+	"room_area_uom":  "area_uom",
+	"room_picts":     "room_pict_urls",
+	"room_details":   "room_numbers",
+}
+
+// kostImportListSeparator splits the multiple values of a template cell, e.g. AC|WiFi
+var kostImportListSeparator = regexp.MustCompile(`[|;\n]`)
+
+// kostImportRoomField matches the room field of the kost payload, e.g. rooms[2].room_price
+var kostImportRoomField = regexp.MustCompile(`^rooms\[([0-9]+)\]\.(.+)$`)
+
+// kostImportGroup is the rows of a single kost in the import file
+type kostImportGroup struct {
+	kostRef string
+	rows    []int
+	kostReq *entities.Kost
+	failed  bool
+}
+
+// kostImportMasterData is the active master data looked up by its lower cased name
+type kostImportMasterData struct {
+	kostTypes    map[string]uint
+	periods      map[string]uint
+	facilities   map[string]uint
+	currencyUOMs map[string]uint
+	lengthUOMs   map[string]uint
+}
+
+// masterDataKey normalizes the given master data name so it is looked up regardless of its case and spacing
+func masterDataKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// loadKostImportMasterData gets the active master data referenced by name in the import file
+func (kost *Kost) loadKostImportMasterData() (*kostImportMasterData, error) {
+
+	masterData := &kostImportMasterData{
+		kostTypes:    map[string]uint{},
+		periods:      map[string]uint{},
+		facilities:   map[string]uint{},
+		currencyUOMs: map[string]uint{},
+		lengthUOMs:   map[string]uint{},
+	}
+
+	kostTypes, err := kost.GetMasterDataList("kost-types", false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kostType := range *kostTypes.(*[]database.MasterKostType) {
+		masterData.kostTypes[masterDataKey(kostType.TypeDesc)] = kostType.ID
+	}
+
+	periods, err := kost.GetMasterDataList("periods", false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, period := range *periods.(*[]database.MasterPeriod) {
+		masterData.periods[masterDataKey(period.PeriodDesc)] = period.ID
+	}
+
+	facilities, err := kost.GetMasterDataList("facilities", false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, facility := range *facilities.(*[]database.MasterFacilities) {
+		masterData.facilities[masterDataKey(facility.FacName)] = facility.ID
+	}
+
+	uoms, err := kost.GetMasterDataList("uoms", false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, uom := range *uoms.(*[]database.MasterUOM) {
+		if uom.UOMType == "currency" {
+			masterData.currencyUOMs[masterDataKey(uom.UOMDesc)] = uom.ID
+		} else if uom.UOMType == "length" {
+			masterData.lengthUOMs[masterDataKey(uom.UOMDesc)] = uom.ID
+		}
+	}
+
+	return masterData, nil
+}
+
+// splitKostImportList splits the given list cell into its non empty values
+func splitKostImportList(value string) []string {
+
+	var values []string
+	for _, item := range kostImportListSeparator.Split(value, -1) {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
+
+// parseKostImportNumber parses the number of the template cell, the thousand separators and the decimal comma are tolerated
+func parseKostImportNumber(value string) (float64, bool) {
+
+	value = strings.TrimSpace(strings.Replace(value, " ", "", -1))
+	if value == "" {
+		return 0, true
+	}
+
+	if thousandGroupedPattern.MatchString(value) {
+		value = strings.NewReplacer(",", "", ".", "").Replace(value)
+	}
+
+	number, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+
+	return number, err == nil
+}
+
+// readKostImportRecords reads the rows of the given csv or xlsx import file
+func readKostImportRecords(content []byte) ([][]string, error) {
+
+	if IsXLSX(content) {
+		return ReadXLSX(content)
+	}
+
+	// skip the utf-8 bom of the spreadsheet exports
+	content = bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})
+
+	// detect the delimiter from the header line
+	headerLine := content
+	if index := bytes.IndexByte(content, '\n'); index != -1 {
+		headerLine = content[:index]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = detectCSVDelimiter(string(headerLine))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, WrapError(ErrCodeValidation, err, "Isi file import tidak valid")
+	}
+
+	return records, nil
+}
+
+// ImportKosts is a function to add the kosts of the given csv or xlsx import file owned by the given user,
+// every row is validated and the row level errors are reported in the given language,
+// the kosts are added by the same logic as a single new kost either all at once or one by one by the given mode,
+// the import is identified by the given idempotency key or the file hash so a retried import is never added twice
+func (kost *Kost) ImportKosts(currentUser *database.MasterUser, src io.Reader, mode, idempotencyKey, language string) (*entities.KostImportResult, error) {
+
+	if mode == "" {
+		mode = KostImportAtomic
+	}
+
+	if mode != KostImportAtomic && mode != KostImportPartial {
+		return nil, NewError(ErrCodeValidation, "Mode import %s tidak valid, gunakan atomic atau partial", mode)
+	}
+
+	// the import is capped by the request body size, so the whole file is read at once
+	content, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, RequestBodyError(err)
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, NewError(ErrCodeValidation, "File import kosong")
+	}
+
+	// the client without its own idempotency key is identified by the file content
+	if idempotencyKey == "" {
+		hash := sha256.Sum256(content)
+		idempotencyKey = hex.EncodeToString(hash[:])
+	}
+
+	if len(idempotencyKey) > 64 {
+		return nil, NewError(ErrCodeValidation, "Idempotency key maksimal %d karakter", 64)
+	}
+
+	records, err := readKostImportRecords(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, NewError(ErrCodeValidation, "File import kosong")
+	}
+
+	if len(records)-1 > maxKostImportRows {
+		return nil, NewError(ErrCodeValidation, "Maksimal %d baris per import", maxKostImportRows)
+	}
+
+	// map the header to the template columns, the unknown columns are ignored
+	columnIndexes := make(map[string]int)
+	for index, name := range records[0] {
+
+		column := nonHeaderPattern.ReplaceAllString(strings.ToLower(name), "")
+		for _, templateColumn := range KostImportTemplate {
+			if column == strings.Replace(templateColumn, "_", "", -1) {
+				column = templateColumn
+			}
+		}
+
+		if alias, ok := kostImportColumnAliases[column]; ok {
+			column = alias
+		}
+
+		if _, exists := columnIndexes[column]; !exists {
+			columnIndexes[column] = index
+		}
+	}
+
+	// report every missing column of the template at once
+	templateErr := NewError(ErrCodeValidation, "Template import tidak valid")
+	for _, column := range kostImportRequiredColumns {
+		if _, ok := columnIndexes[column]; !ok {
+			templateErr.Fields = append(templateErr.Fields, FieldError{Field: column, Format: "Kolom tidak ditemukan"})
+		}
+	}
+
+	if len(templateErr.Fields) > 0 {
+		return nil, templateErr
+	}
+
+	masterData, err := kost.loadKostImportMasterData()
+	if err != nil {
+		return nil, err
+	}
+
+	cell := func(record []string, column string) string {
+		index, ok := columnIndexes[column]
+		if !ok || index >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[index])
+	}
+
+	result := &entities.KostImportResult{
+		Mode:     mode,
+		Imported: []entities.KostImportedKost{},
+		Failed:   []entities.KostImportError{},
+	}
+
+	fail := func(group *kostImportGroup, row int, column, format string, args ...interface{}) {
+		group.failed = true
+		result.Failed = append(result.Failed, entities.KostImportError{
+			Row:     row,
+			KostRef: group.kostRef,
+			Column:  column,
+			Message: Translate(language, format, args...),
+		})
+	}
+
+	// group the rows by the kost ref keeping the order of the file
+	var groups []*kostImportGroup
+	groupsByRef := make(map[string]*kostImportGroup)
+
+	for index, record := range records[1:] {
+
+		// the header is the first row of the file
+		row := index + 2
+
+		isEmpty := true
+		for _, value := range record {
+			if strings.TrimSpace(value) != "" {
+				isEmpty = false
+			}
+		}
+
+		if isEmpty {
+			continue
+		}
+
+		kostRef := cell(record, "kost_ref")
+		if kostRef == "" {
+			fail(&kostImportGroup{}, row, "kost_ref", "Wajib diisi")
+			continue
+		}
+
+		group, ok := groupsByRef[kostRef]
+		if !ok {
+			group = &kostImportGroup{kostRef: kostRef, kostReq: &entities.Kost{}}
+			groupsByRef[kostRef] = group
+			groups = append(groups, group)
+
+			// the kost columns are read from the first row of the kost
+			kostReq := group.kostReq
+			kostReq.KostName = cell(record, "kost_name")
+			kostReq.KostDesc = cell(record, "kost_desc")
+			kostReq.Country = cell(record, "country")
+			kostReq.City = cell(record, "city")
+			kostReq.Address = cell(record, "address")
+			kostReq.Latitude = cell(record, "latitude")
+			kostReq.Longitude = cell(record, "longitude")
+
+			if kostType := cell(record, "kost_type"); kostType != "" {
+				if kostReq.TypeID, ok = masterData.kostTypes[masterDataKey(kostType)]; !ok {
+					fail(group, row, "kost_type", "%s tidak ditemukan pada master data", kostType)
+				}
+			}
+
+			for _, period := range splitKostImportList(cell(record, "periods")) {
+				if periodID, ok := masterData.periods[masterDataKey(period)]; ok {
+					kostReq.KostPeriods = append(kostReq.KostPeriods, database.DBKostPeriod{PeriodID: periodID})
+				} else {
+					fail(group, row, "periods", "%s tidak ditemukan pada master data", period)
+				}
+			}
+
+			for _, facility := range splitKostImportList(cell(record, "facilities")) {
+				if facilityID, ok := masterData.facilities[masterDataKey(facility)]; ok {
+					kostReq.Facilities = append(kostReq.Facilities, database.DBKostFacilities{FacID: facilityID})
+				} else {
+					fail(group, row, "facilities", "%s tidak ditemukan pada master data", facility)
+				}
+			}
+
+			for _, pictURL := range splitKostImportList(cell(record, "kost_pict_urls")) {
+				kostReq.KostPicts = append(kostReq.KostPicts, database.DBKostPict{URL: pictURL})
+			}
+		}
+
+		group.rows = append(group.rows, row)
+
+		// every row is a room of the kost
+		room := entities.KostRoom{
+			RoomDesc:      cell(record, "room_desc"),
+			AllowedGender: cell(record, "allowed_gender"),
+		}
+
+		numbers := []struct {
+			column string
+			target *float64
+		}{
+			{"room_price", &room.RoomPrice},
+			{"room_length", &room.RoomLength},
+			{"room_width", &room.RoomWidth},
+		}
+
+		for _, number := range numbers {
+			if value, ok := parseKostImportNumber(cell(record, number.column)); ok {
+				*number.target = value
+			} else {
+				fail(group, row, number.column, "Harus berupa angka")
+			}
+		}
+
+		if maxPerson, err := strconv.ParseUint(cell(record, "max_person"), 10, 32); err == nil {
+			room.MaxPerson = uint(maxPerson)
+		} else if cell(record, "max_person") != "" {
+			fail(group, row, "max_person", "Harus berupa angka")
+		}
+
+		if priceUOM := cell(record, "price_uom"); priceUOM != "" {
+			if room.RoomPriceUOM, ok = masterData.currencyUOMs[masterDataKey(priceUOM)]; !ok {
+				fail(group, row, "price_uom", "%s tidak ditemukan pada master data", priceUOM)
+			}
+		}
+
+		if areaUOM := cell(record, "area_uom"); areaUOM != "" {
+			if room.RoomAreaUOM, ok = masterData.lengthUOMs[masterDataKey(areaUOM)]; !ok {
+				fail(group, row, "area_uom", "%s tidak ditemukan pada master data", areaUOM)
+			}
+		}
+
+		var floorLevel uint
+		if value := cell(record, "floor_level"); value != "" {
+			if parsed, err := strconv.ParseUint(value, 10, 32); err == nil {
+				floorLevel = uint(parsed)
+			} else {
+				fail(group, row, "floor_level", "Harus berupa angka")
+			}
+		}
+
+		for _, roomNumber := range splitKostImportList(cell(record, "room_numbers")) {
+			room.RoomDetails = append(room.RoomDetails, database.DBKostRoomDetail{RoomNumber: roomNumber, FloorLevel: floorLevel})
+		}
+
+		for _, pictURL := range splitKostImportList(cell(record, "room_pict_urls")) {
+			room.RoomPicts = append(room.RoomPicts, database.DBKostRoomPict{URL: pictURL})
+		}
+
+		group.kostReq.Rooms = append(group.kostReq.Rooms, room)
+	}
+
+	// validate every kost by the same rules as a single new kost
+	for _, group := range groups {
+
+		if group.failed {
+			continue
+		}
+
+		err := kost.ValidateKost(group.kostReq)
+		if err == nil {
+			continue
+		}
+
+		var appErr *AppError
+		if !errors.As(err, &appErr) || len(appErr.Fields) == 0 {
+			return nil, err
+		}
+
+		for _, fieldErr := range appErr.Fields {
+
+			// the room errors point to the row of the room, the kost errors to the first row of the kost
+			row := group.rows[0]
+			field := fieldErr.Field
+			if match := kostImportRoomField.FindStringSubmatch(field); match != nil {
+				if index, err := strconv.Atoi(match[1]); err == nil && index < len(group.rows) {
+					row = group.rows[index]
+				}
+
+				field = match[2]
+			}
+
+			column := strings.SplitN(strings.SplitN(field, "[", 2)[0], ".", 2)[0]
+			if templateColumn, ok := kostImportFieldColumns[column]; ok {
+				column = templateColumn
+			}
+
+			fail(group, row, column, fieldErr.Format, fieldErr.Args...)
+		}
+	}
+
+	result.Total = len(groups)
+
+	// the atomic import adds nothing if any row is invalid
+	if mode == KostImportAtomic {
+
+		if len(result.Failed) > 0 {
+			return result, nil
+		}
+
+		var imported []entities.KostImportedKost
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			imported = nil
+
+			// the import is recorded in the same transaction, so the retried import is rejected once it is committed
+			if dbErr := kost.recordKostImport(tx, currentUser, idempotencyKey, mode, uint(len(groups))); dbErr != nil {
+				return dbErr
+			}
+
+			for _, group := range groups {
+				newKost, dbErr := kost.CreateKost(tx, currentUser, group.kostReq)
+				if dbErr != nil {
+					return dbErr
+				}
+
+				imported = append(imported, entities.KostImportedKost{KostRef: group.kostRef, KostID: newKost.ID, KostCode: newKost.KostCode, Rooms: len(group.kostReq.Rooms)})
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		result.Committed = true
+		result.Imported = append(result.Imported, imported...)
+
+		return result, nil
+	}
+
+	// the partial import is recorded before any kost is added, so the retried import is rejected while it is still running
+	if err := kost.recordKostImport(config.DB, currentUser, idempotencyKey, mode, 0); err != nil {
+		return nil, err
+	}
+
+	// the partial import adds every valid kost on its own
+	for _, group := range groups {
+
+		if group.failed {
+			continue
+		}
+
+		var newKost *database.DBKost
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var dbErr error
+			newKost, dbErr = kost.CreateKost(tx, currentUser, group.kostReq)
+
+			return dbErr
+		})
+
+		if err != nil {
+			appErr := ToAppError(err)
+			if appErr.Status >= http.StatusInternalServerError {
+				kost.logger.Error("Unable to import the kost", "kost_ref", group.kostRef, "error", err.Error())
+			}
+
+			fail(group, group.rows[0], "", appErr.Format, appErr.Args...)
+			continue
+		}
+
+		result.Imported = append(result.Imported, entities.KostImportedKost{KostRef: group.kostRef, KostID: newKost.ID, KostCode: newKost.KostCode, Rooms: len(group.kostReq.Rooms)})
+	}
+
+	result.Committed = len(result.Imported) > 0
+
+	// the import adding nothing can be retried once the file is fixed
+	importRecord := config.DB.Where("owner_id = ? AND idempotency_key = ?", currentUser.ID, idempotencyKey)
+	if result.Committed {
+		err = importRecord.Model(&database.DBKostImport{}).Update("imported", len(result.Imported)).Error
+	} else {
+		err = importRecord.Delete(&database.DBKostImport{}).Error
+	}
+
+	if err != nil {
+		kost.logger.Error("Unable to update the kost import record", "error", err.Error())
+	}
+
+	return result, nil
+}
+
+// recordKostImport records the import of the given idempotency key by the given user,
+// the import already recorded by the same key is rejected by the unique index
+func (kost *Kost) recordKostImport(tx *gorm.DB, currentUser *database.MasterUser, idempotencyKey, mode string, imported uint) error {
+
+	now := time.Now().Local()
+	err := tx.Create(&database.DBKostImport{
+		OwnerID:        currentUser.ID,
+		IdempotencyKey: idempotencyKey,
+		Mode:           mode,
+		Imported:       imported,
+		Created:        now,
+		CreatedBy:      currentUser.Username,
+		Modified:       now,
+		ModifiedBy:     currentUser.Username,
+	}).Error
+
+	if err != nil && strings.Contains(err.Error(), "Error 1062") {
+		return WrapError(ErrCodeConflict, err, "Import yang sama sudah diproses, gunakan idempotency key yang baru untuk mengimport ulang")
+	}
+
+	return err
+}
+
+// KostImportTemplateCSV is a function to write the kost import template with a sample row as csv
+func KostImportTemplateCSV(dst io.Writer) error {
+
+	writer := csv.NewWriter(dst)
+	if err := writer.Write(KostImportTemplate); err != nil {
+		return err
+	}
+
+	sample := []string{
+		"KOST-1", "Kost Melati", "Kost Putri", "Kost dekat kampus", "Indonesia", "Jakarta Selatan", "Jl. Melati No. 1",
+		"-6.2607", "106.7816", "Bulanan|Tahunan", "WiFi|Parkir Motor", "https://example.com/kost.jpg",
+		"Kamar Standar", "1.500.000", "IDR", "3", "4", "m", "1", "female",
+		"A1|A2|A3", "1", "https://example.com/kamar.jpg",
+	}
+
+	if err := writer.Write(sample); err != nil {
+		return err
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,326 @@
+package data
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+)
+
+// MasterDataCacheTTL is how long the active master data list is cached before it is read from the db again
+var MasterDataCacheTTL = 10 * time.Minute
+
+// MasterUOMTypes is the available types of the master uom
+var MasterUOMTypes = map[string]bool{
+	"currency": true,
+	"length":   true,
+}
+
+// masterDataTable describes how a master data kind is stored and validated
+type masterDataTable struct {
+	newModel func() interface{}
+	newList  func() interface{}
+
+	// columns validates the given payload and returns the columns to store,
+	// the unique columns identify a master data so the duplicates are rejected
+	columns func(payload *entities.MasterData) (columns map[string]interface{}, unique map[string]interface{}, err error)
+}
+
+// masterDataTables is the maintainable master data by its kind
+var masterDataTables = map[string]masterDataTable{
+	"facilities": {
+		newModel: func() interface{} { return &database.MasterFacilities{} },
+		newList:  func() interface{} { return &[]database.MasterFacilities{} },
+		columns: func(payload *entities.MasterData) (map[string]interface{}, map[string]interface{}, error) {
+
+			payload.FacName = strings.TrimSpace(payload.FacName)
+			if payload.FacName == "" {
+				return nil, nil, NewError(ErrCodeValidation, "Nama fasilitas harus diisi")
+			}
+
+			columns := map[string]interface{}{"fac_category": payload.FacCategory, "fac_name": payload.FacName}
+			return columns, columns, nil
+		},
+	},
+	"kost-types": {
+		newModel: func() interface{} { return &database.MasterKostType{} },
+		newList:  func() interface{} { return &[]database.MasterKostType{} },
+		columns: func(payload *entities.MasterData) (map[string]interface{}, map[string]interface{}, error) {
+
+			payload.TypeDesc = strings.TrimSpace(payload.TypeDesc)
+			if payload.TypeDesc == "" {
+				return nil, nil, NewError(ErrCodeValidation, "Nama tipe kost harus diisi")
+			}
+
+			columns := map[string]interface{}{"type_desc": payload.TypeDesc}
+			return columns, columns, nil
+		},
+	},
+	"uoms": {
+		newModel: func() interface{} { return &database.MasterUOM{} },
+		newList:  func() interface{} { return &[]database.MasterUOM{} },
+		columns: func(payload *entities.MasterData) (map[string]interface{}, map[string]interface{}, error) {
+
+			payload.UOMDesc = strings.TrimSpace(payload.UOMDesc)
+			if !MasterUOMTypes[payload.UOMType] {
+				return nil, nil, NewError(ErrCodeValidation, "Tipe UOM harus currency atau length")
+			}
+
+			if payload.UOMDesc == "" {
+				return nil, nil, NewError(ErrCodeValidation, "Nama UOM harus diisi")
+			}
+
+			if payload.UOMRate <= 0 {
+				return nil, nil, NewError(ErrCodeValidation, "Rate UOM harus lebih dari 0")
+			}
+
+			columns := map[string]interface{}{"uom_type": payload.UOMType, "uom_desc": payload.UOMDesc, "uom_rate": payload.UOMRate}
+			return columns, map[string]interface{}{"uom_type": payload.UOMType, "uom_desc": payload.UOMDesc}, nil
+		},
+	},
+	"periods": {
+		newModel: func() interface{} { return &database.MasterPeriod{} },
+		newList:  func() interface{} { return &[]database.MasterPeriod{} },
+		columns: func(payload *entities.MasterData) (map[string]interface{}, map[string]interface{}, error) {
+
+			payload.PeriodDesc = strings.TrimSpace(payload.PeriodDesc)
+			if payload.PeriodDesc == "" {
+				return nil, nil, NewError(ErrCodeValidation, "Nama periode harus diisi")
+			}
+
+			// the period value is the length of the period in days
+			if payload.PeriodValue <= 0 {
+				return nil, nil, NewError(ErrCodeValidation, "Jumlah hari periode harus lebih dari 0")
+			}
+
+			columns := map[string]interface{}{"period_desc": payload.PeriodDesc, "period_value": payload.PeriodValue}
+			return columns, map[string]interface{}{"period_desc": payload.PeriodDesc}, nil
+		},
+	},
+	"icons": {
+		newModel: func() interface{} { return &database.MasterIcon{} },
+		newList:  func() interface{} { return &[]database.MasterIcon{} },
+		columns: func(payload *entities.MasterData) (map[string]interface{}, map[string]interface{}, error) {
+
+			payload.IconName = strings.TrimSpace(payload.IconName)
+			if payload.IconName == "" {
+				return nil, nil, NewError(ErrCodeValidation, "Nama icon harus diisi")
+			}
+
+			columns := map[string]interface{}{"icon_name": payload.IconName}
+			return columns, columns, nil
+		},
+	},
+}
+
+// masterDataCacheEntry is the cached active master data list of a kind
+type masterDataCacheEntry struct {
+	list      interface{}
+	expiredAt time.Time
+}
+
+// masterDataCache holds the active master data list by its kind
+var masterDataCache = struct {
+	sync.RWMutex
+	entries map[string]masterDataCacheEntry
+}{entries: map[string]masterDataCacheEntry{}}
+
+// getMasterDataTable looks for the table of the given master data kind
+func getMasterDataTable(kind string) (*masterDataTable, error) {
+
+	table, ok := masterDataTables[kind]
+	if !ok {
+		return nil, NewError(ErrCodeNotFound, "Master data %s tidak ditemukan", kind)
+	}
+
+	return &table, nil
+}
+
+// invalidateMasterData removes the cached list of the given master data kind
+func invalidateMasterData(kind string) {
+
+	masterDataCache.Lock()
+	delete(masterDataCache.entries, kind)
+	masterDataCache.Unlock()
+}
+
+// GetMasterDataList is a function to get the list of the given master data kind,
+// the active list is cached as it is read by every client and rarely changed
+func (kost *Kost) GetMasterDataList(kind string, includeInactive bool) (interface{}, error) {
+
+	table, err := getMasterDataTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if !includeInactive {
+		masterDataCache.RLock()
+		entry, ok := masterDataCache.entries[kind]
+		masterDataCache.RUnlock()
+
+		if ok && entry.expiredAt.After(time.Now()) {
+			return entry.list, nil
+		}
+	}
+
+	model := config.DB.Model(table.newModel())
+	if !includeInactive {
+		model = model.Where("is_active = ?", true)
+	}
+
+	list := table.newList()
+	if err := model.Order("id").Find(list).Error; err != nil {
+		return nil, err
+	}
+
+	if !includeInactive {
+		masterDataCache.Lock()
+		masterDataCache.entries[kind] = masterDataCacheEntry{list: list, expiredAt: time.Now().Add(MasterDataCacheTTL)}
+		masterDataCache.Unlock()
+	}
+
+	return list, nil
+}
+
+// validateMasterData validates the given payload of the given master data kind, the given id is excluded from the unique check
+func (kost *Kost) validateMasterData(table *masterDataTable, payload *entities.MasterData, id uint) (map[string]interface{}, error) {
+
+	columns, unique, err := table.columns(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := config.DB.Model(table.newModel()).Where(unique).Where("id <> ?", id).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	if count > 0 {
+		return nil, NewError(ErrCodeConflict, "Master data dengan nama yang sama sudah ada")
+	}
+
+	return columns, nil
+}
+
+// AddMasterData is a function to add a new master data of the given kind
+func (kost *Kost) AddMasterData(currentUser *database.MasterUser, kind string, payload *entities.MasterData) (interface{}, error) {
+
+	table, err := getMasterDataTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := kost.validateMasterData(table, payload, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	newMasterData := table.newModel()
+
+	// proceed to create the new master data with transaction scope
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// look for the unique columns before the audit columns are added
+		where := map[string]interface{}{}
+		for column, value := range columns {
+			where[column] = value
+		}
+
+		columns["is_active"] = true
+		columns["created"] = time.Now().Local()
+		columns["created_by"] = currentUser.Username
+		columns["modified"] = time.Now().Local()
+		columns["modified_by"] = currentUser.Username
+
+		if dbErr := tx.Model(table.newModel()).Create(columns).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// read the new master data back as creating from a map doesn't fill the model
+		if dbErr := tx.Where(where).Order("id DESC").First(newMasterData).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	invalidateMasterData(kind)
+
+	return newMasterData, nil
+}
+
+// UpdateMasterData is a function to update the given master data of the given kind
+func (kost *Kost) UpdateMasterData(currentUser *database.MasterUser, kind string, id uint, payload *entities.MasterData) (interface{}, error) {
+
+	table, err := getMasterDataTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	// look for the target master data in the db
+	targetMasterData := table.newModel()
+	if err := config.DB.Where("id = ?", id).First(targetMasterData).Error; err != nil {
+		return nil, err
+	}
+
+	columns, err := kost.validateMasterData(table, payload, id)
+	if err != nil {
+		return nil, err
+	}
+
+	columns["is_active"] = payload.IsActive
+	columns["modified"] = time.Now().Local()
+	columns["modified_by"] = currentUser.Username
+
+	if err := config.DB.Model(targetMasterData).Updates(columns).Error; err != nil {
+		return nil, err
+	}
+
+	invalidateMasterData(kind)
+
+	// read the master data back so the response has every column
+	updatedMasterData := table.newModel()
+	if err := config.DB.Where("id = ?", id).First(updatedMasterData).Error; err != nil {
+		return nil, err
+	}
+
+	return updatedMasterData, nil
+}
+
+// DeactivateMasterData is a function to soft delete the given master data of the given kind
+func (kost *Kost) DeactivateMasterData(currentUser *database.MasterUser, kind string, id uint) error {
+
+	table, err := getMasterDataTable(kind)
+	if err != nil {
+		return err
+	}
+
+	result := config.DB.Model(table.newModel()).Where("id = ?", id).Updates(map[string]interface{}{
+		"is_active":   false,
+		"modified":    time.Now().Local(),
+		"modified_by": currentUser.Username,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	invalidateMasterData(kind)
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package data
+
+// messageTranslations is the translation of the indonesian messages returned to the client by the language,
+// the indonesian message is the key so a missing translation falls back to it
+var messageTranslations = map[string]map[string]string{
+	LanguageEN: {
+		// the generic errors
+		"Data tidak ditemukan": "Data not found",
+		"Terjadi kesalahan pada server, silahkan coba lagi nanti":      "Something went wrong on the server, please try again later",
+		"Format request tidak valid":                                   "Invalid request format",
+		"Ukuran request terlalu besar":                                 "The request is too large",
+		"ID tidak valid":                                               "Invalid id",
+		"ID kamar tidak valid":                                         "Invalid room id",
+		"ID kost tidak valid":                                          "Invalid kost id",
+		"ID file tidak valid":                                          "Invalid file id",
+		"Halaman tidak valid":                                          "Invalid page",
+		"Kategori tidak valid":                                         "Invalid category",
+		"Format tanggal tidak valid, gunakan format YYYY-MM-DD":        "Invalid date format, use the YYYY-MM-DD format",
+		"Format minggu tidak valid, gunakan format YYYY-MM-DD":         "Invalid week format, use the YYYY-MM-DD format",
+		"Terlalu banyak permintaan, silahkan coba lagi dalam %d detik": "Too many requests, please try again in %d seconds",
+
+		// the validation errors
+		"Data yang dikirim tidak valid":                "The submitted data is not valid",
+		"Wajib diisi":                                  "This field is required",
+		"Harus lebih dari 0":                           "Must be more than 0",
+		"Minimal harus berisi %d data":                 "Must contain at least %d items",
+		"Harus berupa angka antara %d dan %d":          "Must be a number between %d and %d",
+		"Data master tidak ditemukan atau tidak aktif": "The master data is not found or inactive",
+
+		// the auth errors
+		"Kamu harus login terlebih dahulu":                         "You have to log in first",
+		"Kamu tidak punya akses untuk melakukan aksi ini":          "You are not allowed to do this action",
+		"Token tidak valid":                                        "Invalid token",
+		"Token sudah kedaluwarsa":                                  "The token has expired",
+		"Token sudah memiliki sesi, gunakan refresh token":         "The token already has a session, use the refresh token",
+		"Refresh token harus diisi":                                "The refresh token is required",
+		"Refresh token tidak valid":                                "Invalid refresh token",
+		"Refresh token sudah tidak berlaku, silakan login kembali": "The refresh token is no longer valid, please log in again",
+		"Sesi sudah berakhir, silakan login kembali":               "The session has ended, please log in again",
+		"Sesi sudah kedaluwarsa, silakan login kembali":            "The session has expired, please log in again",
+		"API key harus diisi":                                      "The api key is required",
+		"API key tidak valid":                                      "Invalid api key",
+		"API key sudah kedaluwarsa":                                "The api key has expired",
+		"API key tidak punya akses untuk melakukan aksi ini":       "The api key is not allowed to do this action",
+		"Nama API key harus diisi":                                 "The api key name is required",
+		"Scope API key harus diisi":                                "The api key scopes are required",
+		"Scope API key %s tidak valid":                             "Invalid api key scope %s",
+		"Tanggal kedaluwarsa API key harus setelah hari ini":       "The api key expiry date must be after today",
+
+		// the kost errors
+		"Hanya pemilik kost yang bisa mengubah kost ini": "Only the kost owner can change this kost",
+		"Status kost tidak valid untuk di approve":       "The kost status can't be approved",
+		"Foto harus diisi":                          "The pict is required",
+		"Resolusi foto maksimal %d megapixel":       "The pict resolution is at most %d megapixels",
+		"File foto tidak valid":                     "Invalid pict file",
+		"Urutan foto harus berisi semua foto kost":  "The pict order must contain every kost pict",
+		"Urutan foto harus berisi semua foto kamar": "The pict order must contain every room pict",
+		"Foto %d tidak ditemukan pada galeri kost":  "Pict %d is not found in the kost gallery",
+		"Foto %d tidak ditemukan pada galeri kamar": "Pict %d is not found in the room gallery",
+		"Tipe UOM tidak valid":                      "Invalid uom type",
+
+		// the kost draft messages
+		"Kost sudah diajukan, hanya draft kost yang bisa diubah": "The kost has been submitted, only a draft kost can be changed",
+		"Bagian draft kost %s tidak ditemukan":                   "Kost draft section %s not found",
+		"Draft kost belum lengkap":                               "The kost draft is not complete yet",
+		"Wajib dilengkapi sebelum diajukan":                      "Must be completed before submitting",
+		"Nama kost":                                              "Kost name",
+		"Tipe kost":                                              "Kost type",
+		"Alamat kost":                                            "Kost address",
+		"Titik lokasi kost":                                      "Kost location",
+		"Deskripsi kost":                                         "Kost description",
+		"Periode sewa":                                           "Rent periods",
+		"Kamar kost":                                             "Kost rooms",
+		"Foto setiap kamar":                                      "Picts of every room",
+		"Foto kost":                                              "Kost picts",
+		"Fasilitas kost":                                         "Kost facilities",
+		"Patokan lokasi kost":                                    "Kost landmarks",
+		"Akses kost":                                             "Kost accessibility",
+		"Lingkungan sekitar kost":                                "Around the kost",
+
+		// the kost import errors
+		"Mode import %s tidak valid, gunakan atomic atau partial": "Invalid import mode %s, use atomic or partial",
+		"File import kosong":                  "The import file is empty",
+		"Isi file import tidak valid":         "Invalid import file content",
+		"File xlsx tidak valid":               "Invalid xlsx file",
+		"Template import tidak valid":         "Invalid import template",
+		"Kolom tidak ditemukan":               "Column not found",
+		"%s tidak ditemukan pada master data": "%s is not found in the master data",
+		"Harus berupa angka":                  "Must be a number",
+
+		// the kost export errors
+		"Data export %s tidak ditemukan":                      "Export dataset %s not found",
+		"Format export %s tidak valid, gunakan csv atau xlsx": "Invalid export format %s, use csv or xlsx",
+		"Tanggal akhir harus setelah tanggal awal":            "The end date must be after the start date",
+		"Kolom export tidak valid":                            "Invalid export columns",
+		"Kolom %s tidak tersedia":                             "Column %s is not available",
+
+		// the room booking errors
+		"Kamar tidak ditemukan":                    "Room not found",
+		"Nomor kamar tidak ditemukan":              "Room number not found",
+		"Periode sewa tidak tersedia di kost ini":  "The rent period is not available in this kost",
+		"Nomor kamar dan periode sewa harus diisi": "The room number and the rent period are required",
+		"Penghuni kamar harus diisi":               "The room members are required",
+		"Nama penghuni kamar harus diisi":          "The room member name is required",
+		"Jumlah penghuni melebihi kapasitas kamar": "The number of members exceeds the room capacity",
+		"Kamar sudah dipesan":                      "The room is already booked",
+
+		// the voucher errors
+		"Kode voucher harus diisi":                              "The voucher code is required",
+		"Tipe diskon voucher harus percentage atau fixed":       "The voucher discount type must be percentage or fixed",
+		"Nilai diskon voucher harus lebih dari 0":               "The voucher discount value must be more than 0",
+		"Diskon persentase voucher tidak boleh lebih dari 100":  "The voucher discount percentage can't be more than 100",
+		"Maksimal diskon voucher tidak valid":                   "Invalid voucher max discount",
+		"Tanggal mulai dan tanggal selesai voucher harus diisi": "The voucher start and end dates are required",
+		"Tanggal selesai voucher harus setelah tanggal mulai":   "The voucher end date must be after its start date",
+		"Kost voucher tidak ditemukan":                          "The voucher kost is not found",
+		"Event voucher tidak ditemukan":                         "The voucher event is not found",
+		"Periode minimal voucher tidak ditemukan":               "The voucher min period is not found",
+		"Kode voucher %s sudah digunakan":                       "Voucher code %s is already used",
+		"Kode voucher tidak ditemukan":                          "Voucher code not found",
+		"Voucher tidak berlaku saat ini":                        "The voucher is not valid at the moment",
+		"Voucher tidak berlaku untuk kost ini":                  "The voucher is not valid for this kost",
+		"Voucher hanya berlaku untuk kost yang mengikuti event": "The voucher is only valid for the kosts enrolled to the event",
+		"Voucher hanya berlaku untuk periode sewa minimal %s":   "The voucher is only valid for the rent period of at least %s",
+		"Kuota voucher sudah habis":                             "The voucher quota has run out",
+		"Kamu sudah mencapai batas penggunaan voucher ini":      "You have reached the usage limit of this voucher",
+
+		// the event errors
+		"Nama event harus diisi":                                        "The event name is required",
+		"Tanggal mulai dan tanggal selesai event harus diisi":           "The event start and end dates are required",
+		"Tanggal selesai event harus setelah tanggal mulai":             "The event end date must be after its start date",
+		"Event sudah berakhir":                                          "The event has ended",
+		"Kost hanya bisa didaftarkan ke event ini oleh admin":           "The kost can only be enrolled to this event by the admin",
+		"Hanya pemilik kost yang bisa mendaftarkan kost ini":            "Only the kost owner can enroll this kost",
+		"Kost sudah terdaftar pada event ini":                           "The kost is already enrolled to this event",
+		"Hanya pemilik kost yang bisa mengeluarkan kost ini dari event": "Only the kost owner can withdraw this kost from the event",
+		"Kost tidak terdaftar pada event ini":                           "The kost is not enrolled to this event",
+		"Mode event tidak valid":                                        "Invalid event mode",
+		"Mode event tidak valid, gunakan running, upcoming atau past":   "Invalid event mode, use running, upcoming or past",
+
+		// the master data errors
+		"Master data %s tidak ditemukan":              "Master data %s not found",
+		"Master data dengan nama yang sama sudah ada": "A master data with the same name already exists",
+		"Nama fasilitas harus diisi":                  "The facility name is required",
+		"Nama tipe kost harus diisi":                  "The kost type name is required",
+		"Tipe UOM harus currency atau length":         "The uom type must be currency or length",
+		"Nama UOM harus diisi":                        "The uom name is required",
+		"Rate UOM harus lebih dari 0":                 "The uom rate must be more than 0",
+		"Nama periode harus diisi":                    "The period name is required",
+		"Jumlah hari periode harus lebih dari 0":      "The period days must be more than 0",
+		"Nama icon harus diisi":                       "The icon name is required",
+
+		// the boost errors
+		"Nama paket boost harus diisi":                       "The boost package name is required",
+		"Up rate dan durasi paket boost harus lebih dari 0":  "The boost package up rate and duration must be more than 0",
+		"Harga paket boost tidak valid":                      "Invalid boost package price",
+		"Nama paket boost %s sudah digunakan":                "Boost package name %s is already used",
+		"Paket boost tidak ditemukan":                        "Boost package not found",
+		"Pengajuan boost kost ini masih menunggu konfirmasi": "The boost request of this kost is still waiting for confirmation",
+		"Pengajuan boost tidak ditemukan":                    "Boost request not found",
+		"Pengajuan boost sudah diproses":                     "The boost request is already processed",
+		"Status boost %s tidak valid":                        "Invalid boost status %s",
+
+		// the ads errors
+		"Channel iklan %s tidak valid":                                      "Invalid ads channel %s",
+		"Tipe file iklan tidak valid":                                       "Invalid ads file type",
+		"File iklan tidak valid":                                            "Invalid ads file",
+		"Maksimal %d file per iklan":                                        "At most %d files per ads",
+		"Jumlah ads_files dan ads_file_types tidak sama":                    "The number of ads_files and ads_file_types doesn't match",
+		"Nomor telepon %s tidak valid":                                      "Invalid phone number %s",
+		"Iklan yang sama sudah diajukan dan sedang diproses":                "The same ads has been submitted and is being processed",
+		"Terlalu banyak pengajuan iklan, silahkan coba lagi dalam %d detik": "Too many ads submissions, please try again in %d seconds",
+		"Terlalu banyak pengajuan iklan dari nomor telepon ini, silahkan coba lagi dalam %d detik": "Too many ads submissions from this phone number, please try again in %d seconds",
+		"Challenge iklan harus diisi":                              "The ads challenge is required",
+		"Challenge iklan tidak valid":                              "Invalid ads challenge",
+		"Challenge iklan sudah kedaluwarsa":                        "The ads challenge has expired",
+		"Jawaban challenge iklan salah":                            "Wrong ads challenge answer",
+		"Challenge iklan sudah digunakan":                          "The ads challenge is already used",
+		"Status iklan %s tidak valid":                              "Invalid ads status %s",
+		"Status iklan tidak bisa diubah dari %s ke %s":             "The ads status can't be changed from %s to %s",
+		"Alasan penolakan iklan harus diisi":                       "The ads rejection reason is required",
+		"Pilih satu channel untuk export caption":                  "Choose a channel to export the caption",
+		"Nama paket iklan harus diisi":                             "The ads package name is required",
+		"Tier paket iklan %s tidak valid":                          "Invalid ads package tier %s",
+		"Harga paket iklan tidak valid":                            "Invalid ads package price",
+		"Durasi dan jumlah posting paket iklan harus lebih dari 0": "The ads package duration and posts must be more than 0",
+		"Nama paket iklan %s sudah digunakan":                      "Ads package name %s is already used",
+		"Iklan tidak ditemukan":                                    "Ads not found",
+		"Paket iklan tidak ditemukan":                              "Ads package not found",
+		"Tanggal %s tidak valid, gunakan format YYYY-MM-DD":        "Invalid date %s, use the YYYY-MM-DD format",
+		"Tanggal %s sudah lewat":                                   "Date %s has passed",
+		"Iklan dengan status %s tidak bisa dijadwalkan":            "The ads with status %s can't be scheduled",
+		"Paket iklan %s hanya bisa diposting di channel %s":        "Ads package %s can only be posted on channel %s",
+		"Tanggal posting iklan harus diisi":                        "The ads posting dates are required",
+		"Paket iklan %s hanya mendapat %d posting":                 "Ads package %s only gets %d posts",
+		"Jadwal iklan bentrok":                                     "The ads schedule conflicts",
+		"File metrik kosong":                                       "The metric file is empty",
+		"Header file metrik tidak valid":                           "Invalid metric file header",
+		"Isi file metrik tidak valid":                              "Invalid metric file content",
+		"Kolom ads_code tidak ditemukan":                           "The ads_code column is not found",
+		"Kolom channel tidak ditemukan, isi channel pada request":  "The channel column is not found, set the channel in the request",
+		"Tidak ada kolom metrik yang dikenali":                     "No known metric column",
+		"Maksimal %d baris per import":                             "At most %d rows per import",
+		"Idempotency key maksimal %d karakter":                     "The idempotency key must be at most %d characters",
+		"Import yang sama sudah diproses, gunakan idempotency key yang baru untuk mengimport ulang": "The same import has already been processed, use a new idempotency key to import it again",
+		"Nilai %s tidak valid":   "Invalid value %s",
+		"Tanggal %s tidak valid": "Invalid date %s",
+	},
+}
@@ -0,0 +1,172 @@
+package data
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"image"
+	_ "image/gif"  // register the gif decoder for the uploaded picts
+	_ "image/jpeg" // register the jpeg decoder for the uploaded picts
+	_ "image/png"  // register the png decoder for the uploaded picts
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/buckket/go-blurhash"
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+// PictFileURLPrefix is the url prefix used to serve the generated pict variants
+const PictFileURLPrefix = "/files/picts"
+
+// maxPictMegapixels is the max resolution of the uploaded pict, the decoded pict takes 4 bytes per pixel
+// so a small file declaring a huge resolution can't exhaust the memory
+const maxPictMegapixels = 40
+
+// pictVariantSize defines the bounding box of a generated pict variant
+type pictVariantSize struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// pictVariantSizes is the list of the fixed size variants generated on every upload
+var pictVariantSizes = []pictVariantSize{
+	{Name: "thumbnail", Width: 320, Height: 320},
+	{Name: "medium", Width: 800, Height: 800},
+	{Name: "large", Width: 1600, Height: 1600},
+}
+
+// GetPictFileDirPath returns the root directory where the pict variants are stored
+func GetPictFileDirPath() string {
+	return os.Getenv("APP_MK_DIR_PICT_FILE_PATH")
+}
+
+// GeneratePictVariants will decode the given uploaded pict and store its webp and jpeg variants
+// inside the given folder, the original file is never stored so the EXIF metadata (GPS, camera, etc)
+// is stripped as the variants are re-encoded from the decoded pixels only
+func (kost *Kost) GeneratePictVariants(src io.Reader, folderName string) (*entities.PictVariants, error) {
+
+	// the upload is capped by the request body size, so the whole file is read at once
+	content, err := ioutil.ReadAll(src)
+	if err != nil {
+
+		return nil, RequestBodyError(err)
+	}
+
+	// check the declared resolution from the header before the pixels are decoded
+	pictConfig, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+
+		return nil, WrapError(ErrCodeValidation, err, "File foto tidak valid")
+	}
+
+	if int64(pictConfig.Width)*int64(pictConfig.Height) > maxPictMegapixels*1000000 {
+
+		return nil, NewError(ErrCodeValidation, "Resolusi foto maksimal %d megapixel", maxPictMegapixels)
+	}
+
+	// decode the uploaded pict, applying the EXIF orientation before the metadata is dropped
+	img, err := imaging.Decode(bytes.NewReader(content), imaging.AutoOrientation(true))
+	if err != nil {
+
+		return nil, WrapError(ErrCodeValidation, err, "File foto tidak valid")
+	}
+
+	// create the target folder if it doesn't exist yet
+	dirPath := filepath.Join(GetPictFileDirPath(), folderName)
+	if err = os.MkdirAll(dirPath, 0755); err != nil {
+
+		return nil, err
+	}
+
+	// generate a random base name so the uploaded file name is never trusted
+	baseName, err := generatePictName()
+	if err != nil {
+
+		return nil, err
+	}
+
+	variants := &entities.PictVariants{}
+	for _, size := range pictVariantSizes {
+
+		// resize the pict to fit the variant bounding box, keeping the aspect ratio
+		resized := imaging.Fit(img, size.Width, size.Height, imaging.Lanczos)
+
+		webpName := baseName + "_" + size.Name + ".webp"
+		err = writePictFile(filepath.Join(dirPath, webpName), func(w io.Writer) error {
+			return webp.Encode(w, resized, &webp.Options{Quality: 80})
+		})
+		if err != nil {
+
+			return nil, err
+		}
+
+		jpegName := baseName + "_" + size.Name + ".jpg"
+		err = writePictFile(filepath.Join(dirPath, jpegName), func(w io.Writer) error {
+			return imaging.Encode(w, resized, imaging.JPEG, imaging.JPEGQuality(85))
+		})
+		if err != nil {
+
+			return nil, err
+		}
+
+		webpURL := PictFileURLPrefix + "/" + folderName + "/" + webpName
+		jpegURL := PictFileURLPrefix + "/" + folderName + "/" + jpegName
+
+		switch size.Name {
+		case "thumbnail":
+			variants.ThumbnailWebpURL = webpURL
+			variants.ThumbnailJpegURL = jpegURL
+
+			// compute the blurhash placeholder from the smallest variant to keep it cheap
+			variants.Blurhash, err = blurhash.Encode(4, 3, resized)
+			if err != nil {
+
+				return nil, err
+			}
+		case "medium":
+			variants.MediumWebpURL = webpURL
+			variants.MediumJpegURL = jpegURL
+		case "large":
+			variants.LargeWebpURL = webpURL
+			variants.LargeJpegURL = jpegURL
+		}
+	}
+
+	return variants, nil
+}
+
+// generatePictName will generate a random hex name for the stored pict variants
+func generatePictName() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// writePictFile will create the given file path and write the encoded pict into it
+func writePictFile(path string, encode func(w io.Writer) error) error {
+
+	file, err := os.Create(path)
+	if err != nil {
+
+		return err
+	}
+
+	if err = encode(file); err != nil {
+		file.Close()
+		os.Remove(path)
+
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,196 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+// trustedProxies is the networks of the reverse proxies in front of the app, the X-Forwarded-For header is only trusted from them
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies is a function to set the reverse proxies in front of the app by their ip address or cidr
+func SetTrustedProxies(proxies []string) error {
+
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		// the single ip address is a network of its own
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %s", proxy)
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %s", proxy)
+		}
+
+		networks = append(networks, network)
+	}
+
+	trustedProxies = networks
+
+	return nil
+}
+
+// IsTrustedProxy checks whether the given ip address is one of the trusted reverse proxies
+func IsTrustedProxy(address string) bool {
+
+	ip := net.ParseIP(strings.TrimSpace(address))
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the rule names of the rate limited routes
+const (
+	RateLimitDefault  = "default"  // every request per client ip
+	RateLimitKostList = "kostlist" // the kost list
+	RateLimitNearby   = "nearby"   // the nearby kost list calling the external geocoder
+	RateLimitRoomBook = "roombook" // the room booking and its invoice preview
+	RateLimitAuth     = "auth"     // the token refresh and exchange
+	RateLimitInternal = "internal" // the internal consumers per api key
+	RateLimitAdsIP    = "adsip"    // the ads submission per client ip, set from the ads submission setting
+	RateLimitAdsPhone = "adsphone" // the ads submission per phone number, set from the ads submission setting
+)
+
+// RateLimitEnabled turns the request rate limit on or off
+var RateLimitEnabled = true
+
+// RateLimitRules is the rate limit of the routes by their rule name, a rule without any refill is disabled
+var RateLimitRules = map[string]entities.RateLimitRule{
+	RateLimitDefault:  {RequestsPerMinute: 300, Burst: 100},
+	RateLimitKostList: {RequestsPerMinute: 60, Burst: 20},
+	RateLimitNearby:   {RequestsPerMinute: 20, Burst: 5},
+	RateLimitRoomBook: {RequestsPerMinute: 10, Burst: 5},
+	RateLimitAuth:     {RequestsPerMinute: 20, Burst: 10},
+	RateLimitInternal: {RequestsPerMinute: 600, Burst: 200},
+	RateLimitAdsIP:    {Burst: 5, Window: time.Hour},
+	RateLimitAdsPhone: {Burst: 3, Window: 24 * time.Hour},
+}
+
+// rateLimitRefillRate gets the tokens per second the bucket of the given rule is refilled with
+func rateLimitRefillRate(rule entities.RateLimitRule) float64 {
+
+	if rule.Window > 0 {
+		return float64(rule.Burst) / rule.Window.Seconds()
+	}
+
+	return float64(rule.RequestsPerMinute) / time.Minute.Seconds()
+}
+
+// RateLimitStore is the backend keeping the token buckets of the rate limit, the in memory store only limits
+// a single instance and a shared store can be plugged in to limit the requests across the instances
+type RateLimitStore interface {
+
+	// Take takes a token from the bucket of the given key filled by the given rule,
+	// the time to wait until the next token is available is returned when the bucket is empty
+	Take(key string, rule entities.RateLimitRule) (bool, time.Duration, error)
+}
+
+// tokenBucket is the remaining tokens of a key since its last update
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	rule    entities.RateLimitRule
+}
+
+// refill gets the tokens of the bucket at the given time, the bucket never holds more than its burst
+func (bucket *tokenBucket) refill(now time.Time) float64 {
+
+	return math.Min(rateLimitBurst(bucket.rule), bucket.tokens+now.Sub(bucket.updated).Seconds()*rateLimitRefillRate(bucket.rule))
+}
+
+// MemoryRateLimitStore is an in memory token bucket RateLimitStore
+type MemoryRateLimitStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore is a function to create new MemoryRateLimitStore struct
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// rateLimitBurst gets the bucket size of the given rule, the bucket holds at least a token
+func rateLimitBurst(rule entities.RateLimitRule) float64 {
+
+	if rule.Burst <= 0 {
+		return 1
+	}
+
+	return float64(rule.Burst)
+}
+
+// Take takes a token from the bucket of the given key filled by the given rule
+func (store *MemoryRateLimitStore) Take(key string, rule entities.RateLimitRule) (bool, time.Duration, error) {
+
+	refillRate := rateLimitRefillRate(rule)
+	if refillRate <= 0 {
+		return true, 0, nil
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+
+	// forget the buckets that are full again so the map doesn't grow forever,
+	// a full bucket is the same as a missing one
+	if now.Sub(store.lastSweep) > time.Minute {
+		for sweepKey, sweepBucket := range store.buckets {
+			if sweepBucket.refill(now) >= rateLimitBurst(sweepBucket.rule) {
+				delete(store.buckets, sweepKey)
+			}
+		}
+
+		store.lastSweep = now
+	}
+
+	bucket, ok := store.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: rateLimitBurst(rule), updated: now}
+		store.buckets[key] = bucket
+	}
+
+	// refill the bucket for the time passed since its last update
+	bucket.rule = rule
+	bucket.tokens = bucket.refill(now)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / refillRate * float64(time.Second)), nil
+	}
+
+	bucket.tokens--
+
+	return true, 0, nil
+}
@@ -0,0 +1,150 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+// RoleNone is the role id of the user without a role, e.g. an unset role_id or a token without a role,
+// it is reserved so the missing role is never mistaken for a configured one and is granted no permission
+const RoleNone uint = 0
+
+// the roles of the master user, the role ids are the role_id values of the master users registered by the auth service,
+// they are overridden by the app configuration so they can be matched with the auth service without a code change,
+// the admin has no default so it is only granted once its role id is configured
+var (
+	RoleAdmin      uint = RoleNone
+	RoleOwner      uint = 1
+	RoleTenant     uint = 2
+	RoleSocialTeam uint = 3
+)
+
+// RoleService is the role of the internal consumer authenticated by an api key,
+// it is granted no permission as the api key scopes are checked instead
+const RoleService uint = 99
+
+// Permission is an action a role is allowed to do
+type Permission string
+
+// the permissions of the roles
+const (
+	PermManageKost      Permission = "kost:manage"        // add the kost and manage its gallery
+	PermApproveKost     Permission = "kost:approve"       // approve or reject the new kost
+	PermRequestBoost    Permission = "kost:boost:request" // request a paid boost for the owned kost
+	PermBoostKost       Permission = "kost:boost"         // grant a promo boost to any kost and confirm the paid boost requests
+	PermBookRoom        Permission = "room:book"          // book a kost room
+	PermManageAds       Permission = "ads:manage"         // moderate, schedule, export and report the ads
+	PermManageCatalogue Permission = "catalogue:manage"   // maintain the ads packages, boost packages and vouchers
+	PermManageEvent     Permission = "event:manage"       // maintain the events
+	PermEnrollEvent     Permission = "event:enroll"       // enroll a kost to an event
+	PermManageMaster    Permission = "master:manage"      // maintain the master data
+	PermManageAPIKey    Permission = "apikey:manage"      // issue and revoke the api keys of the internal consumers
+)
+
+// RoleNames is the description of the roles
+var RoleNames map[uint]string
+
+// rolePermissions is the permissions granted to each role, the unknown roles have no permission
+var rolePermissions map[uint]map[Permission]bool
+
+// the permissions are granted to the default role ids until the configured ones are set
+func init() {
+	setRolePermissions()
+}
+
+// SetRoles is a function to set the role ids of the master users, every role must have its own id other than the reserved ones
+func SetRoles(roles entities.RoleConfiguration) error {
+
+	roleIDs := map[uint]string{RoleService: "service"}
+	for _, role := range []struct {
+		name string
+		id   uint
+	}{
+		{"admin", roles.Admin},
+		{"owner", roles.Owner},
+		{"tenant", roles.Tenant},
+		{"social team", roles.SocialTeam},
+	} {
+		if role.id == RoleNone {
+			return fmt.Errorf("the %s role id must be set to a role id other than %d", role.name, RoleNone)
+		}
+
+		if name, ok := roleIDs[role.id]; ok {
+			return fmt.Errorf("the %s role id %d is already used by the %s role", role.name, role.id, name)
+		}
+
+		roleIDs[role.id] = role.name
+	}
+
+	RoleAdmin = roles.Admin
+	RoleOwner = roles.Owner
+	RoleTenant = roles.Tenant
+	RoleSocialTeam = roles.SocialTeam
+	setRolePermissions()
+
+	return nil
+}
+
+// setRolePermissions grants the permissions to the current role ids
+func setRolePermissions() {
+
+	// the unset admin role is left out so the users without a role are never granted its permissions
+	RoleNames = map[uint]string{
+		RoleAdmin:      "admin",
+		RoleOwner:      "owner",
+		RoleTenant:     "tenant",
+		RoleSocialTeam: "social team",
+		RoleService:    "service",
+	}
+	delete(RoleNames, RoleNone)
+
+	rolePermissions = map[uint]map[Permission]bool{
+		RoleAdmin: {
+			PermManageKost:      true,
+			PermApproveKost:     true,
+			PermManageAds:       true,
+			PermManageCatalogue: true,
+			PermBoostKost:       true,
+			PermManageEvent:     true,
+			PermEnrollEvent:     true,
+			PermManageMaster:    true,
+			PermManageAPIKey:    true,
+		},
+		RoleOwner: {
+			PermManageKost:   true,
+			PermRequestBoost: true,
+			PermBookRoom:     true,
+			PermEnrollEvent:  true,
+		},
+		RoleTenant: {
+			PermBookRoom: true,
+		},
+		RoleSocialTeam: {
+			PermManageAds: true,
+		},
+	}
+	delete(rolePermissions, RoleNone)
+}
+
+// HasPermission is a function to check whether the given role is granted every given permission,
+// the user without a role is granted none
+func HasPermission(roleID uint, permissions ...Permission) bool {
+
+	if roleID == RoleNone {
+		return false
+	}
+
+	granted, ok := rolePermissions[roleID]
+	if !ok {
+		return false
+	}
+
+	for _, permission := range permissions {
+		if !granted[permission] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,231 @@
+package data
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoomBookStatusNew is the status of the newly created room booking waiting for the payment,
+// status 2 is the paid booking occupying the room
+const RoomBookStatusNew uint = 0
+
+// getBookableRoom looks for the given kost room and checks whether the given room detail can be booked with the given period
+func (kost *Kost) getBookableRoom(tx *gorm.DB, kostID, roomID uint, bookReq *entities.RoomBookRequest) (*database.DBKostRoom, error) {
+
+	var targetRoom database.DBKostRoom
+	if err := tx.Where("id = ? AND kost_id = ? AND is_active = ?", roomID, kostID, true).First(&targetRoom).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NewError(ErrCodeNotFound, "Kamar tidak ditemukan")
+		}
+
+		return nil, err
+	}
+
+	// the kost only accepts the periods it offers
+	var count int64
+	if err := tx.Model(&database.DBKostPeriod{}).Where("kost_id = ? AND period_id = ? AND is_active = ?", kostID, bookReq.PeriodID, true).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return nil, NewError(ErrCodeValidation, "Periode sewa tidak tersedia di kost ini")
+	}
+
+	if bookReq.RoomDetailID == 0 {
+		return &targetRoom, nil
+	}
+
+	if err := tx.Where("id = ? AND room_id = ? AND is_active = ?", bookReq.RoomDetailID, roomID, true).First(&database.DBKostRoomDetail{}).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NewError(ErrCodeNotFound, "Nomor kamar tidak ditemukan")
+		}
+
+		return nil, err
+	}
+
+	return &targetRoom, nil
+}
+
+// QuoteRoomBook is a function to calculate the invoice amount of the given room booking without booking the room
+func (kost *Kost) QuoteRoomBook(currentUser *database.MasterUser, kostID, roomID uint, bookReq *entities.RoomBookRequest) (*entities.RoomBookQuote, error) {
+
+	targetRoom, err := kost.getBookableRoom(config.DB, kostID, roomID, bookReq)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &entities.RoomBookQuote{
+		RoomPrice:     targetRoom.RoomPrice,
+		InvoiceAmount: targetRoom.RoomPrice,
+	}
+
+	if strings.TrimSpace(bookReq.VoucherCode) == "" {
+		return quote, nil
+	}
+
+	voucher, err := kost.CheckVoucher(config.DB, currentUser, bookReq.VoucherCode, kostID, bookReq.PeriodID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.VoucherCode = voucher.VoucherCode
+	quote.DiscountAmount = CalculateVoucherDiscount(voucher, targetRoom.RoomPrice)
+	quote.InvoiceAmount = targetRoom.RoomPrice - quote.DiscountAmount
+
+	return quote, nil
+}
+
+// CreateRoomBook is a function to book the given kost room, the given voucher is validated and
+// its discount is taken off the first invoice derived from the room price
+func (kost *Kost) CreateRoomBook(currentUser *database.MasterUser, kostID, roomID uint, bookReq *entities.RoomBookRequest) (*database.DBTransactionRoomBook, error) {
+
+	if bookReq.RoomDetailID == 0 || bookReq.PeriodID == 0 {
+		return nil, NewError(ErrCodeValidation, "Nomor kamar dan periode sewa harus diisi")
+	}
+
+	if len(bookReq.Members) == 0 {
+		return nil, NewError(ErrCodeValidation, "Penghuni kamar harus diisi")
+	}
+
+	for _, member := range bookReq.Members {
+		if strings.TrimSpace(member.MemberName) == "" {
+			return nil, NewError(ErrCodeValidation, "Nama penghuni kamar harus diisi")
+		}
+	}
+
+	// look for the target kost to generate the book code
+	var targetKost database.DBKost
+	if err := config.DB.Where("id = ? AND is_active = ?", kostID, true).First(&targetKost).Error; err != nil {
+		return nil, err
+	}
+
+	bookCode, err := kost.GenerateCode("B", codeInitial(targetKost.Country), codeInitial(targetKost.City))
+	if err != nil {
+		return nil, err
+	}
+
+	var newRoomBook database.DBTransactionRoomBook
+
+	// proceed to create the room booking with transaction scope
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+
+		// lock the room detail so the same room can't be booked twice at once
+		if dbErr := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", bookReq.RoomDetailID).First(&database.DBKostRoomDetail{}).Error; dbErr != nil {
+			return dbErr
+		}
+
+		targetRoom, dbErr := kost.getBookableRoom(tx, kostID, roomID, bookReq)
+		if dbErr != nil {
+			return dbErr
+		}
+
+		if int(targetRoom.MaxPerson) < len(bookReq.Members) {
+			return NewError(ErrCodeValidation, "Jumlah penghuni melebihi kapasitas kamar")
+		}
+
+		// the room detail can't be booked while it is still occupied or waiting for a payment
+		var count int64
+		if dbErr = tx.Model(&database.DBTransactionRoomBook{}).
+			Where("room_detail_id = ? AND status = ? AND is_active = ?", bookReq.RoomDetailID, RoomBookStatusNew, true).
+			Count(&count).Error; dbErr != nil {
+			return dbErr
+		}
+
+		bookedRoom, dbErr := kost.GetKostRoomBooked(bookReq.RoomDetailID)
+		if dbErr != nil {
+			return dbErr
+		}
+
+		if count > 0 || bookedRoom != nil {
+			return NewError(ErrCodeConflict, "Kamar sudah dipesan")
+		}
+
+		now := time.Now().Local()
+		newRoomBook = database.DBTransactionRoomBook{
+			BookerID:      currentUser.ID,
+			KostID:        kostID,
+			RoomID:        roomID,
+			RoomDetailID:  bookReq.RoomDetailID,
+			PeriodID:      bookReq.PeriodID,
+			Status:        RoomBookStatusNew,
+			BookCode:      bookCode,
+			BookDate:      now,
+			RoomPrice:     targetRoom.RoomPrice,
+			InvoiceAmount: targetRoom.RoomPrice,
+			IsActive:      true,
+			Created:       now,
+			CreatedBy:     currentUser.Username,
+			Modified:      now,
+			ModifiedBy:    currentUser.Username,
+		}
+
+		// apply the voucher and take its quota
+		if strings.TrimSpace(bookReq.VoucherCode) != "" {
+			voucher, dbErr := kost.CheckVoucher(tx, currentUser, bookReq.VoucherCode, kostID, bookReq.PeriodID, true)
+			if dbErr != nil {
+				return dbErr
+			}
+
+			newRoomBook.VoucherID = voucher.ID
+			newRoomBook.DiscountAmount = CalculateVoucherDiscount(voucher, targetRoom.RoomPrice)
+			newRoomBook.InvoiceAmount = targetRoom.RoomPrice - newRoomBook.DiscountAmount
+
+			if dbErr = tx.Model(&database.MasterVoucher{}).Where("id = ?", voucher.ID).
+				Update("usage_count", gorm.Expr("usage_count + ?", 1)).Error; dbErr != nil {
+				return dbErr
+			}
+		}
+
+		if dbErr = tx.Create(&newRoomBook).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// add the room booking members
+		var roomBookMembers []database.DBTransactionRoomBookMember
+		for _, member := range bookReq.Members {
+			roomBookMembers = append(roomBookMembers, database.DBTransactionRoomBookMember{
+				RoomBookID: newRoomBook.ID,
+				MemberName: strings.TrimSpace(member.MemberName),
+				Phone:      member.Phone,
+				Gender:     member.Gender,
+				IsActive:   true,
+				Created:    now,
+				CreatedBy:  currentUser.Username,
+				Modified:   now,
+				ModifiedBy: currentUser.Username,
+			})
+		}
+
+		if dbErr = tx.Create(&roomBookMembers).Error; dbErr != nil {
+			return dbErr
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+
+	// if transaction error
+	if err != nil {
+
+		return nil, err
+	}
+
+	return &newRoomBook, nil
+}
+
+// codeInitial gets the first letter of the given name for the generated code
+func codeInitial(name string) string {
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "X"
+	}
+
+	return strings.ToUpper(name[0:1])
+}
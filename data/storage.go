@@ -0,0 +1,140 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StorageObject is a stored binary object opened for reading, it is seekable so it can be served with range requests
+type StorageObject interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// StorageObjectInfo holds the metadata of a stored binary object
+type StorageObjectInfo struct {
+	Key      string
+	Size     int64
+	Checksum string
+	Modified time.Time
+}
+
+// Storage is an abstraction of the place where the uploaded binary objects are stored
+type Storage interface {
+	// Put stores the content of the given reader under the given key and returns its size and sha256 checksum
+	Put(key string, src io.Reader) (*StorageObjectInfo, error)
+	// Open opens the object stored under the given key
+	Open(key string) (StorageObject, *StorageObjectInfo, error)
+	// Delete removes the object stored under the given key
+	Delete(key string) error
+}
+
+// LocalStorage is a Storage backed by a directory of the local file system
+type LocalStorage struct {
+	baseDir string
+}
+
+// NewLocalStorage is a function to create new LocalStorage struct rooted at the given directory
+func NewLocalStorage(baseDir string) *LocalStorage {
+	return &LocalStorage{baseDir}
+}
+
+// path resolves the given key into a file path, making sure the key can't escape the base directory
+func (storage *LocalStorage) path(key string) (string, error) {
+
+	cleanKey := filepath.Clean("/" + key)
+	if cleanKey == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("Invalid storage key")
+	}
+
+	return filepath.Join(storage.baseDir, cleanKey), nil
+}
+
+// Put stores the content of the given reader under the given key and returns its size and sha256 checksum
+func (storage *LocalStorage) Put(key string, src io.Reader) (*StorageObjectInfo, error) {
+
+	path, err := storage.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// hash the content while it is being written so the object is only read once
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), src)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+
+		return nil, err
+	}
+
+	if err = file.Close(); err != nil {
+		os.Remove(path)
+
+		return nil, err
+	}
+
+	return &StorageObjectInfo{
+		Key:      key,
+		Size:     size,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+		Modified: time.Now(),
+	}, nil
+}
+
+// Open opens the object stored under the given key
+func (storage *LocalStorage) Open(key string) (StorageObject, *StorageObjectInfo, error) {
+
+	path, err := storage.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return nil, nil, err
+	}
+
+	return file, &StorageObjectInfo{
+		Key:      key,
+		Size:     stat.Size(),
+		Modified: stat.ModTime(),
+	}, nil
+}
+
+// Delete removes the object stored under the given key
+func (storage *LocalStorage) Delete(key string) error {
+
+	path, err := storage.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
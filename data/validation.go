@@ -0,0 +1,318 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+// FieldError is the validation error of a single field of the request payload,
+// the field is the json path of the field, e.g. rooms[0].room_price
+type FieldError struct {
+	Field  string
+	Format string
+	Args   []interface{}
+}
+
+// Localize gets the message of the field error in the given language
+func (fieldErr FieldError) Localize(language string) string {
+	return Translate(language, fieldErr.Format, fieldErr.Args...)
+}
+
+// masterReferenceTable describes the master data table referenced by the request payload
+type masterReferenceTable struct {
+	newModel func() interface{}
+
+	// where narrows down the referenceable master data, e.g. the uom of a certain type
+	where map[string]interface{}
+}
+
+// masterReferenceTables is the master data referenceable by the request payload by its kind
+var masterReferenceTables = map[string]masterReferenceTable{
+	"kost-types":    {newModel: func() interface{} { return &database.MasterKostType{} }},
+	"facilities":    {newModel: func() interface{} { return &database.MasterFacilities{} }},
+	"periods":       {newModel: func() interface{} { return &database.MasterPeriod{} }},
+	"currency-uoms": {newModel: func() interface{} { return &database.MasterUOM{} }, where: map[string]interface{}{"uom_type": "currency"}},
+	"length-uoms":   {newModel: func() interface{} { return &database.MasterUOM{} }, where: map[string]interface{}{"uom_type": "length"}},
+	"icons":         {newModel: func() interface{} { return &database.MasterIcon{} }},
+	"ads-packages":  {newModel: func() interface{} { return &database.MasterAdsPackage{} }},
+}
+
+// masterReference is a field of the request payload referencing a master data by its id
+type masterReference struct {
+	field string
+	id    uint
+}
+
+// Validator collects the field errors of a request payload, the rules are declared field by field
+// and every broken rule is reported at once instead of only the first one
+type Validator struct {
+	fields     []FieldError
+	references map[string][]masterReference
+}
+
+// NewValidator is a function to create new Validator struct
+func NewValidator() *Validator {
+	return &Validator{references: map[string][]masterReference{}}
+}
+
+// Fail adds the given message as the error of the given field
+func (validator *Validator) Fail(field, format string, args ...interface{}) {
+	validator.fields = append(validator.fields, FieldError{Field: field, Format: format, Args: args})
+}
+
+// Required checks the given text field is filled
+func (validator *Validator) Required(field, value string) bool {
+
+	if strings.TrimSpace(value) == "" {
+		validator.Fail(field, "Wajib diisi")
+
+		return false
+	}
+
+	return true
+}
+
+// Positive checks the given number field is more than 0
+func (validator *Validator) Positive(field string, value float64) bool {
+
+	if value <= 0 {
+		validator.Fail(field, "Harus lebih dari 0")
+
+		return false
+	}
+
+	return true
+}
+
+// MinItems checks the given list field has at least the given number of items
+func (validator *Validator) MinItems(field string, length, min int) bool {
+
+	if length < min {
+		validator.Fail(field, "Minimal harus berisi %d data", min)
+
+		return false
+	}
+
+	return true
+}
+
+// Coordinate checks the given text field is a number within the given absolute limit, e.g. 90 for the latitude
+func (validator *Validator) Coordinate(field, value string, limit int) bool {
+
+	if !validator.Required(field, value) {
+		return false
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < -float64(limit) || number > float64(limit) {
+		validator.Fail(field, "Harus berupa angka antara %d dan %d", -limit, limit)
+
+		return false
+	}
+
+	return true
+}
+
+// Price checks the given text field contains a price more than 0, the thousand separators and the currency are ignored,
+// e.g. Rp 1.500.000 is accepted
+func (validator *Validator) Price(field, value string) bool {
+
+	if !validator.Required(field, value) {
+		return false
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, value)
+
+	price, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || price == 0 {
+		validator.Fail(field, "Harus lebih dari 0")
+
+		return false
+	}
+
+	return true
+}
+
+// Master checks the given id field references an active master data of the given kind,
+// the references are checked against the db all at once by Validate
+func (validator *Validator) Master(field, kind string, id uint) {
+
+	if id == 0 {
+		validator.Fail(field, "Wajib diisi")
+
+		return
+	}
+
+	validator.references[kind] = append(validator.references[kind], masterReference{field: field, id: id})
+}
+
+// Validate checks the collected master references and returns the validation error holding every field error, if any
+func (validator *Validator) Validate() error {
+
+	// the rules without the db are checked first so the invalid payload never reaches the db
+	if len(validator.fields) > 0 {
+		return validator.err()
+	}
+
+	for kind, references := range validator.references {
+
+		table, ok := masterReferenceTables[kind]
+		if !ok {
+			return fmt.Errorf("unknown master reference %s", kind)
+		}
+
+		ids := make([]uint, 0, len(references))
+		for _, reference := range references {
+			ids = append(ids, reference.id)
+		}
+
+		// look for the active master data of the given ids in a single query
+		var existingIDs []uint
+		model := config.DB.Model(table.newModel()).Where("id IN ? AND is_active = ?", ids, true)
+		if table.where != nil {
+			model = model.Where(table.where)
+		}
+
+		if err := model.Pluck("id", &existingIDs).Error; err != nil {
+			return err
+		}
+
+		existing := make(map[uint]bool, len(existingIDs))
+		for _, id := range existingIDs {
+			existing[id] = true
+		}
+
+		for _, reference := range references {
+			if !existing[reference.id] {
+				validator.Fail(reference.field, "Data master tidak ditemukan atau tidak aktif")
+			}
+		}
+	}
+
+	if len(validator.fields) > 0 {
+		return validator.err()
+	}
+
+	return nil
+}
+
+// err gets the validation error of the collected field errors
+func (validator *Validator) err() error {
+
+	validationErr := NewError(ErrCodeValidation, "Data yang dikirim tidak valid")
+	validationErr.Fields = validator.fields
+
+	return validationErr
+}
+
+// ValidateKost is a function to validate the given new kost payload including its rooms
+func (kost *Kost) ValidateKost(kostReq *entities.Kost) error {
+
+	validator := NewValidator()
+
+	validator.Master("type_id", "kost-types", kostReq.TypeID)
+	validator.Required("kost_name", kostReq.KostName)
+	validator.Required("country", kostReq.Country)
+	validator.Required("city", kostReq.City)
+	validator.Required("address", kostReq.Address)
+	validator.Coordinate("latitude", kostReq.Latitude, 90)
+	validator.Coordinate("longitude", kostReq.Longitude, 180)
+
+	if validator.MinItems("kost_periods", len(kostReq.KostPeriods), 1) {
+		for i, kostPeriod := range kostReq.KostPeriods {
+			validator.Master(fmt.Sprintf("kost_periods[%d].period_id", i), "periods", kostPeriod.PeriodID)
+		}
+	}
+
+	for i, facility := range kostReq.Facilities {
+		validator.Master(fmt.Sprintf("facilities[%d].fac_id", i), "facilities", facility.FacID)
+	}
+
+	for i, kostPict := range kostReq.KostPicts {
+		validator.Required(fmt.Sprintf("kost_picts[%d].url", i), kostPict.URL)
+	}
+
+	// the first pict of the first room is the thumbnail of the kost
+	if validator.MinItems("rooms", len(kostReq.Rooms), 1) {
+		for i := range kostReq.Rooms {
+			validateKostRoom(validator, fmt.Sprintf("rooms[%d].", i), &kostReq.Rooms[i], true)
+		}
+	}
+
+	return validator.Validate()
+}
+
+// validateKostRoom declares the rules of the kost room payload, the given prefix is the json path of the room
+// and the room picts are only required when the room is listed right away
+func validateKostRoom(validator *Validator, prefix string, room *entities.KostRoom, requirePicts bool) {
+
+	validator.Required(prefix+"room_desc", room.RoomDesc)
+	validator.Positive(prefix+"room_price", room.RoomPrice)
+	validator.Master(prefix+"room_price_uom", "currency-uoms", room.RoomPriceUOM)
+	validator.Positive(prefix+"room_length", room.RoomLength)
+	validator.Positive(prefix+"room_width", room.RoomWidth)
+	validator.Master(prefix+"room_area_uom", "length-uoms", room.RoomAreaUOM)
+	validator.Positive(prefix+"max_person", float64(room.MaxPerson))
+
+	if requirePicts {
+		validator.MinItems(prefix+"room_picts", len(room.RoomPicts), 1)
+	}
+
+	for i, roomPict := range room.RoomPicts {
+		validator.Required(fmt.Sprintf("%sroom_picts[%d].url", prefix, i), roomPict.URL)
+	}
+
+	for i, roomDetail := range room.RoomDetails {
+		validator.Required(fmt.Sprintf("%sroom_details[%d].room_number", prefix, i), roomDetail.RoomNumber)
+	}
+}
+
+// ValidateKostAds is a function to validate the given new kost ads payload, the phone numbers are validated
+// and normalized separately by NormalizePhoneNumber
+func (kost *Kost) ValidateKostAds(kostAds *entities.KostAds) error {
+
+	validator := NewValidator()
+
+	// the legacy clients pick the ads package by its name
+	if kostAds.AdsPackageID != 0 {
+		validator.Master("ads_package_id", "ads-packages", kostAds.AdsPackageID)
+	} else {
+		validator.Required("ads_type", kostAds.AdsType)
+	}
+
+	validator.Required("ads_kost_type", kostAds.AdsKostType)
+	validator.Required("ads_owner", kostAds.AdsOwner)
+	validator.Required("ads_phone_number", kostAds.AdsPhoneNumber)
+	validator.Required("ads_pic_whatsapp", kostAds.AdsPICWhatsapp)
+	validator.Required("ads_property_address", kostAds.AdsPropertyAddress)
+	validator.Required("ads_property_city", kostAds.AdsPropertyCity)
+	validator.Price("ads_property_price", kostAds.AdsPropertyPrice)
+	validator.Required("ads_gender", kostAds.AdsGender)
+
+	for i, adsFile := range kostAds.AdsFiles {
+		if !adsFileTypePattern.MatchString(adsFile.AdsFileType) {
+			validator.Fail(fmt.Sprintf("ads_files[%d].ads_file_type", i), "Tipe file iklan tidak valid")
+		}
+
+		validator.Required(fmt.Sprintf("ads_files[%d].base64_string", i), adsFile.BASE64STRING)
+	}
+
+	for i, upload := range kostAds.AdsUploads {
+		if !adsFileTypePattern.MatchString(upload.AdsFileType) {
+			validator.Fail(fmt.Sprintf("ads_file_types[%d]", i), "Tipe file iklan tidak valid")
+		}
+	}
+
+	return validator.Validate()
+}
@@ -0,0 +1,203 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+)
+
+// validationTestKost is a new kost payload passing every rule checked without the db
+func validationTestKost() *entities.Kost {
+	return &entities.Kost{
+		TypeID:      1,
+		KostName:    "Kost Melati",
+		Country:     "Indonesia",
+		City:        "Jakarta",
+		Address:     "Jl. Melati No. 1",
+		Latitude:    "-6.2088",
+		Longitude:   "106.8456",
+		KostPeriods: []database.DBKostPeriod{{PeriodID: 1}},
+		Facilities:  []database.DBKostFacilities{{FacID: 1}},
+		KostPicts:   []database.DBKostPict{{URL: "https://example.com/kost.jpg"}},
+		Rooms: []entities.KostRoom{
+			{
+				RoomDesc:     "Kamar A",
+				RoomPrice:    1500000,
+				RoomPriceUOM: 1,
+				RoomLength:   3,
+				RoomWidth:    4,
+				RoomAreaUOM:  2,
+				MaxPerson:    1,
+				RoomPicts:    []database.DBKostRoomPict{{URL: "https://example.com/room.jpg"}},
+				RoomDetails:  []database.DBKostRoomDetail{{RoomNumber: "A1"}},
+			},
+		},
+	}
+}
+
+// validationErrorFields gets the fields of the given validation error
+func validationErrorFields(t *testing.T, err error) []string {
+
+	var appErr *AppError
+	if !errors.As(err, &appErr) || appErr.Code != ErrCodeValidation {
+		t.Fatalf("error = %v, want a %s error", err, ErrCodeValidation)
+	}
+
+	var fields []string
+	for _, fieldErr := range appErr.Fields {
+		fields = append(fields, fieldErr.Field)
+	}
+
+	return fields
+}
+
+func TestValidateKost(t *testing.T) {
+
+	// every case breaks a rule checked without the db so the master references are never looked up
+	tests := []struct {
+		name       string
+		modify     func(kostReq *entities.Kost)
+		wantFields []string
+	}{
+		{
+			name:       "empty payload",
+			modify:     func(kostReq *entities.Kost) { *kostReq = entities.Kost{} },
+			wantFields: []string{"type_id", "kost_name", "country", "city", "address", "latitude", "longitude", "kost_periods", "rooms"},
+		},
+		{
+			name:       "blank text",
+			modify:     func(kostReq *entities.Kost) { kostReq.KostName = "  "; kostReq.Country = "" },
+			wantFields: []string{"kost_name", "country"},
+		},
+		{
+			name:       "coordinates out of range",
+			modify:     func(kostReq *entities.Kost) { kostReq.Latitude = "90.1"; kostReq.Longitude = "-180.5" },
+			wantFields: []string{"latitude", "longitude"},
+		},
+		{
+			name:       "coordinate not a number",
+			modify:     func(kostReq *entities.Kost) { kostReq.Latitude = "6,2 LS" },
+			wantFields: []string{"latitude"},
+		},
+		{
+			name: "unset master references",
+			modify: func(kostReq *entities.Kost) {
+				kostReq.KostPeriods = append(kostReq.KostPeriods, database.DBKostPeriod{})
+				kostReq.Facilities = []database.DBKostFacilities{{}}
+			},
+			wantFields: []string{"kost_periods[1].period_id", "facilities[0].fac_id"},
+		},
+		{
+			name:       "kost pict without url",
+			modify:     func(kostReq *entities.Kost) { kostReq.KostPicts[0].URL = "" },
+			wantFields: []string{"kost_picts[0].url"},
+		},
+		{
+			name:       "room without pict",
+			modify:     func(kostReq *entities.Kost) { kostReq.Rooms[0].RoomPicts = nil },
+			wantFields: []string{"rooms[0].room_picts"},
+		},
+		{
+			name: "invalid second room",
+			modify: func(kostReq *entities.Kost) {
+				kostReq.Rooms = append(kostReq.Rooms, entities.KostRoom{
+					RoomPrice:   -1,
+					RoomPicts:   []database.DBKostRoomPict{{}},
+					RoomDetails: []database.DBKostRoomDetail{{RoomNumber: " "}},
+				})
+			},
+			wantFields: []string{
+				"rooms[1].room_desc",
+				"rooms[1].room_price",
+				"rooms[1].room_price_uom",
+				"rooms[1].room_length",
+				"rooms[1].room_width",
+				"rooms[1].room_area_uom",
+				"rooms[1].max_person",
+				"rooms[1].room_picts[0].url",
+				"rooms[1].room_details[0].room_number",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			kostReq := validationTestKost()
+			test.modify(kostReq)
+
+			got := validationErrorFields(t, (&Kost{}).ValidateKost(kostReq))
+			if !reflect.DeepEqual(got, test.wantFields) {
+				t.Errorf("ValidateKost() fields = %q, want %q", got, test.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidatorPrice(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "plain number", value: "1500000", want: true},
+		{name: "formatted rupiah", value: "Rp 1.500.000", want: true},
+		{name: "zero", value: "0", want: false},
+		{name: "no digits", value: "nego", want: false},
+		{name: "blank", value: " ", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			validator := NewValidator()
+			if got := validator.Price("ads_property_price", test.value); got != test.want {
+				t.Errorf("Price(%q) = %v, want %v", test.value, got, test.want)
+			}
+
+			if got := len(validator.fields) == 0; got != test.want {
+				t.Errorf("Price(%q) field errors = %v, want none %v", test.value, validator.fields, test.want)
+			}
+		})
+	}
+}
+
+func TestValidatorCoordinate(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		value      string
+		limit      int
+		want       bool
+		wantFormat string
+	}{
+		{name: "within the limit", value: "-6.2088", limit: 90, want: true},
+		{name: "on the limit", value: "180", limit: 180, want: true},
+		{name: "over the limit", value: "90.0001", limit: 90, wantFormat: "Harus berupa angka antara %d dan %d"},
+		{name: "not a number", value: "abc", limit: 90, wantFormat: "Harus berupa angka antara %d dan %d"},
+		{name: "blank", value: "", limit: 90, wantFormat: "Wajib diisi"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			validator := NewValidator()
+			if got := validator.Coordinate("latitude", test.value, test.limit); got != test.want {
+				t.Errorf("Coordinate(%q) = %v, want %v", test.value, got, test.want)
+			}
+
+			var gotFormat string
+			if len(validator.fields) > 0 {
+				gotFormat = validator.fields[0].Format
+			}
+
+			if gotFormat != test.wantFormat {
+				t.Errorf("Coordinate(%q) error = %q, want %q", test.value, gotFormat, test.wantFormat)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package data
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VoucherDiscountTypes is the available discount types of the voucher
+var VoucherDiscountTypes = map[string]bool{
+	"percentage": true,
+	"fixed":      true,
+}
+
+// ValidateVoucher is a function to validate the given voucher, the given voucher id is excluded from the unique code check
+func (kost *Kost) ValidateVoucher(voucher *entities.Voucher, voucherID uint) error {
+
+	// the voucher code is case insensitive for the bookers
+	voucher.VoucherCode = strings.ToUpper(strings.TrimSpace(voucher.VoucherCode))
+	if voucher.VoucherCode == "" {
+		return NewError(ErrCodeValidation, "Kode voucher harus diisi")
+	}
+
+	if !VoucherDiscountTypes[voucher.DiscountType] {
+		return NewError(ErrCodeValidation, "Tipe diskon voucher harus percentage atau fixed")
+	}
+
+	if voucher.DiscountValue <= 0 {
+		return NewError(ErrCodeValidation, "Nilai diskon voucher harus lebih dari 0")
+	}
+
+	if voucher.DiscountType == "percentage" && voucher.DiscountValue > 100 {
+		return NewError(ErrCodeValidation, "Diskon persentase voucher tidak boleh lebih dari 100")
+	}
+
+	if voucher.MaxDiscount < 0 {
+		return NewError(ErrCodeValidation, "Maksimal diskon voucher tidak valid")
+	}
+
+	if voucher.StartDate.IsZero() || voucher.EndDate.IsZero() {
+		return NewError(ErrCodeValidation, "Tanggal mulai dan tanggal selesai voucher harus diisi")
+	}
+
+	if !voucher.EndDate.After(voucher.StartDate) {
+		return NewError(ErrCodeValidation, "Tanggal selesai voucher harus setelah tanggal mulai")
+	}
+
+	if voucher.KostID != 0 {
+		if err := config.DB.Where("id = ?", voucher.KostID).First(&database.DBKost{}).Error; err != nil {
+			return NewError(ErrCodeValidation, "Kost voucher tidak ditemukan")
+		}
+	}
+
+	if voucher.EventID != 0 {
+		if err := config.DB.Where("id = ? AND is_cancelled = ?", voucher.EventID, false).First(&database.MasterEvent{}).Error; err != nil {
+			return NewError(ErrCodeValidation, "Event voucher tidak ditemukan")
+		}
+	}
+
+	if voucher.MinPeriodID != 0 {
+		if _, err := kost.GetMasterPeriod(voucher.MinPeriodID); err != nil {
+			return NewError(ErrCodeValidation, "Periode minimal voucher tidak ditemukan")
+		}
+	}
+
+	var count int64
+	if err := config.DB.Model(&database.MasterVoucher{}).Where("voucher_code = ? AND id <> ?", voucher.VoucherCode, voucherID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return NewError(ErrCodeConflict, "Kode voucher %s sudah digunakan", voucher.VoucherCode)
+	}
+
+	return nil
+}
+
+// AddVoucher is a function to add a new voucher
+func (kost *Kost) AddVoucher(currentUser *database.MasterUser, voucher *entities.Voucher) (*database.MasterVoucher, error) {
+
+	if err := kost.ValidateVoucher(voucher, 0); err != nil {
+		return nil, err
+	}
+
+	newVoucher := &database.MasterVoucher{
+		VoucherCode:   voucher.VoucherCode,
+		VoucherDesc:   voucher.VoucherDesc,
+		DiscountType:  voucher.DiscountType,
+		DiscountValue: voucher.DiscountValue,
+		MaxDiscount:   voucher.MaxDiscount,
+		KostID:        voucher.KostID,
+		EventID:       voucher.EventID,
+		MinPeriodID:   voucher.MinPeriodID,
+		UsageLimit:    voucher.UsageLimit,
+		PerUserLimit:  voucher.PerUserLimit,
+		StartDate:     voucher.StartDate.Local(),
+		EndDate:       voucher.EndDate.Local(),
+		IsActive:      true,
+		Created:       time.Now().Local(),
+		CreatedBy:     currentUser.Username,
+		Modified:      time.Now().Local(),
+		ModifiedBy:    currentUser.Username,
+	}
+
+	// insert the new voucher to the database
+	if err := config.DB.Create(newVoucher).Error; err != nil {
+		return nil, err
+	}
+
+	return newVoucher, nil
+}
+
+// UpdateVoucher is a function to update the given voucher, the usage count is kept as is
+func (kost *Kost) UpdateVoucher(currentUser *database.MasterUser, voucherID uint, voucher *entities.Voucher) (*database.MasterVoucher, error) {
+
+	// look for the target voucher in the db
+	targetVoucher := &database.MasterVoucher{}
+	if err := config.DB.Where("id = ?", voucherID).First(targetVoucher).Error; err != nil {
+		return nil, err
+	}
+
+	if err := kost.ValidateVoucher(voucher, voucherID); err != nil {
+		return nil, err
+	}
+
+	targetVoucher.VoucherCode = voucher.VoucherCode
+	targetVoucher.VoucherDesc = voucher.VoucherDesc
+	targetVoucher.DiscountType = voucher.DiscountType
+	targetVoucher.DiscountValue = voucher.DiscountValue
+	targetVoucher.MaxDiscount = voucher.MaxDiscount
+	targetVoucher.KostID = voucher.KostID
+	targetVoucher.EventID = voucher.EventID
+	targetVoucher.MinPeriodID = voucher.MinPeriodID
+	targetVoucher.UsageLimit = voucher.UsageLimit
+	targetVoucher.PerUserLimit = voucher.PerUserLimit
+	targetVoucher.StartDate = voucher.StartDate.Local()
+	targetVoucher.EndDate = voucher.EndDate.Local()
+	targetVoucher.IsActive = voucher.IsActive
+	targetVoucher.Modified = time.Now().Local()
+	targetVoucher.ModifiedBy = currentUser.Username
+
+	// update the voucher
+	if err := config.DB.Save(targetVoucher).Error; err != nil {
+		return nil, err
+	}
+
+	return targetVoucher, nil
+}
+
+// DeactivateVoucher is a function to soft delete the given voucher
+func (kost *Kost) DeactivateVoucher(currentUser *database.MasterUser, voucherID uint) error {
+
+	result := config.DB.Model(&database.MasterVoucher{}).Where("id = ?", voucherID).Updates(map[string]interface{}{
+		"is_active":   false,
+		"modified":    time.Now().Local(),
+		"modified_by": currentUser.Username,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetVoucherList is a function to get the voucher list
+func (kost *Kost) GetVoucherList(includeInactive bool) ([]database.MasterVoucher, error) {
+
+	model := config.DB.Model(&database.MasterVoucher{})
+	if !includeInactive {
+		model = model.Where("is_active = ?", true)
+	}
+
+	vouchers := []database.MasterVoucher{}
+	if err := model.Order("end_date DESC").Find(&vouchers).Error; err != nil {
+		return nil, err
+	}
+
+	return vouchers, nil
+}
+
+// CalculateVoucherDiscount is a function to calculate the discount of the given voucher on the given price,
+// the discount never exceeds the price
+func CalculateVoucherDiscount(voucher *database.MasterVoucher, price float64) float64 {
+
+	discount := voucher.DiscountValue
+	if voucher.DiscountType == "percentage" {
+		discount = price * voucher.DiscountValue / 100
+		if voucher.MaxDiscount > 0 && discount > voucher.MaxDiscount {
+			discount = voucher.MaxDiscount
+		}
+	}
+
+	if discount > price {
+		discount = price
+	}
+
+	// the price is in rupiah, so the cents are dropped
+	return math.Floor(discount)
+}
+
+// CheckVoucher is a function to look for the given voucher code and check whether it can be used by the given user
+// to book the given kost with the given period, lock the voucher row when the voucher is going to be used
+func (kost *Kost) CheckVoucher(tx *gorm.DB, currentUser *database.MasterUser, voucherCode string, kostID uint, periodID uint, lock bool) (*database.MasterVoucher, error) {
+
+	model := tx
+	if lock {
+		model = model.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	// look for the active voucher in the db
+	var voucher database.MasterVoucher
+	if err := model.Where("voucher_code = ? AND is_active = ?", strings.ToUpper(strings.TrimSpace(voucherCode)), true).First(&voucher).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NewError(ErrCodeNotFound, "Kode voucher tidak ditemukan")
+		}
+
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Before(voucher.StartDate) || !voucher.EndDate.After(now) {
+		return nil, NewError(ErrCodeValidation, "Voucher tidak berlaku saat ini")
+	}
+
+	if voucher.KostID != 0 && voucher.KostID != kostID {
+		return nil, NewError(ErrCodeValidation, "Voucher tidak berlaku untuk kost ini")
+	}
+
+	// the event voucher only applies to the kosts enrolled to the running event
+	if voucher.EventID != 0 {
+		var count int64
+		if err := tx.Model(&database.MasterEventDetail{}).
+			Joins("inner join master_events on master_events.id = master_event_details.event_id").
+			Where("master_event_details.event_id = ? AND master_event_details.kost_id = ? AND master_event_details.is_active = ?", voucher.EventID, kostID, true).
+			Where("master_events.is_cancelled = ? AND master_events.start_date <= ? AND (master_events.end_date IS NULL OR master_events.end_date > ?)", false, now, now).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+
+		if count == 0 {
+			return nil, NewError(ErrCodeValidation, "Voucher hanya berlaku untuk kost yang mengikuti event")
+		}
+	}
+
+	if voucher.MinPeriodID != 0 {
+		minPeriod, err := kost.GetMasterPeriod(voucher.MinPeriodID)
+		if err != nil {
+			return nil, err
+		}
+
+		period, err := kost.GetMasterPeriod(periodID)
+		if err != nil {
+			return nil, err
+		}
+
+		if period.PeriodValue < minPeriod.PeriodValue {
+			return nil, NewError(ErrCodeValidation, "Voucher hanya berlaku untuk periode sewa minimal %s", minPeriod.PeriodDesc)
+		}
+	}
+
+	if voucher.UsageLimit != 0 && voucher.UsageCount >= voucher.UsageLimit {
+		return nil, NewError(ErrCodeValidation, "Kuota voucher sudah habis")
+	}
+
+	if voucher.PerUserLimit != 0 {
+		var count int64
+		if err := tx.Model(&database.DBTransactionRoomBook{}).
+			Where("voucher_id = ? AND booker_id = ? AND is_active = ?", voucher.ID, currentUser.ID, true).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+
+		if uint(count) >= voucher.PerUserLimit {
+			return nil, NewError(ErrCodeValidation, "Kamu sudah mencapai batas penggunaan voucher ini")
+		}
+	}
+
+	return &voucher, nil
+}
@@ -0,0 +1,352 @@
+package data
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXPartSize is the max uncompressed size of a single part of the xlsx file, it guards against the zip bombs
+const maxXLSXPartSize = 64 << 20
+
+// the max number of rows and columns of an xlsx sheet
+const (
+	maxXLSXRows    = 1048576
+	maxXLSXColumns = 16384
+)
+
+// xlsxMagic is the leading bytes of the zip file the xlsx file is stored in
+var xlsxMagic = []byte("PK\x03\x04")
+
+// xlsxWorkbook is the workbook part of the xlsx file listing its sheets
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is the relationships part of the xlsx workbook pointing to the sheet parts
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxStringItem is a shared or inline string, the rich text is split into runs
+type xlsxStringItem struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String gets the whole text of the string item
+func (item xlsxStringItem) String() string {
+
+	if len(item.Runs) == 0 {
+		return item.Text
+	}
+
+	var text strings.Builder
+	for _, run := range item.Runs {
+		text.WriteString(run.Text)
+	}
+
+	return text.String()
+}
+
+// xlsxSharedStrings is the shared strings part of the xlsx file
+type xlsxSharedStrings struct {
+	Items []xlsxStringItem `xml:"si"`
+}
+
+// xlsxWorksheet is the worksheet part of the xlsx file
+type xlsxWorksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string         `xml:"r,attr"`
+			Type   string         `xml:"t,attr"`
+			Value  string         `xml:"v"`
+			Inline xlsxStringItem `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// IsXLSX checks whether the given file content is stored in a zip file like the xlsx file
+func IsXLSX(content []byte) bool {
+	return bytes.HasPrefix(content, xlsxMagic)
+}
+
+// readXLSXPart decodes the given xml part of the given xlsx file, the missing part is reported as not found
+func readXLSXPart(archive *zip.Reader, name string, target interface{}) (bool, error) {
+
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return true, err
+		}
+
+		defer reader.Close()
+
+		content, err := ioutil.ReadAll(io.LimitReader(reader, maxXLSXPartSize+1))
+		if err != nil {
+			return true, err
+		}
+
+		if len(content) > maxXLSXPartSize {
+			return true, NewError(ErrCodePayloadTooLarge, "Ukuran request terlalu besar")
+		}
+
+		return true, xml.Unmarshal(content, target)
+	}
+
+	return false, nil
+}
+
+// xlsxColumnIndex gets the zero based column index of the given cell reference, e.g. 2 for C7
+func xlsxColumnIndex(ref string) int {
+
+	index := 0
+	for _, char := range ref {
+		if char < 'A' || char > 'Z' {
+			break
+		}
+
+		index = index*26 + int(char-'A'+1)
+		if index > maxXLSXColumns {
+			break
+		}
+	}
+
+	return index - 1
+}
+
+// ReadXLSX is a function to read the rows of the first sheet of the given xlsx file as text,
+// the empty cells skipped by the file are filled so every cell is kept in its column
+func ReadXLSX(content []byte) ([][]string, error) {
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, WrapError(ErrCodeValidation, err, "File xlsx tidak valid")
+	}
+
+	// look for the part of the first sheet, the default part name is used if the workbook doesn't say
+	sheetPart := "xl/worksheets/sheet1.xml"
+
+	var workbook xlsxWorkbook
+	var relationships xlsxRelationships
+	if found, err := readXLSXPart(archive, "xl/workbook.xml", &workbook); err != nil || !found {
+		return nil, WrapError(ErrCodeValidation, err, "File xlsx tidak valid")
+	}
+
+	if _, err := readXLSXPart(archive, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, WrapError(ErrCodeValidation, err, "File xlsx tidak valid")
+	}
+
+	if len(workbook.Sheets) > 0 {
+		for _, relationship := range relationships.Relationships {
+			if relationship.ID != workbook.Sheets[0].RID {
+				continue
+			}
+
+			// the target is relative to the workbook part unless it is absolute
+			if strings.HasPrefix(relationship.Target, "/") {
+				sheetPart = strings.TrimPrefix(relationship.Target, "/")
+			} else {
+				sheetPart = path.Join("xl", relationship.Target)
+			}
+		}
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if _, err := readXLSXPart(archive, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+		return nil, WrapError(ErrCodeValidation, err, "File xlsx tidak valid")
+	}
+
+	var worksheet xlsxWorksheet
+	if found, err := readXLSXPart(archive, sheetPart, &worksheet); err != nil || !found {
+		return nil, WrapError(ErrCodeValidation, err, "File xlsx tidak valid")
+	}
+
+	rows := make([][]string, 0, len(worksheet.Rows))
+	for _, sheetRow := range worksheet.Rows {
+
+		// the empty rows are skipped by the file, fill them so the row numbers match the sheet
+		if sheetRow.Ref > maxXLSXRows {
+			return nil, NewError(ErrCodeValidation, "File xlsx tidak valid")
+		}
+
+		for len(rows) < sheetRow.Ref-1 {
+			rows = append(rows, nil)
+		}
+
+		var row []string
+		for _, cell := range sheetRow.Cells {
+
+			var value string
+			switch cell.Type {
+			case "s":
+				// the shared string is referenced by its index
+				index, err := strconv.Atoi(cell.Value)
+				if err == nil && index >= 0 && index < len(sharedStrings.Items) {
+					value = sharedStrings.Items[index].String()
+				}
+			case "inlineStr":
+				value = cell.Inline.String()
+			default:
+				value = cell.Value
+			}
+
+			// the cells without reference follow the previous cell
+			column := len(row)
+			if cell.Ref != "" {
+				column = xlsxColumnIndex(cell.Ref)
+			}
+
+			if column < 0 || column >= maxXLSXColumns {
+				return nil, NewError(ErrCodeValidation, "File xlsx tidak valid")
+			}
+
+			for len(row) <= column {
+				row = append(row, "")
+			}
+
+			row[column] = value
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// xlsxStaticParts is the parts of the written xlsx file that don't depend on the sheet content
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxNumberPattern matches the plain decimal number the numeric cell can hold
+var xlsxNumberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// xlsxColumnName gets the column name of the given zero based column index, e.g. C for 2
+func xlsxColumnName(index int) string {
+
+	var name []byte
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = append([]byte{byte('A' + (index-1)%26)}, name...)
+	}
+
+	return string(name)
+}
+
+// WriteXLSX is a function to write the rows given by the rows function as the single sheet of an xlsx file to the given writer,
+// the rows function calls its write function once per row, the cells of the given numeric columns are written as numbers
+// except for the header on the first row, every row is streamed as soon as it is given so the rows are never held in memory
+func WriteXLSX(dst io.Writer, sheetName string, numericColumns map[int]bool, rows func(write func(row []string) error) error) error {
+
+	archive := zip.NewWriter(dst)
+
+	for _, part := range xlsxStaticParts {
+		writer, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(writer, part.content); err != nil {
+			return err
+		}
+	}
+
+	writer, err := archive.Create("xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+
+	var escapedName bytes.Buffer
+	xml.EscapeText(&escapedName, []byte(sheetName))
+
+	if _, err := fmt.Fprintf(writer, xml.Header+`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" `+
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`, escapedName.String()); err != nil {
+		return err
+	}
+
+	writer, err = archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+
+	sheet := bufio.NewWriter(writer)
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	rowIndex := 0
+	err = rows(func(row []string) error {
+
+		fmt.Fprintf(sheet, `<row r="%d">`, rowIndex+1)
+		for columnIndex, value := range row {
+
+			if value == "" {
+				continue
+			}
+
+			ref := xlsxColumnName(columnIndex) + strconv.Itoa(rowIndex+1)
+
+			// the number is kept as text if it can't be parsed, so the cell is never corrupted
+			if rowIndex > 0 && numericColumns[columnIndex] {
+				if xlsxNumberPattern.MatchString(value) {
+					fmt.Fprintf(sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+					continue
+				}
+			}
+
+			fmt.Fprintf(sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(sheet, []byte(value))
+			sheet.WriteString(`</t></is></c>`)
+		}
+
+		rowIndex++
+		_, err := sheet.WriteString(`</row>`)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+	if err := sheet.Flush(); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,217 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// xlsxTestWorkbook is the workbook part pointing to the sheet of the test xlsx files
+const xlsxTestWorkbook = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Kosts" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+// xlsxTestRelationships is the workbook relationships part of the test xlsx files
+const xlsxTestRelationships = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/data.xml"/>
+</Relationships>`
+
+// buildTestXLSX zips the given parts into an xlsx file
+func buildTestXLSX(t *testing.T, parts map[string]string) []byte {
+
+	var content bytes.Buffer
+	archive := zip.NewWriter(&content)
+	for name, part := range parts {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+
+		if _, err := writer.Write([]byte(part)); err != nil {
+			t.Fatalf("Write(%s) error = %v", name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	return content.Bytes()
+}
+
+// xlsxTestSheet wraps the given sheet data into a worksheet part
+func xlsxTestSheet(sheetData string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheetData + `</sheetData></worksheet>`
+}
+
+func TestReadXLSX(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		sharedStrings string
+		sheetData     string
+		want          [][]string
+	}{
+		{
+			name: "shared strings",
+			sharedStrings: `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+				`<si><t>kost_name</t></si><si><t>city</t></si>` +
+				`<si><r><t>Kost </t></r><r><t>Melati</t></r></si><si><t>Jakarta</t></si></sst>`,
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" t="s"><v>3</v></c></row>`,
+			want: [][]string{{"kost_name", "city"}, {"Kost Melati", "Jakarta"}},
+		},
+		{
+			name: "inline strings and numbers",
+			sheetData: `<row r="1"><c r="A1" t="inlineStr"><is><t>room_price</t></is></c></row>` +
+				`<row r="2"><c r="A2"><v>1500000</v></c></row>`,
+			want: [][]string{{"room_price"}, {"1500000"}},
+		},
+		{
+			name:          "out of range shared string",
+			sharedStrings: `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>kost_name</t></si></sst>`,
+			sheetData:     `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>7</v></c></row>`,
+			want:          [][]string{{"kost_name", ""}},
+		},
+		{
+			name: "sparse cells",
+			sheetData: `<row r="1"><c r="B1" t="inlineStr"><is><t>city</t></is></c>` +
+				`<c r="D1" t="inlineStr"><is><t>address</t></is></c></row>`,
+			want: [][]string{{"", "city", "", "address"}},
+		},
+		{
+			name: "cells without reference follow the previous cell",
+			sheetData: `<row r="1"><c r="B1" t="inlineStr"><is><t>city</t></is></c>` +
+				`<c t="inlineStr"><is><t>address</t></is></c></row>`,
+			want: [][]string{{"", "city", "address"}},
+		},
+		{
+			name: "row gaps",
+			sheetData: `<row r="1"><c r="A1" t="inlineStr"><is><t>kost_ref</t></is></c></row>` +
+				`<row r="4"><c r="A4" t="inlineStr"><is><t>KOST-1</t></is></c></row>`,
+			want: [][]string{{"kost_ref"}, nil, nil, {"KOST-1"}},
+		},
+		{
+			name:      "columns past z",
+			sheetData: `<row r="1"><c r="AB1" t="inlineStr"><is><t>room_pict_urls</t></is></c></row>`,
+			want:      [][]string{append(make([]string, 27), "room_pict_urls")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			parts := map[string]string{
+				"xl/workbook.xml":            xlsxTestWorkbook,
+				"xl/_rels/workbook.xml.rels": xlsxTestRelationships,
+				"xl/worksheets/data.xml":     xlsxTestSheet(test.sheetData),
+			}
+
+			if test.sharedStrings != "" {
+				parts["xl/sharedStrings.xml"] = test.sharedStrings
+			}
+
+			got, err := ReadXLSX(buildTestXLSX(t, parts))
+			if err != nil {
+				t.Fatalf("ReadXLSX() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ReadXLSX() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadXLSXInvalid(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{
+			name:    "not a zip file",
+			content: []byte("kost_ref,kost_name"),
+		},
+		{
+			name: "missing workbook",
+			content: buildTestXLSX(t, map[string]string{
+				"xl/worksheets/sheet1.xml": xlsxTestSheet(""),
+			}),
+		},
+		{
+			name: "missing sheet",
+			content: buildTestXLSX(t, map[string]string{
+				"xl/workbook.xml":            xlsxTestWorkbook,
+				"xl/_rels/workbook.xml.rels": xlsxTestRelationships,
+			}),
+		},
+		{
+			name: "row past the sheet limit",
+			content: buildTestXLSX(t, map[string]string{
+				"xl/workbook.xml":            xlsxTestWorkbook,
+				"xl/_rels/workbook.xml.rels": xlsxTestRelationships,
+				"xl/worksheets/data.xml":     xlsxTestSheet(`<row r="1048577"><c r="A1048577"><v>1</v></c></row>`),
+			}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadXLSX(test.content); err == nil {
+				t.Errorf("ReadXLSX() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestWriteXLSXRoundTrip(t *testing.T) {
+
+	rows := [][]string{
+		{"kost_name", "room_price", "note"},
+		{"Kost <Melati> & Co", "1500000", ""},
+		{"Kost Mawar", "n/a", "  spaced  "},
+		{},
+		{"", "", "last"},
+	}
+
+	var content bytes.Buffer
+	err := WriteXLSX(&content, "Kosts & Rooms", map[int]bool{1: true}, func(write func(row []string) error) error {
+		for _, row := range rows {
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("WriteXLSX() error = %v", err)
+	}
+
+	if !IsXLSX(content.Bytes()) {
+		t.Fatalf("IsXLSX() = false, want true")
+	}
+
+	got, err := ReadXLSX(content.Bytes())
+	if err != nil {
+		t.Fatalf("ReadXLSX() error = %v", err)
+	}
+
+	// the empty cells are skipped by the writer, so the trailing empty cells are not read back
+	want := [][]string{
+		{"kost_name", "room_price", "note"},
+		{"Kost <Melati> & Co", "1500000"},
+		{"Kost Mawar", "n/a", "  spaced  "},
+		nil,
+		{"", "", "last"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadXLSX(WriteXLSX()) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package database
+
+import "time"
+
+// DBAuthSession will migrate an auth session table with the given specification into the database,
+// a session lives from the login until it is revoked or reaches its absolute lifetime
+type DBAuthSession struct {
+	ID                uint       `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	SessionID         string     `gorm:"unique;not null" json:"session_id"`
+	UserID            uint       `gorm:"not null;index" json:"user_id"`
+	RoleID            uint       `gorm:"not null" json:"role_id"`
+	Username          string     `gorm:"not null" json:"username"`
+	RefreshTokenHash  string     `gorm:"not null" json:"-"` // only the sha256 of the current refresh token is kept
+	RefreshExpiresAt  time.Time  `gorm:"type:datetime" json:"refresh_expires_at"`
+	AbsoluteExpiresAt time.Time  `gorm:"type:datetime;index" json:"absolute_expires_at"` // the session can't be refreshed past this time
+	LastRefreshed     time.Time  `gorm:"type:datetime" json:"last_refreshed"`
+	RevokedAt         *time.Time `gorm:"type:datetime" json:"revoked_at"`
+	RevokedReason     string     `json:"revoked_reason"`
+	Created           time.Time  `gorm:"type:datetime" json:"created"`
+	CreatedBy         string     `json:"created_by"`
+	Modified          time.Time  `gorm:"type:datetime" json:"modified"`
+	ModifiedBy        string     `json:"modified_by"`
+}
+
+// AuthSessionTable set the migrated struct table name
+func (dbAuthSession *DBAuthSession) AuthSessionTable() string {
+	return "dbAuthSession"
+}
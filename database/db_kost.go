@@ -41,15 +41,23 @@ type DBKostPeriod struct {
 
 // DBKostPict will migrate a kost pict table with the given specification into the database
 type DBKostPict struct {
-	ID         uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
-	KostID     uint      `gorm:"not null" json:"kost_id"`
-	PictDesc   string    `gorm:"not null" json:"pict_desc"`
-	URL        string    `gorm:"not null" json:"url"`
-	IsActive   bool      `gorm:"not null;default:true" json:"is_active"`
-	Created    time.Time `gorm:"type:datetime" json:"created"`
-	CreatedBy  string    `json:"created_by"`
-	Modified   time.Time `gorm:"type:datetime" json:"modified"`
-	ModifiedBy string    `json:"modified_by"`
+	ID               uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	KostID           uint      `gorm:"not null" json:"kost_id"`
+	PictDesc         string    `gorm:"not null" json:"pict_desc"`
+	URL              string    `gorm:"not null" json:"url"`
+	ThumbnailWebpURL string    `json:"thumbnail_webp_url"`
+	ThumbnailJpegURL string    `json:"thumbnail_jpeg_url"`
+	MediumWebpURL    string    `json:"medium_webp_url"`
+	MediumJpegURL    string    `json:"medium_jpeg_url"`
+	LargeWebpURL     string    `json:"large_webp_url"`
+	LargeJpegURL     string    `json:"large_jpeg_url"`
+	Blurhash         string    `json:"blurhash"`
+	SortOrder        uint      `gorm:"not null;default:0" json:"sort_order"`
+	IsActive         bool      `gorm:"not null;default:true" json:"is_active"`
+	Created          time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy        string    `json:"created_by"`
+	Modified         time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy       string    `json:"modified_by"`
 }
 
 // DBKostFacilities will migrate a kost facilities table with the given specification into the database
@@ -155,15 +163,23 @@ type DBKostRoomDetail struct {
 
 // DBKostRoomPict will migrate a kost room pict table with the given specification into the database
 type DBKostRoomPict struct {
-	ID         uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
-	RoomID     uint      `gorm:"not null" json:"room_id"`
-	PictDesc   string    `gorm:"not null" json:"pict_desc"`
-	URL        string    `gorm:"not null" json:"url"`
-	IsActive   bool      `gorm:"not null;default:true" json:"is_active"`
-	Created    time.Time `gorm:"type:datetime" json:"created"`
-	CreatedBy  string    `json:"created_by"`
-	Modified   time.Time `gorm:"type:datetime" json:"modified"`
-	ModifiedBy string    `json:"modified_by"`
+	ID               uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	RoomID           uint      `gorm:"not null" json:"room_id"`
+	PictDesc         string    `gorm:"not null" json:"pict_desc"`
+	URL              string    `gorm:"not null" json:"url"`
+	ThumbnailWebpURL string    `json:"thumbnail_webp_url"`
+	ThumbnailJpegURL string    `json:"thumbnail_jpeg_url"`
+	MediumWebpURL    string    `json:"medium_webp_url"`
+	MediumJpegURL    string    `json:"medium_jpeg_url"`
+	LargeWebpURL     string    `json:"large_webp_url"`
+	LargeJpegURL     string    `json:"large_jpeg_url"`
+	Blurhash         string    `json:"blurhash"`
+	SortOrder        uint      `gorm:"not null;default:0" json:"sort_order"`
+	IsActive         bool      `gorm:"not null;default:true" json:"is_active"`
+	Created          time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy        string    `json:"created_by"`
+	Modified         time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy       string    `json:"modified_by"`
 }
 
 // DBKostRoomFacilities will migrate a room facilities table with the given specification into the database
@@ -178,6 +194,39 @@ type DBKostRoomFacilities struct {
 	ModifiedBy string    `json:"modified_by"`
 }
 
+// DBKostBoost will migrate a kost boost table with the given specification into the database,
+// every row is a boost package granted to the kost or requested by its owner
+type DBKostBoost struct {
+	ID             uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	KostID         uint      `gorm:"not null;index" json:"kost_id"`
+	BoostPackageID uint      `gorm:"not null" json:"boost_package_id"`
+	UpRate         uint64    `gorm:"not null" json:"up_rate"`
+	Price          float64   `gorm:"not null" json:"price"`
+	PriceUOM       uint      `gorm:"not null" json:"price_uom"`
+	StartDate      time.Time `gorm:"type:datetime;not null" json:"start_date"`
+	ExpiredDate    time.Time `gorm:"type:datetime;not null" json:"expired_date"`
+	Status         uint      `gorm:"not null;default:0;index" json:"status"` // see the data kost boost statuses
+	IsActive       bool      `gorm:"not null;default:true" json:"is_active"`
+	Created        time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy      string    `json:"created_by"`
+	Modified       time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy     string    `json:"modified_by"`
+}
+
+// DBKostImport will migrate a kost import table with the given specification into the database,
+// every row is an import of the owner identified by its idempotency key so the same import is never added twice
+type DBKostImport struct {
+	ID             uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	OwnerID        uint      `gorm:"not null;uniqueIndex:idx_kost_import_key" json:"owner_id"`
+	IdempotencyKey string    `gorm:"not null;size:64;uniqueIndex:idx_kost_import_key" json:"idempotency_key"`
+	Mode           string    `gorm:"not null" json:"mode"`
+	Imported       uint      `gorm:"not null;default:0" json:"imported"`
+	Created        time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy      string    `json:"created_by"`
+	Modified       time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy     string    `json:"modified_by"`
+}
+
 // KostTable set the migrated struct table name
 func (dbKost *DBKost) KostTable() string {
 	return "dbKost"
@@ -237,3 +286,13 @@ func (dbKostRoomPict *DBKostRoomPict) KostRoomPictTable() string {
 func (dbKostRoomFacilities *DBKostRoomFacilities) KostRoomFacilitiesTable() string {
 	return "dbKostRoomFacilities"
 }
+
+// KostBoostTable set the migrated struct table name
+func (dbKostBoost *DBKostBoost) KostBoostTable() string {
+	return "dbKostBoost"
+}
+
+// KostImportTable set the migrated struct table name
+func (dbKostImport *DBKostImport) KostImportTable() string {
+	return "dbKostImport"
+}
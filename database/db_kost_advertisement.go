@@ -6,7 +6,9 @@ import "time"
 type DBKostAds struct {
 	ID                     uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
 	Status                 uint      `gorm:"not null" json:"status"`
+	StatusReason           string    `json:"status_reason"`
 	AdsCode                string    `gorm:"not null" json:"ads_code"`
+	AdsPackageID           uint      `json:"ads_package_id"`
 	AdsType                string    `gorm:"not null" json:"ads_type"`
 	AdsKostType            string    `gorm:"not null" json:"ads_kost_type"`
 	AdsOwner               string    `gorm:"not null" json:"ads_owner"`
@@ -23,6 +25,8 @@ type DBKostAds struct {
 	AdsHashtag             string    `gorm:"not null" json:"ads_hashtag"`
 	AdsLinkSwipeUp         string    `json:"ads_link_swipe_up"`
 	AdsIgBioLink           string    `json:"ads_ig_bio_link"`
+	SubmissionHash         string    `gorm:"index" json:"submission_hash"`
+	SubmissionLock         *string   `gorm:"unique" json:"-"` // the submission hash while the ads is being processed, null afterwards
 	IsActive               bool      `gorm:"not null;default:true" json:"is_active"`
 	Created                time.Time `gorm:"type:datetime" json:"created"`
 	CreatedBy              string    `json:"created_by"`
@@ -35,7 +39,12 @@ type DBKostAdsFiles struct {
 	ID           uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
 	AdsID        uint      `gorm:"not null" json:"ads_id"`
 	AdsFileType  string    `gorm:"not null" json:"ads_file_type"`
-	AdsDirPath   string    `gorm:"not null" json:"ads_dir_path"`
+	AdsFileName  string    `json:"ads_file_name"`
+	ContentType  string    `json:"content_type"`
+	FileSize     int64     `json:"file_size"`
+	Checksum     string    `json:"checksum"`
+	StorageKey   string    `json:"storage_key"`
+	AdsDirPath   string    `gorm:"not null" json:"ads_dir_path"` // legacy base64 text file path, empty for the binary objects
 	BASE64STRING string    `gorm:"not null" json:"base64_string"`
 	IsActive     bool      `gorm:"not null;default:true" json:"is_active"`
 	Created      time.Time `gorm:"type:datetime" json:"created"`
@@ -44,6 +53,70 @@ type DBKostAdsFiles struct {
 	ModifiedBy   string    `json:"modified_by"`
 }
 
+// DBKostAdsStatusLog will migrate a kost ads status log table with the given specification into the database
+type DBKostAdsStatusLog struct {
+	ID         uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	AdsID      uint      `gorm:"not null" json:"ads_id"`
+	FromStatus uint      `gorm:"not null" json:"from_status"`
+	ToStatus   uint      `gorm:"not null" json:"to_status"`
+	Reason     string    `json:"reason"`
+	IsActive   bool      `gorm:"not null;default:true" json:"is_active"`
+	Created    time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy  string    `json:"created_by"`
+	Modified   time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy string    `json:"modified_by"`
+}
+
+// DBKostAdsScheduleRequest will migrate a kost ads schedule request table with the given specification into the database
+type DBKostAdsScheduleRequest struct {
+	ID            uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	AdsID         uint      `gorm:"not null" json:"ads_id"`
+	Channel       string    `gorm:"not null" json:"channel"`
+	RequestedDate time.Time `gorm:"type:date;not null" json:"requested_date"`
+	IsActive      bool      `gorm:"not null;default:true" json:"is_active"`
+	Created       time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy     string    `json:"created_by"`
+	Modified      time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy    string    `json:"modified_by"`
+}
+
+// DBKostAdsSlot will migrate a kost ads posting slot table with the given specification into the database
+type DBKostAdsSlot struct {
+	ID         uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	AdsID      uint      `gorm:"not null" json:"ads_id"`
+	Channel    string    `gorm:"not null;uniqueIndex:idx_ads_slot" json:"channel"`
+	SlotDate   time.Time `gorm:"type:date;not null;uniqueIndex:idx_ads_slot" json:"slot_date"`
+	SlotNumber uint      `gorm:"not null;uniqueIndex:idx_ads_slot" json:"slot_number"`
+	IsActive   bool      `gorm:"not null;default:true" json:"is_active"`
+	Created    time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy  string    `json:"created_by"`
+	Modified   time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy string    `json:"modified_by"`
+}
+
+// DBKostAdsMetric will migrate a kost ads performance metric table with the given specification into the database,
+// every row is a snapshot of a single post metrics on the metric date
+type DBKostAdsMetric struct {
+	ID          uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	AdsID       uint      `gorm:"not null;uniqueIndex:idx_ads_metric" json:"ads_id"`
+	Channel     string    `gorm:"not null;uniqueIndex:idx_ads_metric" json:"channel"`
+	PostRef     string    `gorm:"not null;uniqueIndex:idx_ads_metric" json:"post_ref"`
+	MetricDate  time.Time `gorm:"type:date;not null;uniqueIndex:idx_ads_metric" json:"metric_date"`
+	Reach       int64     `gorm:"not null;default:0" json:"reach"`
+	Impressions int64     `gorm:"not null;default:0" json:"impressions"`
+	Views       int64     `gorm:"not null;default:0" json:"views"`
+	Likes       int64     `gorm:"not null;default:0" json:"likes"`
+	Comments    int64     `gorm:"not null;default:0" json:"comments"`
+	Shares      int64     `gorm:"not null;default:0" json:"shares"`
+	Saves       int64     `gorm:"not null;default:0" json:"saves"`
+	Clicks      int64     `gorm:"not null;default:0" json:"clicks"`
+	IsActive    bool      `gorm:"not null;default:true" json:"is_active"`
+	Created     time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy   string    `json:"created_by"`
+	Modified    time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy  string    `json:"modified_by"`
+}
+
 // KostAdvertisementTable set the migrated struct table name
 func (dbKostAds *DBKostAds) KostAdsTable() string {
 	return "dbKostAds"
@@ -53,3 +126,23 @@ func (dbKostAds *DBKostAds) KostAdsTable() string {
 func (dbKostAdsFiles *DBKostAdsFiles) KostAdsFilesTable() string {
 	return "dbKostAdsFiles"
 }
+
+// KostAdsStatusLogTable set the migrated struct table name
+func (dbKostAdsStatusLog *DBKostAdsStatusLog) KostAdsStatusLogTable() string {
+	return "dbKostAdsStatusLog"
+}
+
+// KostAdsScheduleRequestTable set the migrated struct table name
+func (dbKostAdsScheduleRequest *DBKostAdsScheduleRequest) KostAdsScheduleRequestTable() string {
+	return "dbKostAdsScheduleRequest"
+}
+
+// KostAdsSlotTable set the migrated struct table name
+func (dbKostAdsSlot *DBKostAdsSlot) KostAdsSlotTable() string {
+	return "dbKostAdsSlot"
+}
+
+// KostAdsMetricTable set the migrated struct table name
+func (dbKostAdsMetric *DBKostAdsMetric) KostAdsMetricTable() string {
+	return "dbKostAdsMetric"
+}
@@ -0,0 +1,25 @@
+package database
+
+import "time"
+
+// MasterAdsPackage will migrate a master ads package table with the given specification into the database
+type MasterAdsPackage struct {
+	ID            uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	PackageName   string    `gorm:"unique;not null" json:"package_name"`
+	Channel       string    `gorm:"not null" json:"channel"` // ig_feed , ig_story , tiktok
+	Tier          string    `gorm:"not null" json:"tier"`    // free , premium
+	Price         float64   `gorm:"not null" json:"price"`
+	PriceUOM      uint      `gorm:"not null" json:"price_uom"`
+	DurationDays  uint      `gorm:"not null" json:"duration_days"`
+	IncludedPosts uint      `gorm:"not null" json:"included_posts"`
+	IsActive      bool      `gorm:"not null;default:true" json:"is_active"`
+	Created       time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy     string    `json:"created_by"`
+	Modified      time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy    string    `json:"modified_by"`
+}
+
+// MasterAdsPackageTable set the migrated struct table name
+func (masterAdsPackage *MasterAdsPackage) MasterAdsPackageTable() string {
+	return "dbMasterAdsPackage"
+}
@@ -0,0 +1,26 @@
+package database
+
+import "time"
+
+// MasterAPIKey will migrate a master api key table with the given specification into the database,
+// the api key authenticates the internal consumers calling the service without a user session
+type MasterAPIKey struct {
+	ID         uint       `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	KeyID      string     `gorm:"unique;not null" json:"key_id"` // the public part of the key, logged on every request
+	KeyName    string     `gorm:"not null" json:"key_name"`
+	KeyHash    string     `gorm:"not null" json:"-"`               // only the sha256 of the key secret is kept
+	Scopes     string     `gorm:"not null" json:"scopes"`          // the granted scopes separated by comma
+	ExpiresAt  *time.Time `gorm:"type:datetime" json:"expires_at"` // nil means the key never expires
+	LastUsed   *time.Time `gorm:"type:datetime" json:"last_used"`
+	RevokedAt  *time.Time `gorm:"type:datetime" json:"revoked_at"`
+	IsActive   bool       `gorm:"not null;default:true" json:"is_active"`
+	Created    time.Time  `gorm:"type:datetime" json:"created"`
+	CreatedBy  string     `json:"created_by"`
+	Modified   time.Time  `gorm:"type:datetime" json:"modified"`
+	ModifiedBy string     `json:"modified_by"`
+}
+
+// MasterAPIKeyTable set the migrated struct table name
+func (masterAPIKey *MasterAPIKey) MasterAPIKeyTable() string {
+	return "dbMasterAPIKey"
+}
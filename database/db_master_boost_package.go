@@ -0,0 +1,23 @@
+package database
+
+import "time"
+
+// MasterBoostPackage will migrate a master boost package table with the given specification into the database
+type MasterBoostPackage struct {
+	ID           uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	PackageName  string    `gorm:"unique;not null" json:"package_name"`
+	UpRate       uint64    `gorm:"not null" json:"up_rate"` // the higher the up rate the higher the kost is ranked
+	DurationDays uint      `gorm:"not null" json:"duration_days"`
+	Price        float64   `gorm:"not null" json:"price"`
+	PriceUOM     uint      `gorm:"not null" json:"price_uom"`
+	IsActive     bool      `gorm:"not null;default:true" json:"is_active"`
+	Created      time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy    string    `json:"created_by"`
+	Modified     time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy   string    `json:"modified_by"`
+}
+
+// MasterBoostPackageTable set the migrated struct table name
+func (masterBoostPackage *MasterBoostPackage) MasterBoostPackageTable() string {
+	return "dbMasterBoostPackage"
+}
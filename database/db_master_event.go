@@ -4,14 +4,21 @@ import "time"
 
 // MasterEvent will migrate a master event table with the given specification into the database
 type MasterEvent struct {
-	ID         uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
-	EventName  string    `gorm:"not null" json:"event_name"`
-	Thumbnail  string    `json:"thumbnail"`
-	IsActive   bool      `gorm:"not null;default:true" json:"is_active"`
-	Created    time.Time `gorm:"type:datetime" json:"created"`
-	CreatedBy  string    `json:"created_by"`
-	Modified   time.Time `gorm:"type:datetime" json:"modified"`
-	ModifiedBy string    `json:"modified_by"`
+	ID                uint       `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	EventName         string     `gorm:"not null" json:"event_name"`
+	EventDesc         string     `json:"event_desc"`
+	Thumbnail         string     `json:"thumbnail"`
+	ThumbnailWebpURL  string     `json:"thumbnail_webp_url"`
+	ThumbnailBlurhash string     `json:"thumbnail_blurhash"`
+	StartDate         time.Time  `gorm:"type:datetime" json:"start_date"`
+	EndDate           *time.Time `gorm:"type:datetime" json:"end_date"`                 // nil means the legacy event never ends
+	IsOwnerOptIn      bool       `gorm:"not null;default:false" json:"is_owner_opt_in"` // whether the owners can enroll their kost by themselves
+	IsCancelled       bool       `gorm:"not null;default:false" json:"is_cancelled"`    // cancelled events are never activated by the scheduler
+	IsActive          bool       `gorm:"not null;default:false" json:"is_active"`       // maintained by the scheduler from the start and end date
+	Created           time.Time  `gorm:"type:datetime" json:"created"`
+	CreatedBy         string     `json:"created_by"`
+	Modified          time.Time  `gorm:"type:datetime" json:"modified"`
+	ModifiedBy        string     `json:"modified_by"`
 }
 
 // MasterEventDetail will migrate a master event detail table with the given specification into the database
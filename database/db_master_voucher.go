@@ -0,0 +1,31 @@
+package database
+
+import "time"
+
+// MasterVoucher will migrate a master voucher table with the given specification into the database
+type MasterVoucher struct {
+	ID            uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	VoucherCode   string    `gorm:"unique;not null" json:"voucher_code"`
+	VoucherDesc   string    `json:"voucher_desc"`
+	DiscountType  string    `gorm:"not null" json:"discount_type"`            // percentage or fixed
+	DiscountValue float64   `gorm:"not null" json:"discount_value"`           // the percentage or the fixed amount of the discount
+	MaxDiscount   float64   `gorm:"not null;default:0" json:"max_discount"`   // the cap of the percentage discount, 0 means no cap
+	KostID        uint      `gorm:"not null;default:0;index" json:"kost_id"`  // 0 means the voucher applies to every kost
+	EventID       uint      `gorm:"not null;default:0;index" json:"event_id"` // the voucher only applies to the enrolled kosts while the event is running
+	MinPeriodID   uint      `gorm:"not null;default:0" json:"min_period_id"`  // the shortest period the voucher can be booked with, 0 means any period
+	UsageLimit    uint      `gorm:"not null;default:0" json:"usage_limit"`    // 0 means unlimited
+	UsageCount    uint      `gorm:"not null;default:0" json:"usage_count"`
+	PerUserLimit  uint      `gorm:"not null;default:0" json:"per_user_limit"` // 0 means unlimited
+	StartDate     time.Time `gorm:"type:datetime" json:"start_date"`
+	EndDate       time.Time `gorm:"type:datetime" json:"end_date"`
+	IsActive      bool      `gorm:"not null;default:true" json:"is_active"`
+	Created       time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy     string    `json:"created_by"`
+	Modified      time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy    string    `json:"modified_by"`
+}
+
+// MasterVoucherTable set the migrated struct table name
+func (masterVoucher *MasterVoucher) MasterVoucherTable() string {
+	return "dbMasterVoucher"
+}
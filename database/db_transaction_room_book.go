@@ -4,20 +4,24 @@ import "time"
 
 // DBTransactionRoomBook is an entity that directly communicate with the TransactionRoomBook table in the database
 type DBTransactionRoomBook struct {
-	ID           uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
-	BookerID     uint      `gorm:"not null" json:"booker_id"`
-	KostID       uint      `gorm:"not null" json:"kost_id"`
-	RoomID       uint      `gorm:"not null" json:"room_id"`
-	RoomDetailID uint      `gorm:"not null" json:"room_detail_id"`
-	PeriodID     uint      `gorm:"not null" json:"period_id"`
-	Status       uint      `gorm:"not null" json:"status"`
-	BookCode     string    `gorm:"not null" json:"book_code"`
-	BookDate     time.Time `gorm:"not null" json:"book_date"`
-	IsActive     bool      `gorm:"not null;default:true" json:"is_active"`
-	Created      time.Time `gorm:"type:datetime" json:"created"`
-	CreatedBy    string    `json:"created_by"`
-	Modified     time.Time `gorm:"type:datetime" json:"modified"`
-	ModifiedBy   string    `json:"modified_by"`
+	ID             uint      `gorm:"primary_key;autoIncrement;not null" json:"id"`
+	BookerID       uint      `gorm:"not null" json:"booker_id"`
+	KostID         uint      `gorm:"not null" json:"kost_id"`
+	RoomID         uint      `gorm:"not null" json:"room_id"`
+	RoomDetailID   uint      `gorm:"not null" json:"room_detail_id"`
+	PeriodID       uint      `gorm:"not null" json:"period_id"`
+	Status         uint      `gorm:"not null" json:"status"`
+	BookCode       string    `gorm:"not null" json:"book_code"`
+	BookDate       time.Time `gorm:"not null" json:"book_date"`
+	RoomPrice      float64   `gorm:"not null;default:0" json:"room_price"`      // the room price when the room is booked
+	VoucherID      uint      `gorm:"not null;default:0" json:"voucher_id"`      // 0 means no voucher applied
+	DiscountAmount float64   `gorm:"not null;default:0" json:"discount_amount"` // the discount of the first invoice
+	InvoiceAmount  float64   `gorm:"not null;default:0" json:"invoice_amount"`  // the room price minus the discount
+	IsActive       bool      `gorm:"not null;default:true" json:"is_active"`
+	Created        time.Time `gorm:"type:datetime" json:"created"`
+	CreatedBy      string    `json:"created_by"`
+	Modified       time.Time `gorm:"type:datetime" json:"modified"`
+	ModifiedBy     string    `json:"modified_by"`
 }
 
 // DBTransactionRoomBookMember is an entity that directly communicate with the TransactionRoomBookMember table in the database
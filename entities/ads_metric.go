@@ -0,0 +1,73 @@
+package entities
+
+// AdsMetricValues is an entity to communicate with the ads performance metric values client side
+type AdsMetricValues struct {
+	Reach          int64   `json:"reach"`
+	Impressions    int64   `json:"impressions"`
+	Views          int64   `json:"views"`
+	Likes          int64   `json:"likes"`
+	Comments       int64   `json:"comments"`
+	Shares         int64   `json:"shares"`
+	Saves          int64   `json:"saves"`
+	Clicks         int64   `json:"clicks"`
+	EngagementRate float64 `json:"engagement_rate"`
+}
+
+// AdsMetricImportError is an entity to communicate with the rejected row of the ads metric import client side
+type AdsMetricImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// AdsMetricImportResult is an entity to communicate with the ads metric import summary client side
+type AdsMetricImportResult struct {
+	Imported int                    `json:"imported"`
+	Failed   []AdsMetricImportError `json:"failed"`
+}
+
+// AdsPostMetric is an entity to communicate with the latest metrics of a single ads post client side
+type AdsPostMetric struct {
+	Channel        string `json:"channel"`
+	PostRef        string `json:"post_ref"`
+	LastMetricDate string `json:"last_metric_date"`
+	AdsMetricValues
+}
+
+// AdsMetricTimelinePoint is an entity to communicate with the ads metrics on a given date client side
+type AdsMetricTimelinePoint struct {
+	MetricDate string `json:"metric_date"`
+	AdsMetricValues
+}
+
+// AdsMetricReport is an entity to communicate with the performance report of an ads client side
+type AdsMetricReport struct {
+	AdsID        uint                     `json:"ads_id"`
+	AdsCode      string                   `json:"ads_code"`
+	AdsOwner     string                   `json:"ads_owner"`
+	AdsPackageID uint                     `json:"ads_package_id"`
+	PackageName  string                   `json:"package_name"`
+	Channel      string                   `json:"channel"`
+	Totals       AdsMetricValues          `json:"totals"`
+	Posts        []AdsPostMetric          `json:"posts"`
+	Timeline     []AdsMetricTimelinePoint `json:"timeline"`
+}
+
+// AdsMetricSummary is an entity to communicate with the metric totals of an ads in the package report client side
+type AdsMetricSummary struct {
+	AdsID     uint            `json:"ads_id"`
+	AdsCode   string          `json:"ads_code"`
+	AdsOwner  string          `json:"ads_owner"`
+	PostCount int             `json:"post_count"`
+	Totals    AdsMetricValues `json:"totals"`
+}
+
+// AdsPackageMetricReport is an entity to communicate with the performance report of an ads package client side
+type AdsPackageMetricReport struct {
+	AdsPackageID uint               `json:"ads_package_id"`
+	PackageName  string             `json:"package_name"`
+	Channel      string             `json:"channel"`
+	AdsCount     int                `json:"ads_count"`
+	Totals       AdsMetricValues    `json:"totals"`
+	Average      AdsMetricValues    `json:"average"`
+	Ads          []AdsMetricSummary `json:"ads"`
+}
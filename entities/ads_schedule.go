@@ -0,0 +1,36 @@
+package entities
+
+// AdsSchedule is an entity to communicate with the ads posting schedule client side, the dates use the YYYY-MM-DD format
+type AdsSchedule struct {
+	Channel string   `json:"channel"`
+	Dates   []string `json:"dates"`
+}
+
+// AdsCalendarSlot is an entity to communicate with the allocated ads posting slot client side
+type AdsCalendarSlot struct {
+	SlotNumber uint   `json:"slot_number"`
+	AdsID      uint   `json:"ads_id"`
+	AdsCode    string `json:"ads_code"`
+	AdsOwner   string `json:"ads_owner"`
+	AdsStatus  string `json:"ads_status"`
+}
+
+// AdsCalendarChannel is an entity to communicate with the ads posting slots of a channel client side
+type AdsCalendarChannel struct {
+	Channel  string            `json:"channel"`
+	Capacity int               `json:"capacity"`
+	Slots    []AdsCalendarSlot `json:"slots"`
+}
+
+// AdsCalendarDay is an entity to communicate with the ads posting calendar of a day client side
+type AdsCalendarDay struct {
+	Date     string               `json:"date"`
+	Channels []AdsCalendarChannel `json:"channels"`
+}
+
+// AdsScheduleConflict is an entity to communicate with the ads posting slot conflict client side
+type AdsScheduleConflict struct {
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	Conflicts []string `json:"conflicts"`
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "time"
+
+// APIKey is an entity to communicate with the api key issuance client side
+type APIKey struct {
+	KeyName   string     `json:"key_name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IssuedAPIKey is an entity to communicate with the newly issued api key client side,
+// the key is only shown once as the service keeps its hash only
+type IssuedAPIKey struct {
+	ID        uint       `json:"id"`
+	KeyID     string     `json:"key_id"`
+	KeyName   string     `json:"key_name"`
+	Key       string     `json:"key"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -5,3 +5,9 @@ type ApprovalKost struct {
 	KostID       uint `json:"kost_id"`
 	FlagApproval bool `json:"flag_approval"`
 }
+
+// AdsStatusTransition is an entity to communicate with the ads moderation client side
+type AdsStatusTransition struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,15 @@
+package entities
+
+// AuthToken is an entity to communicate with the issued access and refresh token client side
+type AuthToken struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"` // the access token lifetime in seconds
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"` // the refresh token lifetime in seconds
+}
+
+// RefreshTokenRequest is an entity to communicate with the token refresh client side
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
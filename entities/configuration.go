@@ -1,19 +1,25 @@
 package entities
 
+import "time"
+
 // Configuration Entity
 type Configuration struct {
 	API        APIConfiguration
 	Database   DatabaseConfiguration
 	Jwt        JwtConfiguration
 	MySQLStore MySQLStoreConfiguration
+	Ads        AdsConfiguration
+	RateLimit  RateLimitConfiguration
+	Roles      RoleConfiguration
 }
 
 // APIConfiguration is an entity that stores the app configuration
 type APIConfiguration struct {
-	Environment string
-	Host        string
-	Port        int
-	Debug       bool
+	Environment    string
+	Host           string
+	Port           int
+	Debug          bool
+	TrustedProxies []string // the ip address or cidr of the reverse proxies whose X-Forwarded-For header is trusted
 }
 
 // DatabaseConfiguration is an entity that stores the database configuration
@@ -25,12 +31,58 @@ type DatabaseConfiguration struct {
 	Dbname   string
 }
 
-// JwtConfiguration is an entity that stores the JWT secret
+// JwtConfiguration is an entity that stores the JWT secret and the token lifetimes
 type JwtConfiguration struct {
-	Secret string
+	Secret             string
+	AccessTokenMinutes int
+	RefreshTokenDays   int
+	SessionMaxDays     int
 }
 
 // MySQLStoreConfiguration is an entity that stores the MySqlStore secret
 type MySQLStoreConfiguration struct {
 	Secret string
 }
+
+// AdsConfiguration is an entity that stores the ads posting slot capacity per day and the caption templates of every channel
+type AdsConfiguration struct {
+	IGFeedDailySlots  int
+	IGStoryDailySlots int
+	TiktokDailySlots  int
+	CaptionTemplates  map[string]string
+	CaptionLimits     map[string]int
+	Submission        AdsSubmissionConfiguration
+}
+
+// AdsSubmissionConfiguration is an entity that stores the abuse protection settings of the unauthenticated ads submission
+type AdsSubmissionConfiguration struct {
+	MaxRequestSizeMB     int
+	MaxFiles             int
+	PerIPHourly          int
+	PerPhoneDaily        int
+	PowDifficulty        int
+	DuplicateWindowHours int
+}
+
+// RateLimitConfiguration is an entity that stores the request rate limit of the routes by their rule name
+type RateLimitConfiguration struct {
+	Enabled bool
+	Rules   map[string]RateLimitRule
+}
+
+// RateLimitRule is an entity that stores the token bucket setting of a rate limited route,
+// the bucket holds up to the burst tokens and is refilled at the requests per minute rate,
+// or with the whole burst over the window when the window is set, e.g. for the limits counted per hour or per day
+type RateLimitRule struct {
+	RequestsPerMinute int
+	Burst             int
+	Window            time.Duration
+}
+
+// RoleConfiguration is an entity that stores the role ids of the master users registered by the auth service
+type RoleConfiguration struct {
+	Admin      uint
+	Owner      uint
+	Tenant     uint
+	SocialTeam uint
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// Event is an entity to communicate with the event client side
+type Event struct {
+	EventName    string    `json:"event_name"`
+	EventDesc    string    `json:"event_desc"`
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	IsOwnerOptIn bool      `json:"is_owner_opt_in"`
+}
+
+// EventEnrollment is an entity to communicate with the kost enrollment of an event client side
+type EventEnrollment struct {
+	KostID uint `json:"kost_id"`
+}
+
+// EventKostList is an entity to communicate with the kost list of an event client side
+type EventKostList struct {
+	KostList  []Kost `json:"kost_list"`
+	KostCount int64  `json:"kost_count"`
+}
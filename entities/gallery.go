@@ -0,0 +1,11 @@
+package entities
+
+// PictOrder is an entity to communicate with the pict gallery reorder client side
+type PictOrder struct {
+	PictIDs []uint `json:"pict_ids"`
+}
+
+// PictCaption is an entity to communicate with the pict gallery caption client side
+type PictCaption struct {
+	PictDesc string `json:"pict_desc"`
+}
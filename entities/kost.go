@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"mime/multipart"
 	"time"
 
 	"github.com/fakhripraya/kost-service/database"
@@ -217,7 +218,10 @@ type MasterUOM struct {
 type KostAds struct {
 	ID                     uint                      `json:"id"`
 	Status                 uint                      `json:"status"`
+	StatusReason           string                    `json:"status_reason"`
 	AdsCode                string                    `json:"ads_code"`
+	AdsPackageID           uint                      `json:"ads_package_id"`
+	AdsChannel             string                    `json:"ads_channel"`
 	AdsType                string                    `json:"ads_type"`
 	AdsKostType            string                    `json:"ads_kost_type"`
 	AdsOwner               string                    `json:"ads_owner"`
@@ -231,10 +235,12 @@ type KostAds struct {
 	AdsGender              string                    `json:"ads_gender"`
 	AdsPetAllowed          string                    `json:"ads_pet_allowed"`
 	AdsPostScheduleRequest string                    `json:"ads_post_schedule_request"`
+	AdsScheduleRequests    []AdsSchedule             `gorm:"-" json:"ads_schedule_requests"`
 	AdsHashtag             string                    `json:"ads_hashtag"`
 	AdsLinkSwipeUp         string                    `json:"ads_link_swipe_up"`
 	AdsIgBioLink           string                    `json:"ads_ig_bio_link"`
 	AdsFiles               []database.DBKostAdsFiles `gorm:"-" json:"ads_files"`
+	AdsUploads             []KostAdsUpload           `gorm:"-" json:"-"`
 	IsActive               bool                      `json:"is_active"`
 	Created                time.Time                 `json:"created"`
 	CreatedBy              string                    `json:"created_by"`
@@ -244,13 +250,130 @@ type KostAds struct {
 
 // KostAdsFiles is an entity to communicate with the kost ads file client side
 type KostAdsFiles struct {
+	ID          uint      `json:"id"`
+	AdsID       uint      `json:"ads_id"`
+	AdsFileType string    `json:"ads_file_type"`
+	AdsFileName string    `json:"ads_file_name"`
+	ContentType string    `json:"content_type"`
+	FileSize    int64     `json:"file_size"`
+	Checksum    string    `json:"checksum"`
+	DownloadURL string    `json:"download_url"`
+	IsActive    bool      `json:"is_active"`
+	Created     time.Time `json:"created"`
+	CreatedBy   string    `json:"created_by"`
+	Modified    time.Time `json:"modified"`
+	ModifiedBy  string    `json:"modified_by"`
+}
+
+// PictVariants is an entity to communicate with the generated pict variants client side
+type PictVariants struct {
+	ThumbnailWebpURL string `json:"thumbnail_webp_url"`
+	ThumbnailJpegURL string `json:"thumbnail_jpeg_url"`
+	MediumWebpURL    string `json:"medium_webp_url"`
+	MediumJpegURL    string `json:"medium_jpeg_url"`
+	LargeWebpURL     string `json:"large_webp_url"`
+	LargeJpegURL     string `json:"large_jpeg_url"`
+	Blurhash         string `json:"blurhash"`
+}
+
+// KostAdsUpload is an entity to communicate with the kost ads file uploaded as multipart form
+type KostAdsUpload struct {
+	AdsFileType string
+	FileHeader  *multipart.FileHeader
+}
+
+// MasterAdsPackage is an entity to communicate with the master ads package client side
+type MasterAdsPackage struct {
+	ID            uint      `json:"id"`
+	PackageName   string    `json:"package_name"`
+	Channel       string    `json:"channel"`
+	Tier          string    `json:"tier"`
+	Price         float64   `json:"price"`
+	PriceUOM      uint      `json:"price_uom"`
+	PriceUOMDesc  string    `json:"price_uom_desc"`
+	DurationDays  uint      `json:"duration_days"`
+	IncludedPosts uint      `json:"included_posts"`
+	IsActive      bool      `json:"is_active"`
+	Created       time.Time `json:"created"`
+	CreatedBy     string    `json:"created_by"`
+	Modified      time.Time `json:"modified"`
+	ModifiedBy    string    `json:"modified_by"`
+}
+
+// AdsCaption is an entity to communicate with the rendered ads caption client side
+type AdsCaption struct {
+	AdsID     uint   `json:"ads_id"`
+	AdsCode   string `json:"ads_code"`
+	Channel   string `json:"channel"`
+	Caption   string `json:"caption"`
+	Length    int    `json:"length"`
+	Limit     int    `json:"limit"`
+	Truncated bool   `json:"truncated"`
+}
+
+// AdsChallenge is an entity to communicate with the ads submission proof of work challenge client side,
+// the client must find a nonce where sha256(challenge + ":" + nonce) starts with the given number of zero bits
+type AdsChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// AdsBundleMetadata is an entity to communicate with the metadata.json of the ads zip bundle client side
+type AdsBundleMetadata struct {
+	Ads         database.DBKostAds        `json:"ads"`
+	AdsStatus   string                    `json:"ads_status"`
+	AdsPackage  database.MasterAdsPackage `json:"ads_package"`
+	Files       []AdsBundleFile           `json:"files"`
+	GeneratedAt time.Time                 `json:"generated_at"`
+}
+
+// AdsBundleFile is an entity to communicate with the ads file listed in the zip bundle metadata client side
+type AdsBundleFile struct {
+	ID          uint   `json:"id"`
+	AdsFileType string `json:"ads_file_type"`
+	AdsFileName string `json:"ads_file_name"`
+	ContentType string `json:"content_type"`
+	FileSize    int64  `json:"file_size"`
+	Checksum    string `json:"checksum"`
+	Path        string `json:"path"`
+}
+
+// MasterBoostPackage is an entity to communicate with the kost boost package catalogue client side
+type MasterBoostPackage struct {
 	ID           uint      `json:"id"`
-	AdsID        uint      `json:"ads_id"`
-	AdsFileType  string    `json:"ads_file_type"`
-	BASE64STRING string    `json:"base64_string"`
+	PackageName  string    `json:"package_name"`
+	UpRate       uint64    `json:"up_rate"`
+	DurationDays uint      `json:"duration_days"`
+	Price        float64   `json:"price"`
+	PriceUOM     uint      `json:"price_uom"`
+	PriceUOMDesc string    `json:"price_uom_desc"`
 	IsActive     bool      `json:"is_active"`
 	Created      time.Time `json:"created"`
 	CreatedBy    string    `json:"created_by"`
 	Modified     time.Time `json:"modified"`
 	ModifiedBy   string    `json:"modified_by"`
 }
+
+// KostBoostRequest is an entity to communicate with the kost boost grant client side
+type KostBoostRequest struct {
+	BoostPackageID uint `json:"boost_package_id"`
+}
+
+// KostCompleteness is an entity to communicate with the kost draft completeness client side
+type KostCompleteness struct {
+	KostID    uint                `json:"kost_id"`
+	Status    uint                `json:"status"`
+	Score     int                 `json:"score"`
+	CanSubmit bool                `json:"can_submit"`
+	Checklist []KostChecklistItem `json:"checklist"`
+}
+
+// KostChecklistItem is an entity to communicate with the kost draft checklist item client side
+type KostChecklistItem struct {
+	Key      string `json:"key"`
+	Section  string `json:"section"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+	Done     bool   `json:"done"`
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// KostExportFilter is an entity to communicate with the kost export request client side
+type KostExportFilter struct {
+	Dataset string     `json:"dataset"`
+	Format  string     `json:"format"`
+	Columns []string   `json:"columns"`
+	OwnerID uint       `json:"owner_id"`
+	From    *time.Time `json:"from"`
+	To      *time.Time `json:"to"`
+}
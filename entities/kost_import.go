@@ -0,0 +1,26 @@
+package entities
+
+// KostImportError is an entity to communicate with the rejected row of the kost import client side
+type KostImportError struct {
+	Row     int    `json:"row"`
+	KostRef string `json:"kost_ref"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// KostImportedKost is an entity to communicate with the kost added by the kost import client side
+type KostImportedKost struct {
+	KostRef  string `json:"kost_ref"`
+	KostID   uint   `json:"kost_id"`
+	KostCode string `json:"kost_code"`
+	Rooms    int    `json:"rooms"`
+}
+
+// KostImportResult is an entity to communicate with the kost import summary client side
+type KostImportResult struct {
+	Mode      string             `json:"mode"`
+	Committed bool               `json:"committed"`
+	Total     int                `json:"total"`
+	Imported  []KostImportedKost `json:"imported"`
+	Failed    []KostImportError  `json:"failed"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+// MasterData is an entity to communicate with the master data client side,
+// only the fields of the requested master data kind are used
+type MasterData struct {
+	FacCategory uint    `json:"fac_category"`
+	FacName     string  `json:"fac_name"`
+	TypeDesc    string  `json:"type_desc"`
+	UOMType     string  `json:"uom_type"`
+	UOMDesc     string  `json:"uom_desc"`
+	UOMRate     float64 `json:"uom_rate"`
+	PeriodDesc  string  `json:"status_desc"` // follows the json name of the master period
+	PeriodValue float64 `json:"period_value"`
+	IconName    string  `json:"icon_name"`
+	IsActive    bool    `json:"is_active"`
+}
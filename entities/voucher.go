@@ -0,0 +1,43 @@
+package entities
+
+import "time"
+
+// Voucher is an entity to communicate with the voucher client side
+type Voucher struct {
+	VoucherCode   string    `json:"voucher_code"`
+	VoucherDesc   string    `json:"voucher_desc"`
+	DiscountType  string    `json:"discount_type"`
+	DiscountValue float64   `json:"discount_value"`
+	MaxDiscount   float64   `json:"max_discount"`
+	KostID        uint      `json:"kost_id"`
+	EventID       uint      `json:"event_id"`
+	MinPeriodID   uint      `json:"min_period_id"`
+	UsageLimit    uint      `json:"usage_limit"`
+	PerUserLimit  uint      `json:"per_user_limit"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	IsActive      bool      `json:"is_active"`
+}
+
+// RoomBookMember is an entity to communicate with the member of a room booking client side
+type RoomBookMember struct {
+	MemberName string `json:"member_name"`
+	Phone      string `json:"phone"`
+	Gender     bool   `json:"gender"`
+}
+
+// RoomBookRequest is an entity to communicate with the room booking client side
+type RoomBookRequest struct {
+	RoomDetailID uint             `json:"room_detail_id"`
+	PeriodID     uint             `json:"period_id"`
+	VoucherCode  string           `json:"voucher_code"`
+	Members      []RoomBookMember `json:"members"`
+}
+
+// RoomBookQuote is an entity to communicate with the invoice amount of a room booking client side
+type RoomBookQuote struct {
+	RoomPrice      float64 `json:"room_price"`
+	VoucherCode    string  `json:"voucher_code"`
+	DiscountAmount float64 `json:"discount_amount"`
+	InvoiceAmount  float64 `json:"invoice_amount"`
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fakhripraya/kost-service/config"
+	"github.com/fakhripraya/kost-service/data"
+	"github.com/fakhripraya/kost-service/database"
+	"github.com/fakhripraya/kost-service/entities"
+	"github.com/gorilla/mux"
+)
+
+// RemoveKostPict is a method to remove the given kost pict from the gallery by the owner
+func (kostHandler *KostHandler) RemoveKostPict(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+
+	// get the pict id via mux
+	vars := mux.Vars(r)
+	pictID, err := strconv.ParseUint(vars["pictId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// soft delete the kost pict, the kost thumbnail is reassigned if the pict is the cover
+	err = kostHandler.kost.RemoveKostPict(currentUser, targetKost, uint(pictID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses menghapus foto kost"}, rw)
+	return
+}
+
+// RemoveKostRoomPict is a method to remove the given kost room pict from the gallery by the owner
+func (kostHandler *KostHandler) RemoveKostRoomPict(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+
+	// get the room id and the pict id via mux
+	vars := mux.Vars(r)
+	roomID, err := strconv.ParseUint(vars["roomId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	pictID, err := strconv.ParseUint(vars["pictId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// look for the target room in the db, it must belong to the target kost
+	var targetRoom database.DBKostRoom
+	if err := config.DB.Where("id = ? AND kost_id = ?", roomID, targetKost.ID).First(&targetRoom).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// soft delete the kost room pict, the kost thumbnail is reassigned if the pict is the cover
+	err = kostHandler.kost.RemoveKostRoomPict(currentUser, targetKost, targetRoom.ID, uint(pictID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses menghapus foto kamar"}, rw)
+	return
+}
+
+// AdminDeactivateAdsPackage is a method to deactivate the given ads package of the catalogue by the admin
+func (kostHandler *KostHandler) AdminDeactivateAdsPackage(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads package id via mux
+	vars := mux.Vars(r)
+	packageID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the package is only deactivated as the existing ads still refer to it
+	err = kostHandler.kost.DeactivateAdsPackage(currentUser, uint(packageID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses menonaktifkan paket iklan"}, rw)
+	return
+}
+
+// AdminDeactivateEvent is a method to deactivate the given event by the admin
+func (kostHandler *KostHandler) AdminDeactivateEvent(rw http.ResponseWriter, r *http.Request) {
+
+	// get the event id via mux
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the event is only deactivated so its enrollment history is kept
+	err = kostHandler.kost.DeactivateEvent(currentUser, uint(eventID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses menonaktifkan event"}, rw)
+	return
+}
+
+// WithdrawEventKost is a method to withdraw the given kost from the given event by its owner or the admin
+func (kostHandler *KostHandler) WithdrawEventKost(rw http.ResponseWriter, r *http.Request) {
+
+	// get the event id and the kost id via mux
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	kostID, err := strconv.ParseUint(vars["kostId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID kost tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	err = kostHandler.kost.WithdrawEventKost(currentUser, uint(eventID), uint(kostID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses mengeluarkan kost dari event"}, rw)
+	return
+}
+
+// AdminDeactivateVoucher is a method to deactivate the given voucher by the admin
+func (kostHandler *KostHandler) AdminDeactivateVoucher(rw http.ResponseWriter, r *http.Request) {
+
+	// get the voucher id via mux
+	vars := mux.Vars(r)
+	voucherID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the voucher is only deactivated as the existing bookings still refer to it
+	err = kostHandler.kost.DeactivateVoucher(currentUser, uint(voucherID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses menonaktifkan voucher"}, rw)
+	return
+}
+
+// AdminDeactivateMasterData is a method to deactivate the given master data of the given kind by the admin
+func (kostHandler *KostHandler) AdminDeactivateMasterData(rw http.ResponseWriter, r *http.Request) {
+
+	// get the master data kind and id via mux
+	vars := mux.Vars(r)
+	masterDataID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the master data is only deactivated as the existing kosts still refer to it
+	err = kostHandler.kost.DeactivateMasterData(currentUser, vars["kind"], uint(masterDataID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses menonaktifkan master data"}, rw)
+	return
+}
+
+// AdminRevokeAPIKey is a method to revoke the given api key by the admin
+func (kostHandler *KostHandler) AdminRevokeAPIKey(rw http.ResponseWriter, r *http.Request) {
+
+	// get the api key id via mux
+	vars := mux.Vars(r)
+	apiKeyID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the api key is kept so its usage can still be audited
+	err = kostHandler.kost.RevokeAPIKey(currentUser, uint(apiKeyID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses mencabut API key"}, rw)
+	return
+}
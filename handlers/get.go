@@ -1,10 +1,14 @@
 package handlers
 
 import (
-	"io/ioutil"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fakhripraya/kost-service/config"
 	"github.com/fakhripraya/kost-service/data"
@@ -19,11 +23,10 @@ func (kostHandler *KostHandler) GetKost(rw http.ResponseWriter, r *http.Request)
 	// get the kost via context
 	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
 
-	// look for the selected kost in the db to fetch all the picts
+	// look for the selected kost in the db to fetch all the picts, the drafts are only seen by their owner in the owner kost list
 	var selectedKost database.DBKost
-	if err := config.DB.Where("id = ?", kostReq.ID).First(&selectedKost).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	if err := config.DB.Where("id = ? AND status <> ?", kostReq.ID, data.KostStatusDraft).First(&selectedKost).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -31,8 +34,7 @@ func (kostHandler *KostHandler) GetKost(rw http.ResponseWriter, r *http.Request)
 	// parse the given instance to the response writer
 	err := data.ToJSON(selectedKost, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -52,8 +54,7 @@ func (kostHandler *KostHandler) GetKostPeriod(rw http.ResponseWriter, r *http.Re
 		Select("db_kost_periods.id,db_kost_periods.kost_id,db_kost_periods.period_id,master_periods.period_desc, master_periods.is_active").
 		Joins("inner join master_periods on master_periods.id = db_kost_periods.period_id").
 		Where("db_kost_periods.kost_id = ?", kostReq.ID).Scan(&kostPeriod).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -61,8 +62,7 @@ func (kostHandler *KostHandler) GetKostPeriod(rw http.ResponseWriter, r *http.Re
 	// parse the given instance to the response writer
 	err := data.ToJSON(kostPeriod, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -78,9 +78,8 @@ func (kostHandler *KostHandler) GetKostPicts(rw http.ResponseWriter, r *http.Req
 
 	// look for the selected kost in the db to fetch all the picts
 	var kostPicts []database.DBKostPict
-	if err := config.DB.Where("kost_id = ?", kostReq.ID).Find(&kostPicts).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	if err := config.DB.Where("kost_id = ? AND is_active = ?", kostReq.ID, true).Order("sort_order, id").Find(&kostPicts).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -88,8 +87,7 @@ func (kostHandler *KostHandler) GetKostPicts(rw http.ResponseWriter, r *http.Req
 	// parse the given instance to the response writer
 	err := data.ToJSON(kostPicts, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -109,8 +107,7 @@ func (kostHandler *KostHandler) GetKostFacilities(rw http.ResponseWriter, r *htt
 	// get the facilities from the method
 	kostFacilities, kostRoomFacilities, err := kostHandler.kost.GetKostFacilities(kostReq.ID, vars["roomId"])
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -123,8 +120,7 @@ func (kostHandler *KostHandler) GetKostFacilities(rw http.ResponseWriter, r *htt
 	}
 
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -141,8 +137,7 @@ func (kostHandler *KostHandler) GetKostBenchmark(rw http.ResponseWriter, r *http
 	// look for the selected kost in the db to fetch all the benchmark
 	var kostBenchmark []database.DBKostBenchmark
 	if err := config.DB.Where("kost_id = ?", kostReq.ID).Find(&kostBenchmark).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -150,8 +145,7 @@ func (kostHandler *KostHandler) GetKostBenchmark(rw http.ResponseWriter, r *http
 	// parse the given instance to the response writer
 	err := data.ToJSON(kostBenchmark, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -168,8 +162,7 @@ func (kostHandler *KostHandler) GetKostAccessibility(rw http.ResponseWriter, r *
 	// look for the selected kost in the db to fetch all the accessibility
 	var kostAccess []database.DBKostAccess
 	if err := config.DB.Where("kost_id = ?", kostReq.ID).Find(&kostAccess).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -177,8 +170,7 @@ func (kostHandler *KostHandler) GetKostAccessibility(rw http.ResponseWriter, r *
 	// parse the given instance to the response writer
 	err := data.ToJSON(kostAccess, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -195,8 +187,7 @@ func (kostHandler *KostHandler) GetKostAround(rw http.ResponseWriter, r *http.Re
 	// look for the selected kost in the db to fetch all the around landmark
 	var kostAround []database.DBKostAround
 	if err := config.DB.Where("kost_id = ?", kostReq.ID).Find(&kostAround).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -204,8 +195,7 @@ func (kostHandler *KostHandler) GetKostAround(rw http.ResponseWriter, r *http.Re
 	// parse the given instance to the response writer
 	err := data.ToJSON(kostAround, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -225,8 +215,7 @@ func (kostHandler *KostHandler) GetKostReviewList(rw http.ResponseWriter, r *htt
 		Select("db_kost_reviews.id, db_kost_reviews.cleanliness,db_kost_reviews.convenience,db_kost_reviews.security,db_kost_reviews.facilities,db_kost_reviews.comments,master_users.display_name, master_users.profile_picture").
 		Joins("inner join master_users on master_users.id = db_kost_reviews.user_id").
 		Where("db_kost_reviews.kost_id = ?", kostReq.ID).Scan(&kostReview).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -234,8 +223,7 @@ func (kostHandler *KostHandler) GetKostReviewList(rw http.ResponseWriter, r *htt
 	// parse the given instance to the response writer
 	err := data.ToJSON(kostReview, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -267,8 +255,7 @@ func (kostHandler *KostHandler) GetKostOwner(rw http.ResponseWriter, r *http.Req
 			",master_users.city").
 		Joins("inner join master_users on master_users.id = db_kosts.owner_id").
 		Where("db_kosts.id = ?", kostReq.ID).Scan(&kostOwner).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -276,8 +263,7 @@ func (kostHandler *KostHandler) GetKostOwner(rw http.ResponseWriter, r *http.Req
 	// look for the selected owner kost list in the db
 	kostList, _, err := kostHandler.kost.GetKostListByOwner(kostOwner.ID, -1)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -299,8 +285,7 @@ func (kostHandler *KostHandler) GetKostOwner(rw http.ResponseWriter, r *http.Req
 	// parse the given instance to the response writer
 	err = data.ToJSON(finalKostAround, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -336,8 +321,7 @@ func (kostHandler *KostHandler) GetKostRoomList(rw http.ResponseWriter, r *http.
 		Joins("inner join master_uoms as area on area.id = db_kost_rooms.room_area_uom").
 		Joins("inner join master_uoms as price on price.id = db_kost_rooms.room_price_uom").
 		Where("db_kost_rooms.kost_id = ? AND (area.uom_type = ? AND price.uom_type = ?)", kostReq.ID, "length", "currency").Scan(&kostRoom).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -345,8 +329,7 @@ func (kostHandler *KostHandler) GetKostRoomList(rw http.ResponseWriter, r *http.
 	// parse the given instance to the response writer
 	err := data.ToJSON(kostRoom, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -361,32 +344,28 @@ func (kostHandler *KostHandler) GetKostRoomInfo(rw http.ResponseWriter, r *http.
 	vars := mux.Vars(r)
 	roomID, err := strconv.ParseUint(vars["roomId"], 10, 32)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: "Unable to convert id"}, rw)
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
 
 		return
 	}
 
 	kostRoomDetails, err := kostHandler.kost.GetKostRoomDetails(uint(roomID))
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	kostRoomPicts, err := kostHandler.kost.GetKostRoomPicts(uint(roomID))
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	kostRoomBookedList, err := kostHandler.kost.GetKostRoomBookedList(uint(roomID))
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -413,8 +392,7 @@ func (kostHandler *KostHandler) GetKostRoomInfo(rw http.ResponseWriter, r *http.
 	// parse the given instance to the response writer
 	err = data.ToJSON(kostDetailView, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -430,23 +408,20 @@ func (kostHandler *KostHandler) GetKostRoomInfoAll(rw http.ResponseWriter, r *ht
 	page, err := strconv.Atoi(vars["page"])
 	kostID, err := strconv.ParseUint(vars["id"], 10, 32)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: "Unable to convert id"}, rw)
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
 
 		return
 	}
 	kostRoomDetails, err := kostHandler.kost.GetKostRoomDetailsByKost(uint(kostID), page)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	var kostRoomDetailSum []database.DBKostRoomDetail
 	if err := config.DB.Where("kost_id = ?", kostID).Find(&kostRoomDetailSum).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -456,81 +431,14 @@ func (kostHandler *KostHandler) GetKostRoomInfoAll(rw http.ResponseWriter, r *ht
 
 		if index >= ((page * 10) - 10) {
 
-			kostRoom, err := kostHandler.kost.GetKostRoom(roomDetail.RoomID)
-			if err != nil {
-				rw.WriteHeader(http.StatusBadRequest)
-				data.ToJSON(&GenericError{Message: err.Error()}, rw)
-
-				return
-			}
-
-			currency, err := kostHandler.kost.GetUOMDesc(kostRoom.RoomPriceUOM)
-			if err != nil {
-				rw.WriteHeader(http.StatusBadRequest)
-				data.ToJSON(&GenericError{Message: err.Error()}, rw)
-
-				return
-			}
-
-			kostRoomDetailBook, err := kostHandler.kost.GetKostRoomBooked(roomDetail.ID)
+			kostRoomDetailInfo, err := kostHandler.kost.GetKostRoomDetailInfo(roomDetail)
 			if err != nil {
-				rw.WriteHeader(http.StatusBadRequest)
-				data.ToJSON(&GenericError{Message: err.Error()}, rw)
+				kostHandler.writeError(rw, r, err)
 
 				return
 			}
 
-			if kostRoomDetailBook != nil {
-				period, err := kostHandler.kost.GetMasterPeriod(kostRoomDetailBook.PeriodID)
-				if err != nil {
-					rw.WriteHeader(http.StatusBadRequest)
-					data.ToJSON(&GenericError{Message: err.Error()}, rw)
-
-					return
-				}
-
-				booker, err := kostHandler.kost.GetMasterUser(kostRoomDetailBook.BookerID)
-				if err != nil {
-					rw.WriteHeader(http.StatusBadRequest)
-					data.ToJSON(&GenericError{Message: err.Error()}, rw)
-
-					return
-				}
-
-				// TODO: status ntr diomongin lagi mau gimana
-				kostRoomDetailsFinal = append(kostRoomDetailsFinal, entities.KostRoomDetail{
-					ID:         roomDetail.ID,
-					KostID:     roomDetail.KostID,
-					RoomID:     roomDetail.RoomID,
-					RoomDesc:   kostRoom.RoomDesc,
-					RoomNumber: roomDetail.RoomNumber,
-					FloorLevel: roomDetail.FloorLevel,
-					Price:      kostRoom.RoomPrice,
-					Currency:   currency,
-					Status:     kostRoomDetailBook.Status,
-					Booker: &database.MasterUser{
-						ID:             booker.ID,
-						DisplayName:    booker.DisplayName,
-						ProfilePicture: booker.ProfilePicture,
-					},
-					PrevPayment: kostRoomDetailBook.BookDate,
-					NextPayment: kostRoomDetailBook.BookDate.AddDate(0, 0, int(period.PeriodValue)),
-					IsActive:    roomDetail.IsActive,
-				})
-			} else {
-				kostRoomDetailsFinal = append(kostRoomDetailsFinal, entities.KostRoomDetail{
-					ID:         roomDetail.ID,
-					KostID:     roomDetail.KostID,
-					RoomID:     roomDetail.RoomID,
-					RoomDesc:   kostRoom.RoomDesc,
-					RoomNumber: roomDetail.RoomNumber,
-					FloorLevel: roomDetail.FloorLevel,
-					Price:      kostRoom.RoomPrice,
-					Currency:   currency,
-					IsActive:   roomDetail.IsActive,
-				})
-			}
-
+			kostRoomDetailsFinal = append(kostRoomDetailsFinal, *kostRoomDetailInfo)
 		}
 
 	}
@@ -546,8 +454,7 @@ func (kostHandler *KostHandler) GetKostRoomInfoAll(rw http.ResponseWriter, r *ht
 	// parse the given instance to the response writer
 	err = data.ToJSON(finalData, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -565,16 +472,14 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 	vars := mux.Vars(r)
 	page, err := strconv.Atoi(vars["page"])
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Halaman tidak valid"))
 
 		return
 	}
 
 	category, err := strconv.Atoi(vars["category"])
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Kategori tidak valid"))
 
 		return
 	}
@@ -591,8 +496,7 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 	if category == 0 {
 		kostList, count, err = kostHandler.kost.GetKostList(page)
 		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
@@ -602,8 +506,7 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 			// parse the given instance to the response writer
 			err = data.ToJSON(kostList, rw)
 			if err != nil {
-				rw.WriteHeader(http.StatusBadRequest)
-				data.ToJSON(&GenericError{Message: err.Error()}, rw)
+				kostHandler.writeError(rw, r, err)
 
 				return
 			}
@@ -612,17 +515,16 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 
 		kostList, count, err = kostHandler.kost.GetNearbyKostList(userReq.Latitude, userReq.Longitude, page)
 		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
 	} else if category == 6 {
 		// get the current user login
 		var currentUser *database.MasterUser
-		currentUser, err := kostHandler.kost.GetCurrentUser(rw, r, kostHandler.store)
+		currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
 		if err != nil {
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
@@ -630,15 +532,19 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 		// look for the current kost list in the db
 		kostList, count, err = kostHandler.kost.GetKostListByOwner(currentUser.ID, page)
 		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
 	}
 
-	// Sort by distance, keeping original order or equal elements.
+	// Sort the boosted kost first by its up rate, the rest by id, keeping original order or equal elements.
 	sort.SliceStable(kostList, func(i, j int) bool {
+		upRateI, upRateJ := data.ActiveUpRate(&kostList[i]), data.ActiveUpRate(&kostList[j])
+		if upRateI != upRateJ {
+			return upRateI > upRateJ
+		}
+
 		return kostList[i].ID < kostList[j].ID
 	})
 
@@ -662,16 +568,14 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 			// get the facilities from the method
 			kostFacilities, _, err := kostHandler.kost.GetKostFacilities(kost.ID, "")
 			if err != nil {
-				rw.WriteHeader(http.StatusBadRequest)
-				data.ToJSON(&GenericError{Message: err.Error()}, rw)
+				kostHandler.writeError(rw, r, err)
 
 				return
 			}
 
 			lowestPrice, err := kostHandler.kost.GetLowestPrice(kost.ID)
 			if err != nil {
-				rw.WriteHeader(http.StatusBadRequest)
-				data.ToJSON(&GenericError{Message: err.Error()}, rw)
+				kostHandler.writeError(rw, r, err)
 
 				return
 			}
@@ -692,8 +596,7 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 		KostCount: count,
 	}, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -701,23 +604,70 @@ func (kostHandler *KostHandler) GetKostList(rw http.ResponseWriter, r *http.Requ
 	return
 }
 
-// GetEventList is a method to fetch the list of application event
+// GetEventList is a method to fetch the list of application event,
+// the running events are returned by default while the upcoming and past events can be requested via the mode query
 func (kostHandler *KostHandler) GetEventList(rw http.ResponseWriter, r *http.Request) {
 
-	// look for the current kost list in the db
-	var eventList []database.MasterEvent
-	if err := config.DB.Where("is_active = ?", true).Find(&eventList).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "running"
+	}
+
+	if !data.EventListModes[mode] {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Mode event tidak valid, gunakan running, upcoming atau past"))
+
+		return
+	}
+
+	// look for the event list of the given mode in the db
+	eventList, err := kostHandler.kost.GetEventList(mode)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(eventList, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// GetEventKostList is a method to fetch the list of kost enrolled to the given event
+func (kostHandler *KostHandler) GetEventKostList(rw http.ResponseWriter, r *http.Request) {
+
+	// get the event id and the page via mux
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	page, err := strconv.Atoi(vars["page"])
+	if err != nil || page < 1 {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Halaman tidak valid"))
+
+		return
+	}
+
+	eventKostList, err := kostHandler.kost.GetEventKostList(uint(eventID), page)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	// parse the given instance to the response writer
-	err := data.ToJSON(eventList, rw)
+	err = data.ToJSON(eventKostList, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -744,8 +694,7 @@ func (kostHandler *KostHandler) GetNearYouList(rw http.ResponseWriter, r *http.R
 	// for this request, the page will always 2
 	listNearbyKosts, _, err := kostHandler.kost.GetNearbyKostList(userReq.Latitude, userReq.Longitude, 2)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -757,8 +706,7 @@ func (kostHandler *KostHandler) GetNearYouList(rw http.ResponseWriter, r *http.R
 
 		lowestPrice, err := kostHandler.kost.GetLowestPrice(nearby.ID)
 		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
@@ -778,8 +726,7 @@ func (kostHandler *KostHandler) GetNearYouList(rw http.ResponseWriter, r *http.R
 	// parse the given instance to the response writer
 	err = data.ToJSON(finalNearbyKostList, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -787,46 +734,383 @@ func (kostHandler *KostHandler) GetNearYouList(rw http.ResponseWriter, r *http.R
 	return
 }
 
-// GetKostInstagramAdsList is a method to fetch the given kost Instagram ads list
-func (kostHandler *KostHandler) GetKostInstagramAdsList(rw http.ResponseWriter, r *http.Request) {
+// AdminGetAdsCalendar is a method to fetch the weekly ads posting calendar by the admin
+func (kostHandler *KostHandler) AdminGetAdsCalendar(rw http.ResponseWriter, r *http.Request) {
 
-	// get the kost via context
-	//kostReq := r.Context().Value(KeyKostAds{}).(*entities.KostAds)
+	// get the week from the query, the current week is used by default
+	week := time.Now()
+	if r.URL.Query().Get("week") != "" {
+		var err error
+		week, err = time.ParseInLocation("2006-01-02", r.URL.Query().Get("week"), time.Local)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Format minggu tidak valid, gunakan format YYYY-MM-DD"))
 
-	var kostAds []entities.KostAds
+			return
+		}
+	}
 
-	if err := config.DB.
-		Model(&database.DBKostAds{}).
-		Select("db_kost_ads.id"+
-			",db_kost_ads.status "+
-			",db_kost_ads.ads_code"+
-			",db_kost_ads.ads_type"+
-			",db_kost_ads.ads_kost_type"+
-			",db_kost_ads.ads_owner"+
-			",db_kost_ads.ads_owner_ig"+
-			",db_kost_ads.ads_phone_number"+
-			",db_kost_ads.ads_pic_whatsapp"+
-			",db_kost_ads.ads_property_address"+
-			",db_kost_ads.ads_property_city"+
-			",db_kost_ads.ads_property_price"+
-			",db_kost_ads.ads_desc"+
-			",db_kost_ads.ads_gender"+
-			",db_kost_ads.ads_pet_allowed"+
-			",db_kost_ads.ads_post_schedule_request"+
-			",db_kost_ads.ads_hashtag"+
-			",db_kost_ads.is_active").
-		Where("db_kost_ads.ads_type != ? AND db_kost_ads.ads_type != ?", "Iklan Tiktok Free", "Iklan Tiktok Premium (Rekomendasi)").Scan(&kostAds).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	calendar, err := kostHandler.kost.GetAdsCalendar(week)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(calendar, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// AdminGetAdsCaption is a method to preview the ready to post captions of the given ads by the admin
+func (kostHandler *KostHandler) AdminGetAdsCaption(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads id via mux
+	vars := mux.Vars(r)
+	adsID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// render the captions of the requested channel, use ?channel=all to preview every channel
+	captions, err := kostHandler.kost.GetAdsCaptions(uint(adsID), r.URL.Query().Get("channel"))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(captions, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// AdminExportAdsCaption is a method to download the ready to post caption of the given ads as a text file by the admin
+func (kostHandler *KostHandler) AdminExportAdsCaption(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads id via mux
+	vars := mux.Vars(r)
+	adsID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// the export is a single caption, so every channel can't be exported at once
+	channel := r.URL.Query().Get("channel")
+	if channel == "all" {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Pilih satu channel untuk export caption"))
+
+		return
+	}
+
+	captions, err := kostHandler.kost.GetAdsCaptions(uint(adsID), channel)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	caption := captions[0]
+	fileName := fmt.Sprintf("caption-%s-%s.txt", data.AdsCodeFileName(caption.AdsCode), caption.Channel)
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	rw.WriteHeader(http.StatusOK)
+	io.WriteString(rw, caption.Caption)
+}
+
+// AdminDownloadAdsBundle is a method to stream the zip bundle of the given ads media, caption and metadata by the admin
+func (kostHandler *KostHandler) AdminDownloadAdsBundle(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads id via mux
+	vars := mux.Vars(r)
+	adsID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	bundle, err := kostHandler.kost.GetAdsBundle(uint(adsID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	fileName := fmt.Sprintf("ads-%s.zip", data.AdsCodeFileName(bundle.Ads.AdsCode))
+
+	// the bundle can take longer than the server write timeout to stream
+	kostHandler.extendWriteDeadline(rw, r)
+
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	rw.WriteHeader(http.StatusOK)
+
+	// the response is already started, so the streaming error can only be logged
+	if err = kostHandler.kost.WriteAdsBundle(rw, bundle); err != nil {
+		kostHandler.logger.Error("Unable to stream the ads bundle", "ads_id", adsID, "error", err.Error())
+	}
+}
+
+// ExportKostData is a method to download the given kost, room or booking dataset as csv or xlsx,
+// the ?format= query picks the file format, the ?columns= query picks the comma separated columns,
+// the ?from= and ?to= query limit the dates using the YYYY-MM-DD format and the ?owner_id= query picks the owner for the admin
+func (kostHandler *KostHandler) ExportKostData(rw http.ResponseWriter, r *http.Request) {
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &entities.KostExportFilter{
+		Dataset: mux.Vars(r)["dataset"],
+		Format:  strings.ToLower(query.Get("format")),
+	}
+
+	for _, column := range strings.Split(query.Get("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			filter.Columns = append(filter.Columns, column)
+		}
+	}
+
+	if query.Get("owner_id") != "" {
+		ownerID, err := strconv.ParseUint(query.Get("owner_id"), 10, 32)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+			return
+		}
+
+		filter.OwnerID = uint(ownerID)
+	}
+
+	// get the optional date range from the query
+	var dateRange [2]*time.Time
+	for i, key := range []string{"from", "to"} {
+		if value := query.Get(key); value != "" {
+			date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Format tanggal tidak valid, gunakan format YYYY-MM-DD"))
+
+				return
+			}
+
+			dateRange[i] = &date
+		}
+	}
+
+	filter.From, filter.To = dateRange[0], dateRange[1]
+
+	export, err := kostHandler.kost.ExportKostData(currentUser, filter)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the rows are streamed as they are read, which can take longer than the server write timeout
+	kostHandler.extendWriteDeadline(rw, r)
+
+	rw.Header().Set("Content-Type", export.ContentType())
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.FileName(time.Now())}))
+	rw.WriteHeader(http.StatusOK)
+
+	// the response is already started, so the writing error can only be logged
+	if err = export.Write(rw); err != nil {
+		kostHandler.logger.Error("Unable to write the kost export", "dataset", filter.Dataset, "error", err.Error())
+	}
+}
+
+// GetKostImportTemplate is a method to download the csv template of the kost import with a sample row
+func (kostHandler *KostHandler) GetKostImportTemplate(rw http.ResponseWriter, r *http.Request) {
+
+	rw.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "kost-import-template.csv"}))
+	rw.WriteHeader(http.StatusOK)
+
+	// the response is already started, so the writing error can only be logged
+	if err := data.KostImportTemplateCSV(rw); err != nil {
+		kostHandler.logger.Error("Unable to write the kost import template", "error", err.Error())
+	}
+}
+
+// AdminGetAdsMetricReport is a method to fetch the performance report of the given ads by the admin
+func (kostHandler *KostHandler) AdminGetAdsMetricReport(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads id via mux
+	vars := mux.Vars(r)
+	adsID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	report, err := kostHandler.kost.GetAdsMetricReport(uint(adsID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(report, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// AdminGetAdsPackageMetricReport is a method to fetch the performance report of the given ads package by the admin,
+// the ?from= and ?to= query limit the metric dates using the YYYY-MM-DD format
+func (kostHandler *KostHandler) AdminGetAdsPackageMetricReport(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads package id via mux
+	vars := mux.Vars(r)
+	packageID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the optional metric date range from the query
+	var dateRange [2]*time.Time
+	for i, key := range []string{"from", "to"} {
+		if value := r.URL.Query().Get(key); value != "" {
+			date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Format tanggal tidak valid, gunakan format YYYY-MM-DD"))
+
+				return
+			}
+
+			dateRange[i] = &date
+		}
+	}
+
+	report, err := kostHandler.kost.GetAdsPackageMetricReport(uint(packageID), dateRange[0], dateRange[1])
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(report, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// GetAdsChallenge is a method to issue a proof of work challenge that must be solved before submitting an ads
+func (kostHandler *KostHandler) GetAdsChallenge(rw http.ResponseWriter, r *http.Request) {
+
+	challenge, err := kostHandler.kost.NewAdsChallenge()
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(challenge, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// GetBoostPackageList is a method to fetch the kost boost package catalogue
+func (kostHandler *KostHandler) GetBoostPackageList(rw http.ResponseWriter, r *http.Request) {
+
+	// look for the active boost packages in the db
+	boostPackages, err := kostHandler.kost.GetBoostPackageList(false)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(boostPackages, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// GetAdsPackageList is a method to fetch the ads package catalogue
+func (kostHandler *KostHandler) GetAdsPackageList(rw http.ResponseWriter, r *http.Request) {
+
+	// look for the active ads packages in the db
+	adsPackages, err := kostHandler.kost.GetAdsPackageList(false)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(adsPackages, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// AdminGetAdsPackageList is a method to fetch the whole ads package catalogue, including the inactive packages, by the admin
+func (kostHandler *KostHandler) AdminGetAdsPackageList(rw http.ResponseWriter, r *http.Request) {
+
+	// look for the ads packages in the db
+	adsPackages, err := kostHandler.kost.GetAdsPackageList(true)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	// parse the given instance to the response writer
-	err := data.ToJSON(kostAds, rw)
+	err = data.ToJSON(adsPackages, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -834,46 +1118,86 @@ func (kostHandler *KostHandler) GetKostInstagramAdsList(rw http.ResponseWriter,
 	return
 }
 
-// GetKostTiktokAdsList is a method to fetch the given kost Tiktok ads list
+// AdminGetKostBoostList is a method to fetch the kost boosts of the ?status= query, the pending ones by default, by the admin
+func (kostHandler *KostHandler) AdminGetKostBoostList(rw http.ResponseWriter, r *http.Request) {
+
+	status := data.KostBoostStatusPending
+	if r.URL.Query().Get("status") != "" {
+		var err error
+		status, err = data.ParseKostBoostStatus(r.URL.Query().Get("status"))
+		if err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+	}
+
+	// look for the kost boosts in the db
+	kostBoosts, err := kostHandler.kost.GetKostBoostList(status)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(kostBoosts, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// GetKostInstagramAdsList is a method to fetch the Instagram ads list shown to the public
+func (kostHandler *KostHandler) GetKostInstagramAdsList(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.writeKostAdsList(rw, r, []string{data.AdsChannelIGFeed, data.AdsChannelIGStory}, true)
+}
+
+// AdminGetKostInstagramAdsList is a method to fetch the Instagram ads list filtered by the ?status= query by the admin
+func (kostHandler *KostHandler) AdminGetKostInstagramAdsList(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.writeKostAdsList(rw, r, []string{data.AdsChannelIGFeed, data.AdsChannelIGStory}, false)
+}
+
+// GetKostTiktokAdsList is a method to fetch the Tiktok ads list shown to the public
 func (kostHandler *KostHandler) GetKostTiktokAdsList(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.writeKostAdsList(rw, r, []string{data.AdsChannelTiktok}, true)
+}
 
-	// get the kost via context
-	//kostReq := r.Context().Value(KeyKostAds{}).(*entities.KostAds)
+// AdminGetKostTiktokAdsList is a method to fetch the Tiktok ads list filtered by the ?status= query by the admin
+func (kostHandler *KostHandler) AdminGetKostTiktokAdsList(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.writeKostAdsList(rw, r, []string{data.AdsChannelTiktok}, false)
+}
 
-	var kostAds []entities.KostAds
+// writeKostAdsList writes the ads list of the given channels, the public list only shows the public ads
+// while the admin list is filtered by the ?status= query and shows the ads that are ready to be posted by default
+func (kostHandler *KostHandler) writeKostAdsList(rw http.ResponseWriter, r *http.Request, channels []string, public bool) {
 
-	if err := config.DB.
-		Model(&database.DBKostAds{}).
-		Select("db_kost_ads.id"+
-			",db_kost_ads.status "+
-			",db_kost_ads.ads_code"+
-			",db_kost_ads.ads_type"+
-			",db_kost_ads.ads_kost_type"+
-			",db_kost_ads.ads_owner"+
-			",db_kost_ads.ads_owner_ig"+
-			",db_kost_ads.ads_phone_number"+
-			",db_kost_ads.ads_pic_whatsapp"+
-			",db_kost_ads.ads_property_address"+
-			",db_kost_ads.ads_property_city"+
-			",db_kost_ads.ads_property_price"+
-			",db_kost_ads.ads_desc"+
-			",db_kost_ads.ads_gender"+
-			",db_kost_ads.ads_pet_allowed"+
-			",db_kost_ads.ads_post_schedule_request"+
-			",db_kost_ads.ads_hashtag"+
-			",db_kost_ads.is_active").
-		Where("db_kost_ads.ads_type = ? OR db_kost_ads.ads_type = ?", "Iklan Tiktok Free", "Iklan Tiktok Premium (Rekomendasi)").Scan(&kostAds).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	statuses := data.PublicAdsStatuses
+	if !public && r.URL.Query().Get("status") != "" {
+		var err error
+		statuses, err = data.ParseAdsStatusList(r.URL.Query().Get("status"))
+		if err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+	}
+
+	// the channel of the ads is derived from its package in the catalogue
+	kostAds, err := kostHandler.kost.GetKostAdsListByChannels(channels, statuses)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	// parse the given instance to the response writer
-	err := data.ToJSON(kostAds, rw)
+	err = data.ToJSON(kostAds, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -881,57 +1205,280 @@ func (kostHandler *KostHandler) GetKostTiktokAdsList(rw http.ResponseWriter, r *
 	return
 }
 
-// GetKostAdsFileList is a method to fetch the given kost Instagram ads file list
+// GetKostAdsFileList is a method to fetch the given kost ads file list of the ads shown to the public
 func (kostHandler *KostHandler) GetKostAdsFileList(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.writeKostAdsFileList(rw, r, true)
+}
 
-	// get the kost via context
-	//kostReq := r.Context().Value(KeyKostAds{}).(*entities.KostAds)
+// AdminGetKostAdsFileList is a method to fetch the given kost ads file list of any ads by the admin reviewing it
+func (kostHandler *KostHandler) AdminGetKostAdsFileList(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.writeKostAdsFileList(rw, r, false)
+}
+
+// writeKostAdsFileList writes the given kost ads file list, the public list only shows the files of the public ads
+func (kostHandler *KostHandler) writeKostAdsFileList(rw http.ResponseWriter, r *http.Request, public bool) {
 
 	// get the id via mux
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
 
 		return
 	}
 
-	var kostAdsFiles []database.DBKostAdsFiles
+	if public {
+		if err := kostHandler.kost.RequirePublicAds(uint(id)); err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+	}
+
+	var kostAdsFiles []entities.KostAdsFiles
 
 	if err = config.DB.
 		Model(&database.DBKostAdsFiles{}).
 		Select("db_kost_ads_files.id"+
 			",db_kost_ads_files.ads_id "+
 			",db_kost_ads_files.ads_file_type"+
-			",db_kost_ads_files.ads_dir_path"+
+			",db_kost_ads_files.ads_file_name"+
+			",db_kost_ads_files.content_type"+
+			",db_kost_ads_files.file_size"+
+			",db_kost_ads_files.checksum"+
 			",db_kost_ads_files.is_active").
 		Where("db_kost_ads_files.ads_id = ?", id).Scan(&kostAdsFiles).Error; err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
-	// add the kost id to the slices
+	// each file is downloaded by its own url instead of being embedded in the list, the admin downloads it by the review url
 	for i := range kostAdsFiles {
+		(&kostAdsFiles[i]).DownloadURL = "/ads/" + strconv.Itoa(id) + "/files/" + strconv.FormatUint(uint64((&kostAdsFiles[i]).ID), 10)
+		if !public {
+			(&kostAdsFiles[i]).DownloadURL += "/review"
+		}
+	}
 
-		body, err := ioutil.ReadFile((&kostAdsFiles[i]).AdsDirPath)
-		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	// parse the given instance to the response writer
+	err = data.ToJSON(kostAdsFiles, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// DownloadKostAdsFile is a method to stream the given kost ads file of the ads shown to the public, range requests are supported
+func (kostHandler *KostHandler) DownloadKostAdsFile(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.serveKostAdsFile(rw, r, true)
+}
+
+// AdminDownloadKostAdsFile is a method to stream the given kost ads file of any ads by the admin reviewing it
+func (kostHandler *KostHandler) AdminDownloadKostAdsFile(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.serveKostAdsFile(rw, r, false)
+}
+
+// serveKostAdsFile streams the given kost ads file, the public download only serves the files of the public ads
+func (kostHandler *KostHandler) serveKostAdsFile(rw http.ResponseWriter, r *http.Request, public bool) {
+
+	// get the ads id and the file id via mux
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	fileID, err := strconv.Atoi(vars["fileId"])
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID file tidak valid"))
+
+		return
+	}
+
+	if public {
+		if err := kostHandler.kost.RequirePublicAds(uint(id)); err != nil {
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
+	}
+
+	// look for the target ads file in the db
+	var kostAdsFile database.DBKostAdsFiles
+	if err := config.DB.Where("id = ? AND ads_id = ?", fileID, id).First(&kostAdsFile).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// open the ads file content from the storage
+	object, modified, err := kostHandler.kost.OpenAdsFile(&kostAdsFile)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	defer object.Close()
+
+	if kostAdsFile.ContentType != "" {
+		rw.Header().Set("Content-Type", kostAdsFile.ContentType)
+	}
+
+	if kostAdsFile.Checksum != "" {
+		rw.Header().Set("ETag", `"`+kostAdsFile.Checksum+`"`)
+	}
+
+	// the legacy ads files don't have a file name, use the file id instead
+	fileName := kostAdsFile.AdsFileName
+	if fileName == "" {
+		fileName = strconv.Itoa(fileID)
+	}
+
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+
+	// the photos and videos can take longer than the server write timeout to stream
+	kostHandler.extendWriteDeadline(rw, r)
 
-		(&kostAdsFiles[i]).BASE64STRING = string(body)
+	// serve the content, this handles the Range and the conditional request headers
+	http.ServeContent(rw, r, fileName, modified, object)
+}
+
+// AdminGetVoucherList is a method to fetch the list of every voucher including the inactive ones by the admin
+func (kostHandler *KostHandler) AdminGetVoucherList(rw http.ResponseWriter, r *http.Request) {
+
+	// look for the vouchers in the db
+	vouchers, err := kostHandler.kost.GetVoucherList(true)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
 	}
 
 	// parse the given instance to the response writer
-	err = data.ToJSON(kostAdsFiles, rw)
+	err = data.ToJSON(vouchers, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// GetMasterDataList is a method to fetch the active list of the given master data kind
+func (kostHandler *KostHandler) GetMasterDataList(rw http.ResponseWriter, r *http.Request) {
+
+	// get the master data kind via mux
+	vars := mux.Vars(r)
+
+	// look for the active master data in the cache or the db
+	masterDataList, err := kostHandler.kost.GetMasterDataList(vars["kind"], false)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(masterDataList, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// AdminGetMasterDataList is a method to fetch the list of the given master data kind including the inactive ones by the admin
+func (kostHandler *KostHandler) AdminGetMasterDataList(rw http.ResponseWriter, r *http.Request) {
+
+	// get the master data kind via mux
+	vars := mux.Vars(r)
+
+	// look for the master data in the db
+	masterDataList, err := kostHandler.kost.GetMasterDataList(vars["kind"], true)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(masterDataList, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// GetKostCompleteness is a method to fetch the completeness score and the checklist of the kost by the owner
+func (kostHandler *KostHandler) GetKostCompleteness(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can see its checklist
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	completeness, err := kostHandler.kost.GetKostCompleteness(targetKost, data.GetLanguage(r))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(completeness, rw)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	return
+}
+
+// AdminGetAPIKeyList is a method to fetch the issued api key list by the admin
+func (kostHandler *KostHandler) AdminGetAPIKeyList(rw http.ResponseWriter, r *http.Request) {
+
+	// the revoked keys are only listed on request
+	includeRevoked := r.URL.Query().Get("revoked") == "true"
+
+	// look for the api keys in the db
+	apiKeys, err := kostHandler.kost.GetAPIKeyList(includeRevoked)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the given instance to the response writer
+	err = data.ToJSON(apiKeys, rw)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -1,7 +1,15 @@
 package handlers
 
 import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fakhripraya/kost-service/config"
 	"github.com/fakhripraya/kost-service/data"
+	"github.com/fakhripraya/kost-service/database"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/srinathgs/mysqlstore"
@@ -19,19 +27,223 @@ type KeyApproval struct{}
 // KeyUser is a key used for the User object in the context
 type KeyUser struct{}
 
+// KeyPictOrder is a key used for the Pict Order object in the context
+type KeyPictOrder struct{}
+
+// KeyPictCaption is a key used for the Pict Caption object in the context
+type KeyPictCaption struct{}
+
+// KeyAdsStatus is a key used for the Ads Status Transition object in the context
+type KeyAdsStatus struct{}
+
+// KeyAdsSchedule is a key used for the Ads Schedule object in the context
+type KeyAdsSchedule struct{}
+
+// KeyAdsPackage is a key used for the Ads Package object in the context
+type KeyAdsPackage struct{}
+
+// KeyBoostPackage is a key used for the Boost Package object in the context
+type KeyBoostPackage struct{}
+
+// KeyKostBoost is a key used for the Kost Boost object in the context
+type KeyKostBoost struct{}
+
+// KeyEvent is a key used for the Event object in the context
+type KeyEvent struct{}
+
+// KeyEventEnrollment is a key used for the Event Enrollment object in the context
+type KeyEventEnrollment struct{}
+
+// KeyVoucher is a key used for the Voucher object in the context
+type KeyVoucher struct{}
+
+// KeyRoomBook is a key used for the Room Book object in the context
+type KeyRoomBook struct{}
+
+// KeyMasterData is a key used for the Master Data object in the context
+type KeyMasterData struct{}
+
+// KeyAPIKey is a key used for the API Key object in the context
+type KeyAPIKey struct{}
+
 // KostHandler is a handler struct for kost changes
 type KostHandler struct {
-	logger hclog.Logger
-	kost   *data.Kost
-	store  *mysqlstore.MySQLStore
+	logger         hclog.Logger
+	kost           *data.Kost
+	store          *mysqlstore.MySQLStore
+	rateLimitStore data.RateLimitStore
 }
 
 // NewKostHandler returns a new Kost handler with the given logger
 func NewKostHandler(newLogger hclog.Logger, newKost *data.Kost, newStore *mysqlstore.MySQLStore) *KostHandler {
-	return &KostHandler{newLogger, newKost, newStore}
+	return &KostHandler{
+		logger:         newLogger,
+		kost:           newKost,
+		store:          newStore,
+		rateLimitStore: data.NewMemoryRateLimitStore(),
+	}
+}
+
+// SetRateLimitStore replaces the in memory rate limit store, e.g. with a shared store when running multiple instances
+func (kostHandler *KostHandler) SetRateLimitStore(store data.RateLimitStore) {
+	kostHandler.rateLimitStore = store
 }
 
-// GenericError is a generic error message returned by a server
+// GenericError is a generic message returned by a server, the code is only set on the errors
+// and the fields are only set on the validation errors
 type GenericError struct {
+	Code    data.ErrorCode `json:"code,omitempty"`
+	Message string         `json:"message"`
+	Fields  []FieldError   `json:"fields,omitempty"`
+}
+
+// FieldError is the error message of a single invalid request payload field returned by a server
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
+
+// writeError writes the given error to the response writer in the language accepted by the client,
+// the internal errors are logged and only a generic message is returned so the sql errors never leak
+func (kostHandler *KostHandler) writeError(rw http.ResponseWriter, r *http.Request, err error) {
+
+	appErr := data.ToAppError(err)
+	if appErr.Status >= http.StatusInternalServerError {
+		kostHandler.logger.Error("Unable to process the request", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	}
+
+	language := data.GetLanguage(r)
+	genericError := &GenericError{Code: appErr.Code, Message: appErr.Localize(language)}
+	for _, fieldErr := range appErr.Fields {
+		genericError.Fields = append(genericError.Fields, FieldError{Field: fieldErr.Field, Message: fieldErr.Localize(language)})
+	}
+
+	rw.WriteHeader(appErr.Status)
+	data.ToJSON(genericError, rw)
+}
+
+// getOwnedKost looks for the given kost and makes sure it is owned by the given user,
+// writing the error response to the response writer if it is not
+func (kostHandler *KostHandler) getOwnedKost(rw http.ResponseWriter, r *http.Request, kostID uint, currentUser *database.MasterUser) (*database.DBKost, bool) {
+
+	// look for the target kost in the db
+	var targetKost database.DBKost
+	if err := config.DB.Where("id = ?", kostID).First(&targetKost).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return nil, false
+	}
+
+	// only the kost owner can manage the kost
+	if targetKost.OwnerID != currentUser.ID {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeForbidden, "Hanya pemilik kost yang bisa mengubah kost ini"))
+
+		return nil, false
+	}
+
+	return &targetKost, true
+}
+
+// getRateLimitKey gets the identity the request is rate limited by, the api key or the user when the request
+// is already authenticated and the client ip otherwise
+func getRateLimitKey(r *http.Request) string {
+
+	if apiKey := data.APIKeyFromContext(r.Context()); apiKey != nil {
+		return "key:" + apiKey.KeyID
+	}
+
+	if claims := data.ClaimsFromContext(r.Context()); claims != nil && claims.Username != "" {
+		return "user:" + claims.Username
+	}
+
+	return "ip:" + getClientIP(r)
+}
+
+// getClientIP gets the ip address of the client, the X-Forwarded-For header is only read when the request comes from
+// a trusted proxy, its entries are read from the last one as each proxy appends the address it got the request from,
+// so the first entry that is not a trusted proxy is the client and the entries before it can't be trusted
+func getClientIP(r *http.Request) string {
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !data.IsTrustedProxy(host) {
+		return host
+	}
+
+	forwardedFor := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+
+		address := strings.TrimSpace(forwardedFor[i])
+		if address == "" {
+			continue
+		}
+
+		host = address
+		if !data.IsTrustedProxy(address) {
+			break
+		}
+	}
+
+	return host
+}
+
+// maxDownloadDuration is how long a streamed download can take, the server write timeout is too short for the large files
+const maxDownloadDuration = 10 * time.Minute
+
+// maxUploadDuration is how long a large upload can take to arrive, the server read timeout is too short for the large files
+const maxUploadDuration = 5 * time.Minute
+
+// extendReadDeadline extends the read deadline of the given large upload past the server read timeout,
+// so the slow client doesn't end up with a truncated body
+func (kostHandler *KostHandler) extendReadDeadline(rw http.ResponseWriter, r *http.Request) {
+
+	if err := http.NewResponseController(rw).SetReadDeadline(time.Now().Add(maxUploadDuration)); err != nil {
+		kostHandler.logger.Warn("Unable to extend the read deadline", "path", r.URL.Path, "error", err.Error())
+	}
+}
+
+// extendWriteDeadline extends the write deadline of the given streamed download past the server write timeout,
+// so the large download is not cut off in the middle
+func (kostHandler *KostHandler) extendWriteDeadline(rw http.ResponseWriter, r *http.Request) {
+
+	if err := http.NewResponseController(rw).SetWriteDeadline(time.Now().Add(maxDownloadDuration)); err != nil {
+		kostHandler.logger.Warn("Unable to extend the write deadline", "path", r.URL.Path, "error", err.Error())
+	}
+}
+
+// pictFileSystem is the directory of the pict variants, the directories are reported as not found so they are never listed
+type pictFileSystem struct {
+	http.FileSystem
+}
+
+// Open opens the given pict variant file, refusing the directories
+func (fileSystem pictFileSystem) Open(name string) (http.File, error) {
+
+	file, err := fileSystem.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	if info.IsDir() {
+		file.Close()
+
+		return nil, os.ErrNotExist
+	}
+
+	return file, nil
+}
+
+// NewPictFileServer returns the handler serving the pict variant files stored in the given directory without listing it
+func NewPictFileServer(dirPath string) http.Handler {
+	return http.FileServer(pictFileSystem{http.Dir(dirPath)})
+}
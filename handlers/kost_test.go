@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fakhripraya/kost-service/data"
+)
+
+func TestGetRateLimitKeyIgnoresForgedForwardedFor(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   []string
+		want           string
+	}{
+		{
+			name:       "no forwarded for",
+			remoteAddr: "203.0.113.7:51234",
+			want:       "ip:203.0.113.7",
+		},
+		{
+			name:         "forged forwarded for without a trusted proxy",
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "ip:203.0.113.7",
+		},
+		{
+			name:           "forged forwarded for from an untrusted address",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "203.0.113.7:51234",
+			forwardedFor:   []string{"198.51.100.1"},
+			want:           "ip:203.0.113.7",
+		},
+		{
+			name:           "client address appended by the trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:51234",
+			forwardedFor:   []string{"203.0.113.7"},
+			want:           "ip:203.0.113.7",
+		},
+		{
+			name:           "forged entry before the client address appended by the trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:51234",
+			forwardedFor:   []string{"198.51.100.1, 203.0.113.7"},
+			want:           "ip:203.0.113.7",
+		},
+		{
+			name:           "forged entry in a separate header before the chain of trusted proxies",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:51234",
+			forwardedFor:   []string{"198.51.100.1", "203.0.113.7, 10.0.0.3"},
+			want:           "ip:203.0.113.7",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			if err := data.SetTrustedProxies(test.trustedProxies); err != nil {
+				t.Fatalf("SetTrustedProxies() error = %v", err)
+			}
+			defer data.SetTrustedProxies(nil)
+
+			r := httptest.NewRequest("GET", "/kost/all", nil)
+			r.RemoteAddr = test.remoteAddr
+			for _, forwardedFor := range test.forwardedFor {
+				r.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+
+			if got := getRateLimitKey(r); got != test.want {
+				t.Errorf("getRateLimitKey() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -2,104 +2,291 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"math"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/fakhripraya/kost-service/data"
 	"github.com/fakhripraya/kost-service/entities"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/sessions"
 )
 
-// MiddlewareValidateAuth validates the request and calls next if ok
+// maxAdsMultipartMemory is the max size of the ads multipart form kept in memory (32 MB)
+const maxAdsMultipartMemory = 32 << 20
+
+// maxAdsMetricImportSize is the max size of the ads metric csv import (10 MB)
+const maxAdsMetricImportSize = 10 << 20
+
+// maxKostImportSize is the max size of the kost csv or xlsx import (10 MB)
+const maxKostImportSize = 10 << 20
+
+// MiddlewareValidateAuth validates the request and calls next if ok,
+// the token is read from the bearer authorization header or else from the session
 func (kostHandler *KostHandler) MiddlewareValidateAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 
+		// the bearer token is used by the mobile clients and the other services, it is never kept in the session
+		if bearerToken := data.GetBearerToken(r); bearerToken != "" {
+
+			claims, err := data.ParseToken(bearerToken)
+			if err != nil {
+				kostHandler.writeError(rw, r, err)
+
+				return
+			}
+
+			// the access token of a logged out session is rejected before it expires
+			if claims.SessionID != "" && data.IsAuthSessionRevoked(claims.SessionID) {
+				kostHandler.writeError(rw, r, data.NewError(data.ErrCodeUnauthorized, "Sesi sudah berakhir, silakan login kembali"))
+
+				return
+			}
+
+			// add the claims to the context so the current user can be resolved from it
+			next.ServeHTTP(rw, r.WithContext(data.WithClaims(r.Context(), claims)))
+
+			return
+		}
+
 		// Get a session (existing/new)
 		session, err := kostHandler.store.Get(r, "session-name")
 		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
 
 		// check the token from the session
 		// if token available, get the token from the session
-		if session.Values["token"] == nil {
-			rw.WriteHeader(http.StatusUnauthorized)
+		tokenString, _ := session.Values["token"].(string)
+		if tokenString == "" {
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeUnauthorized, "Token tidak valid"))
+
 			return
 		}
 
-		// determine the cookie value that holds the token
-		tokenString := session.Values["token"].(string)
+		claims, err := data.ParseToken(tokenString)
+		if err != nil {
+
+			// the expired access token is renewed with the refresh token kept in the session
+			refreshToken, _ := session.Values["refreshToken"].(string)
+			if !data.IsTokenExpired(err) || refreshToken == "" {
+				kostHandler.writeError(rw, r, err)
+
+				return
+			}
+
+			authToken, refreshedClaims, err := kostHandler.kost.RefreshAuthSession(refreshToken)
+			if err != nil {
+				kostHandler.writeError(rw, r, err)
 
-		if tokenString != "" {
+				return
+			}
 
-			// Initialize a new instance of claims
-			claims := &data.Claims{}
+			if err := kostHandler.saveAuthToken(rw, r, session, authToken, refreshedClaims); err != nil {
+				kostHandler.writeError(rw, r, err)
 
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("Error while parsing the token with claims")
-				}
+				return
+			}
 
-				return []byte(data.MySigningKey), nil
-			})
+			claims = refreshedClaims
+		} else if claims.SessionID == "" {
 
+			// the token issued before the auth sessions is upgraded once to a session with a refresh token
+			currentUser, err := kostHandler.kost.GetCurrentUser(r.WithContext(data.WithClaims(r.Context(), claims)), kostHandler.store)
 			if err != nil {
-				if err == jwt.ErrSignatureInvalid {
-					rw.WriteHeader(http.StatusUnauthorized)
-					data.ToJSON(&GenericError{Message: err.Error()}, rw)
+				kostHandler.writeError(rw, r, err)
 
-					return
-				}
+				return
+			}
+
+			authToken, sessionClaims, err := kostHandler.kost.IssueAuthSession(currentUser)
+			if err != nil {
+				kostHandler.writeError(rw, r, err)
+
+				return
+			}
 
-				rw.WriteHeader(http.StatusBadRequest)
-				data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			if err := kostHandler.saveAuthToken(rw, r, session, authToken, sessionClaims); err != nil {
+				kostHandler.writeError(rw, r, err)
 
 				return
 			}
 
-			if token.Valid {
+			claims = sessionClaims
+		} else if data.IsAuthSessionRevoked(claims.SessionID) {
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeUnauthorized, "Sesi sudah berakhir, silakan login kembali"))
 
-				// create a new token for the current use, with a renewed expiration time
-				expirationTime := time.Now().Add(time.Second * 86400 * 7)
-				claims.StandardClaims.ExpiresAt = expirationTime.Unix()
-				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-				tokenString, err := token.SignedString([]byte(data.MySigningKey))
+			return
+		}
 
-				if err != nil {
-					rw.WriteHeader(http.StatusInternalServerError)
-					data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		// add the claims to the context so the current user can be resolved from it
+		next.ServeHTTP(rw, r.WithContext(data.WithClaims(r.Context(), claims)))
+	})
+}
 
-					return
-				}
+// saveAuthToken keeps the given token pair in the given session, the session cookie lives as long as the refresh token
+func (kostHandler *KostHandler) saveAuthToken(rw http.ResponseWriter, r *http.Request, session *sessions.Session, authToken *entities.AuthToken, claims *data.Claims) error {
 
-				// renew the token in the session
-				session.Options.MaxAge = 86400 * 7
-				session.Values["token"] = tokenString
-				session.Values["userLoggedin"] = claims.Username
-				session.Save(r, rw)
+	session.Options.MaxAge = int(authToken.RefreshExpiresIn)
+	session.Values["token"] = authToken.AccessToken
+	session.Values["refreshToken"] = authToken.RefreshToken
+	session.Values["userLoggedin"] = claims.Username
 
-				next.ServeHTTP(rw, r)
-			} else {
-				rw.WriteHeader(http.StatusUnauthorized)
-				data.ToJSON(&GenericError{Message: "Token invalid"}, rw)
+	return session.Save(r, rw)
+}
+
+// RequirePermission returns a middleware making sure the current user role is granted every given permission,
+// it must run after MiddlewareValidateAuth and can be composed via Adapt
+func (kostHandler *KostHandler) RequirePermission(permissions ...data.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+			// get the current user login
+			currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+			if err != nil {
+				kostHandler.writeError(rw, r, err)
 
 				return
 			}
-		} else {
-			rw.WriteHeader(http.StatusUnauthorized)
-			data.ToJSON(&GenericError{Message: "Token invalid"}, rw)
+
+			if !data.HasPermission(currentUser.RoleID, permissions...) {
+				kostHandler.writeError(rw, r, data.NewError(data.ErrCodeForbidden, "Kamu tidak punya akses untuk melakukan aksi ini"))
+
+				return
+			}
+
+			// Call the next handler, which can be another middleware in the chain, or the final handler.
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// MiddlewareValidateAPIKey authenticates the internal consumer by the api key in the request header,
+// the key id is logged so every request can be traced back to its consumer
+func (kostHandler *KostHandler) MiddlewareValidateAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		key := data.GetAPIKey(r)
+		if key == "" {
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeUnauthorized, "API key harus diisi"))
+
+			return
+		}
+
+		apiKey, err := kostHandler.kost.AuthenticateAPIKey(key)
+		if err != nil {
+			kostHandler.writeError(rw, r, err)
 
 			return
 		}
+
+		kostHandler.logger.Info("API key request", "key_id", apiKey.KeyID, "key_name", apiKey.KeyName, "method", r.Method, "path", r.URL.Path)
+
+		// add the api key to the context so the scopes can be checked and the service user resolved from it
+		next.ServeHTTP(rw, r.WithContext(data.WithAPIKey(r.Context(), apiKey)))
 	})
 }
 
+// RequireAPIKeyScope returns a middleware making sure the authenticated api key is granted every given scope,
+// it must run after MiddlewareValidateAPIKey and can be composed via Adapt
+func (kostHandler *KostHandler) RequireAPIKeyScope(scopes ...data.APIKeyScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+			apiKey := data.APIKeyFromContext(r.Context())
+			if apiKey == nil || !data.HasAPIKeyScope(apiKey, scopes...) {
+				kostHandler.writeError(rw, r, data.NewError(data.ErrCodeForbidden, "API key tidak punya akses untuk melakukan aksi ini"))
+
+				return
+			}
+
+			// Call the next handler, which can be another middleware in the chain, or the final handler.
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// MiddlewareParseAPIKeyRequest parses the api key payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseAPIKeyRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the api key instance
+		apiKey := &entities.APIKey{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(apiKey, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the api key to the context
+		ctx := context.WithValue(r.Context(), KeyAPIKey{}, apiKey)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// RateLimit returns a middleware limiting the requests by the given rate limit rule per api key, user or client ip,
+// the rule is applied per client ip when it runs before the auth middleware and can be composed via Adapt
+func (kostHandler *KostHandler) RateLimit(ruleName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+			_, ok := data.RateLimitRules[ruleName]
+			if !data.RateLimitEnabled || !ok {
+				next.ServeHTTP(rw, r)
+
+				return
+			}
+
+			if !kostHandler.takeRateLimit(rw, r, ruleName, getRateLimitKey(r), "Terlalu banyak permintaan, silahkan coba lagi dalam %d detik") {
+				return
+			}
+
+			// Call the next handler, which can be another middleware in the chain, or the final handler.
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// takeRateLimit takes a token of the given rate limit rule for the given key, the too many requests error of the given
+// message carrying the seconds to wait is written when the bucket is empty
+func (kostHandler *KostHandler) takeRateLimit(rw http.ResponseWriter, r *http.Request, ruleName string, key string, message string) bool {
+
+	allowed, retryAfter, err := kostHandler.rateLimitStore.Take(ruleName+":"+key, data.RateLimitRules[ruleName])
+
+	// the request is let through when the rate limit store is unavailable so it doesn't take the service down
+	if err != nil {
+		kostHandler.logger.Error("Unable to take the rate limit token", "rule", ruleName, "error", err.Error())
+
+		return true
+	}
+
+	if !allowed {
+		retrySeconds := int(math.Ceil(retryAfter.Seconds()))
+		if retrySeconds < 1 {
+			retrySeconds = 1
+		}
+
+		rw.Header().Set("Retry-After", strconv.Itoa(retrySeconds))
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeTooManyRequests, message, retrySeconds))
+
+		return false
+	}
+
+	return true
+}
+
 // MiddlewareParseKostGetRequest parses the kost payload from the query parameter
 func (kostHandler *KostHandler) MiddlewareParseKostGetRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
@@ -107,8 +294,7 @@ func (kostHandler *KostHandler) MiddlewareParseKostGetRequest(next http.Handler)
 		vars := mux.Vars(r)
 		id, err := strconv.ParseUint(vars["id"], 10, 32)
 		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: "Unable to convert id"}, rw)
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
 
 			return
 		}
@@ -140,8 +326,7 @@ func (kostHandler *KostHandler) MiddlewareParseKostPostRequest(next http.Handler
 		// parse the request body to the given instance
 		err := data.FromJSON(kost, r.Body)
 		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
 
 			return
 		}
@@ -155,6 +340,95 @@ func (kostHandler *KostHandler) MiddlewareParseKostPostRequest(next http.Handler
 	})
 }
 
+// MiddlewareParseKostDraftRequest parses the kost draft section payload in the request body from json,
+// the kost id is taken from the path
+func (kostHandler *KostHandler) MiddlewareParseKostDraftRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		vars := mux.Vars(r)
+		id, err := strconv.ParseUint(vars["id"], 10, 32)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+			return
+		}
+
+		// create the kost instance
+		kost := &entities.Kost{}
+
+		// parse the request body to the given instance
+		err = data.FromJSON(kost, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		kost.ID = uint(id)
+
+		// add the kost to the context
+		ctx := context.WithValue(r.Context(), KeyKost{}, kost)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareValidateKostRequest validates the parsed kost payload, every invalid field is reported
+// before the kost and its rooms are stored
+func (kostHandler *KostHandler) MiddlewareValidateKostRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// get the kost via context
+		kost := r.Context().Value(KeyKost{}).(*entities.Kost)
+
+		if err := kostHandler.kost.ValidateKost(kost); err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareProtectAdsSubmission guards the unauthenticated ads submission by capping the request body size,
+// checking the solved proof of work challenge and limiting the submission rate per client ip, the challenge is
+// checked first so the requests without a solved challenge don't spend the quota of the client ip
+func (kostHandler *KostHandler) MiddlewareProtectAdsSubmission(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// the solved challenge is sent in the headers so it works for both the json and the multipart body
+		err := kostHandler.kost.VerifyAdsChallenge(r.Header.Get("X-Ads-Challenge"), r.Header.Get("X-Ads-Nonce"))
+		if err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+
+		// limit the submission rate of the client ip
+		if !kostHandler.takeRateLimit(rw, r, data.RateLimitAdsIP, getClientIP(r), "Terlalu banyak pengajuan iklan, silahkan coba lagi dalam %d detik") {
+			return
+		}
+
+		// cap the request body, the base64 files are counted as well,
+		// the body can take longer than the server read timeout to arrive at its max size
+		r.Body = http.MaxBytesReader(rw, r.Body, int64(data.AdsSubmission.MaxRequestSizeMB)<<20)
+		kostHandler.extendReadDeadline(rw, r)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
 // MiddlewareParseKostAdsPostRequest parses the kost ads payload in the request body from json
 func (kostHandler *KostHandler) MiddlewareParseKostAdsPostRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
@@ -165,13 +439,50 @@ func (kostHandler *KostHandler) MiddlewareParseKostAdsPostRequest(next http.Hand
 		// create the kostAds instance
 		kostAds := &entities.KostAds{}
 
-		// parse the request body to the given instance
-		err := data.FromJSON(kostAds, r.Body)
-		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		// the multipart form carries the json payload in the payload field and the binary files in the ads_files field,
+		// the plain json body is still accepted for the clients that send the files as base64 string
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
 
-			return
+			// parse the multipart form, the files that don't fit in memory are kept in temporary files
+			err := r.ParseMultipartForm(maxAdsMultipartMemory)
+			if err != nil {
+				kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+				return
+			}
+
+			// parse the payload field to the given instance
+			err = data.FromJSON(kostAds, strings.NewReader(r.FormValue("payload")))
+			if err != nil {
+				kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+				return
+			}
+
+			// every uploaded file must be paired with its file type
+			adsFiles := r.MultipartForm.File["ads_files"]
+			adsFileTypes := r.MultipartForm.Value["ads_file_types"]
+			if len(adsFiles) != len(adsFileTypes) {
+				kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Jumlah ads_files dan ads_file_types tidak sama"))
+
+				return
+			}
+
+			for i, fileHeader := range adsFiles {
+				kostAds.AdsUploads = append(kostAds.AdsUploads, entities.KostAdsUpload{
+					AdsFileType: adsFileTypes[i],
+					FileHeader:  fileHeader,
+				})
+			}
+		} else {
+
+			// parse the request body to the given instance
+			err := data.FromJSON(kostAds, r.Body)
+			if err != nil {
+				kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+				return
+			}
 		}
 
 		// add the kost to the context
@@ -183,6 +494,54 @@ func (kostHandler *KostHandler) MiddlewareParseKostAdsPostRequest(next http.Hand
 	})
 }
 
+// MiddlewareValidateAdsSubmission validates the parsed ads submission, checking its fields, normalizing the phone numbers
+// and limiting the submission rate per phone number
+func (kostHandler *KostHandler) MiddlewareValidateAdsSubmission(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// get the kost ads via context
+		kostAds := r.Context().Value(KeyKostAds{}).(*entities.KostAds)
+
+		// validate the required fields, the price and the ads package of the ads
+		err := kostHandler.kost.ValidateKostAds(kostAds)
+		if err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+
+		// validate the phone numbers and store them in the same format
+		kostAds.AdsPhoneNumber, err = data.NormalizePhoneNumber(kostAds.AdsPhoneNumber)
+		if err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+
+		kostAds.AdsPICWhatsapp, err = data.NormalizePhoneNumber(kostAds.AdsPICWhatsapp)
+		if err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+
+		// limit the number of files of a single ads
+		if len(kostAds.AdsUploads)+len(kostAds.AdsFiles) > data.AdsSubmission.MaxFiles {
+			kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Maksimal %d file per iklan", data.AdsSubmission.MaxFiles))
+
+			return
+		}
+
+		// limit the submission rate of the phone number
+		if !kostHandler.takeRateLimit(rw, r, data.RateLimitAdsPhone, kostAds.AdsPhoneNumber, "Terlalu banyak pengajuan iklan dari nomor telepon ini, silahkan coba lagi dalam %d detik") {
+			return
+		}
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
 // MiddlewareParseApprovalRequest parses the approval payload in the request body from json
 func (kostHandler *KostHandler) MiddlewareParseApprovalRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
@@ -196,8 +555,7 @@ func (kostHandler *KostHandler) MiddlewareParseApprovalRequest(next http.Handler
 		// parse the request body to the given instance
 		err := data.FromJSON(approval, r.Body)
 		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
 
 			return
 		}
@@ -233,3 +591,327 @@ func (kostHandler *KostHandler) MiddlewareParseUserRequest(next http.Handler) ht
 		next.ServeHTTP(rw, r)
 	})
 }
+
+// MiddlewareParsePictOrderRequest parses the pict order payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParsePictOrderRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the pict order instance
+		pictOrder := &entities.PictOrder{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(pictOrder, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the pict order to the context
+		ctx := context.WithValue(r.Context(), KeyPictOrder{}, pictOrder)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParsePictCaptionRequest parses the pict caption payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParsePictCaptionRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the pict caption instance
+		pictCaption := &entities.PictCaption{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(pictCaption, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the pict caption to the context
+		ctx := context.WithValue(r.Context(), KeyPictCaption{}, pictCaption)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseAdsStatusRequest parses the ads status transition payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseAdsStatusRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the ads status transition instance
+		adsStatus := &entities.AdsStatusTransition{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(adsStatus, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the ads status transition to the context
+		ctx := context.WithValue(r.Context(), KeyAdsStatus{}, adsStatus)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseAdsScheduleRequest parses the ads schedule payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseAdsScheduleRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the ads schedule instance
+		adsSchedule := &entities.AdsSchedule{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(adsSchedule, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the ads schedule to the context
+		ctx := context.WithValue(r.Context(), KeyAdsSchedule{}, adsSchedule)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseAdsPackageRequest parses the ads package payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseAdsPackageRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the ads package instance
+		adsPackage := &entities.MasterAdsPackage{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(adsPackage, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the ads package to the context
+		ctx := context.WithValue(r.Context(), KeyAdsPackage{}, adsPackage)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseBoostPackageRequest parses the boost package payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseBoostPackageRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the boost package instance
+		boostPackage := &entities.MasterBoostPackage{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(boostPackage, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the boost package to the context
+		ctx := context.WithValue(r.Context(), KeyBoostPackage{}, boostPackage)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseKostBoostRequest parses the kost boost payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseKostBoostRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the kost boost instance
+		kostBoost := &entities.KostBoostRequest{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(kostBoost, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the kost boost to the context
+		ctx := context.WithValue(r.Context(), KeyKostBoost{}, kostBoost)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseEventRequest parses the event payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseEventRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the event instance
+		event := &entities.Event{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(event, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the event to the context
+		ctx := context.WithValue(r.Context(), KeyEvent{}, event)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseEventEnrollmentRequest parses the event enrollment payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseEventEnrollmentRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the event enrollment instance
+		eventEnrollment := &entities.EventEnrollment{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(eventEnrollment, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the event enrollment to the context
+		ctx := context.WithValue(r.Context(), KeyEventEnrollment{}, eventEnrollment)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseVoucherRequest parses the voucher payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseVoucherRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the voucher instance
+		voucher := &entities.Voucher{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(voucher, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the voucher to the context
+		ctx := context.WithValue(r.Context(), KeyVoucher{}, voucher)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseRoomBookRequest parses the room booking payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseRoomBookRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the room booking instance
+		roomBook := &entities.RoomBookRequest{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(roomBook, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the room booking to the context
+		ctx := context.WithValue(r.Context(), KeyRoomBook{}, roomBook)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareParseMasterDataRequest parses the master data payload in the request body from json
+func (kostHandler *KostHandler) MiddlewareParseMasterDataRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		// validate content type to be application/json
+		rw.Header().Add("Content-Type", "application/json")
+
+		// create the master data instance
+		masterData := &entities.MasterData{}
+
+		// parse the request body to the given instance
+		err := data.FromJSON(masterData, r.Body)
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		// add the master data to the context
+		ctx := context.WithValue(r.Context(), KeyMasterData{}, masterData)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/fakhripraya/kost-service/config"
 	"github.com/fakhripraya/kost-service/data"
 	"github.com/fakhripraya/kost-service/database"
 	"github.com/fakhripraya/kost-service/entities"
+	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
@@ -20,9 +21,9 @@ func (kostHandler *KostHandler) AdminApprovalKost(rw http.ResponseWriter, r *htt
 
 	// get the current user login
 	var currentUser *database.MasterUser
-	currentUser, err := kostHandler.kost.GetCurrentUser(rw, r, kostHandler.store)
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
 	if err != nil {
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -35,40 +36,29 @@ func (kostHandler *KostHandler) AdminApprovalKost(rw http.ResponseWriter, r *htt
 		var dbErr error
 
 		// look for the existing kost by the given kost id
-		if dbErr := config.DB.Where("id = ?", approvalReq.KostID).First(&targetKost).Error; err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-
+		if dbErr := tx.Where("id = ?", approvalReq.KostID).First(&targetKost).Error; dbErr != nil {
 			return dbErr
 		}
 
 		// occurs when transaction already been approved by the tenant
-		if targetKost.Status != 0 {
-			rw.WriteHeader(http.StatusForbidden)
-
-			return fmt.Errorf("Status kost tidak valid untuk di approve")
-		}
-
-		// only tenant can approve the book transaction in this method
-		if currentUser.RoleID != 0 {
-			rw.WriteHeader(http.StatusForbidden)
-
-			return fmt.Errorf("Hanya admin yang bisa approve kost")
+		if targetKost.Status != data.KostStatusPending {
+			return data.NewError(data.ErrCodeConflict, "Status kost tidak valid untuk di approve")
 		}
 
 		// Status 1 = approved by owner
 		// Status 2 = reject
 		if approvalReq.FlagApproval == true {
-			targetKost.Status = 1
+			targetKost.Status = data.KostStatusApproved
 			targetKost.Modified = time.Now().Local()
 			targetKost.ModifiedBy = currentUser.Username
 		} else {
-			targetKost.Status = 2
+			targetKost.Status = data.KostStatusRejected
 			targetKost.Modified = time.Now().Local()
 			targetKost.ModifiedBy = currentUser.Username
 		}
 
 		// update the kost
-		dbErr = config.DB.Save(targetKost).Error
+		dbErr = tx.Save(&targetKost).Error
 
 		if dbErr != nil {
 			return dbErr
@@ -80,8 +70,7 @@ func (kostHandler *KostHandler) AdminApprovalKost(rw http.ResponseWriter, r *htt
 
 	// if transaction error
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -98,3 +87,528 @@ func (kostHandler *KostHandler) AdminApprovalKost(rw http.ResponseWriter, r *htt
 	return
 
 }
+
+// UpdateKostDraftSection is a method to save a section of the kost draft by the owner
+func (kostHandler *KostHandler) UpdateKostDraftSection(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can fill the draft
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// save the given section of the draft
+	err = kostHandler.kost.UpdateKostDraftSection(currentUser, targetKost, mux.Vars(r)["section"], kostReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// reload the draft so the completeness reflects the saved info section
+	if err = config.DB.Where("id = ?", targetKost.ID).First(targetKost).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// get the updated checklist of the draft
+	completeness, err := kostHandler.kost.GetKostCompleteness(targetKost, data.GetLanguage(r))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(completeness, rw)
+	return
+}
+
+// ReorderKostPicts is a method to reorder the kost pict gallery by the owner
+func (kostHandler *KostHandler) ReorderKostPicts(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the pict order via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	pictOrderReq := r.Context().Value(KeyPictOrder{}).(*entities.PictOrder)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// reorder the kost picts based on the given pict ids
+	err = kostHandler.kost.ReorderKostPicts(currentUser, targetKost.ID, pictOrderReq.PictIDs)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses mengubah urutan foto kost"}, rw)
+	return
+}
+
+// UpdateKostPictCaption is a method to update the kost pict caption by the owner
+func (kostHandler *KostHandler) UpdateKostPictCaption(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the pict caption via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	pictCaptionReq := r.Context().Value(KeyPictCaption{}).(*entities.PictCaption)
+
+	// get the pict id via mux
+	vars := mux.Vars(r)
+	pictID, err := strconv.ParseUint(vars["pictId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// look for the target pict in the db, it must belong to the target kost
+	var targetPict database.DBKostPict
+	if err := config.DB.Where("id = ? AND kost_id = ? AND is_active = ?", pictID, targetKost.ID, true).First(&targetPict).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetPict.PictDesc = pictCaptionReq.PictDesc
+	targetPict.Modified = time.Now().Local()
+	targetPict.ModifiedBy = currentUser.Username
+
+	// update the kost pict
+	if err := config.DB.Save(&targetPict).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetPict, rw)
+	return
+}
+
+// ReorderKostRoomPicts is a method to reorder the kost room pict gallery by the owner
+func (kostHandler *KostHandler) ReorderKostRoomPicts(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the pict order via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	pictOrderReq := r.Context().Value(KeyPictOrder{}).(*entities.PictOrder)
+
+	// get the room id via mux
+	vars := mux.Vars(r)
+	roomID, err := strconv.ParseUint(vars["roomId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// look for the target room in the db, it must belong to the target kost
+	var targetRoom database.DBKostRoom
+	if err := config.DB.Where("id = ? AND kost_id = ?", roomID, targetKost.ID).First(&targetRoom).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// reorder the kost room picts based on the given pict ids
+	err = kostHandler.kost.ReorderKostRoomPicts(currentUser, targetRoom.ID, pictOrderReq.PictIDs)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses mengubah urutan foto kamar"}, rw)
+	return
+}
+
+// UpdateKostRoomPictCaption is a method to update the kost room pict caption by the owner
+func (kostHandler *KostHandler) UpdateKostRoomPictCaption(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the pict caption via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	pictCaptionReq := r.Context().Value(KeyPictCaption{}).(*entities.PictCaption)
+
+	// get the room id and the pict id via mux
+	vars := mux.Vars(r)
+	roomID, err := strconv.ParseUint(vars["roomId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	pictID, err := strconv.ParseUint(vars["pictId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// look for the target pict in the db, it must belong to a room of the target kost
+	var targetPict database.DBKostRoomPict
+	if err := config.DB.
+		Joins("inner join db_kost_rooms on db_kost_rooms.id = db_kost_room_picts.room_id").
+		Where("db_kost_room_picts.id = ? AND db_kost_room_picts.room_id = ? AND db_kost_rooms.kost_id = ? AND db_kost_room_picts.is_active = ?", pictID, roomID, targetKost.ID, true).
+		First(&targetPict).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetPict.PictDesc = pictCaptionReq.PictDesc
+	targetPict.Modified = time.Now().Local()
+	targetPict.ModifiedBy = currentUser.Username
+
+	// update the kost room pict
+	if err := config.DB.Save(&targetPict).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetPict, rw)
+	return
+}
+
+// AdminTransitionAdsStatus is a method to move the given ads through the moderation workflow by the admin
+func (kostHandler *KostHandler) AdminTransitionAdsStatus(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads status transition via context
+	adsStatusReq := r.Context().Value(KeyAdsStatus{}).(*entities.AdsStatusTransition)
+
+	// get the ads id via mux
+	vars := mux.Vars(r)
+	adsID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	toStatus, err := data.ParseAdsStatus(adsStatusReq.Status)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// move the ads to the requested status
+	targetAds, err := kostHandler.kost.TransitionAdsStatus(currentUser, uint(adsID), toStatus, adsStatusReq.Reason)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// TODO: send notif to the advertiser
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetAds, rw)
+	return
+}
+
+// AdminUpdateAdsPackage is a method to update the given ads package of the catalogue by the admin
+func (kostHandler *KostHandler) AdminUpdateAdsPackage(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads package via context
+	adsPackageReq := r.Context().Value(KeyAdsPackage{}).(*entities.MasterAdsPackage)
+
+	// get the ads package id via mux
+	vars := mux.Vars(r)
+	packageID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetAdsPackage, err := kostHandler.kost.UpdateAdsPackage(currentUser, uint(packageID), adsPackageReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetAdsPackage, rw)
+	return
+}
+
+// AdminUpdateBoostPackage is a method to update the given boost package of the catalogue by the admin
+func (kostHandler *KostHandler) AdminUpdateBoostPackage(rw http.ResponseWriter, r *http.Request) {
+
+	// get the boost package via context
+	boostPackageReq := r.Context().Value(KeyBoostPackage{}).(*entities.MasterBoostPackage)
+
+	// get the boost package id via mux
+	vars := mux.Vars(r)
+	packageID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetBoostPackage, err := kostHandler.kost.UpdateBoostPackage(currentUser, uint(packageID), boostPackageReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetBoostPackage, rw)
+	return
+}
+
+// AdminConfirmKostBoost is a method to confirm the given pending kost boost once the owner paid for it by the admin
+func (kostHandler *KostHandler) AdminConfirmKostBoost(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.processKostBoost(rw, r, kostHandler.kost.ConfirmKostBoost)
+}
+
+// AdminRejectKostBoost is a method to reject the given pending kost boost by the admin
+func (kostHandler *KostHandler) AdminRejectKostBoost(rw http.ResponseWriter, r *http.Request) {
+	kostHandler.processKostBoost(rw, r, kostHandler.kost.RejectKostBoost)
+}
+
+// processKostBoost processes the pending kost boost of the mux id with the given process
+func (kostHandler *KostHandler) processKostBoost(rw http.ResponseWriter, r *http.Request, process func(currentUser *database.MasterUser, boostID uint) (*database.DBKostBoost, error)) {
+
+	// get the kost boost id via mux
+	vars := mux.Vars(r)
+	boostID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	kostBoost, err := process(currentUser, uint(boostID))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(kostBoost, rw)
+	return
+}
+
+// AdminUpdateEvent is a method to update the given event by the admin
+func (kostHandler *KostHandler) AdminUpdateEvent(rw http.ResponseWriter, r *http.Request) {
+
+	// get the event via context
+	eventReq := r.Context().Value(KeyEvent{}).(*entities.Event)
+
+	// get the event id via mux
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetEvent, err := kostHandler.kost.UpdateEvent(currentUser, uint(eventID), eventReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetEvent, rw)
+	return
+}
+
+// AdminUpdateVoucher is a method to update the given voucher by the admin
+func (kostHandler *KostHandler) AdminUpdateVoucher(rw http.ResponseWriter, r *http.Request) {
+
+	// get the voucher via context
+	voucherReq := r.Context().Value(KeyVoucher{}).(*entities.Voucher)
+
+	// get the voucher id via mux
+	vars := mux.Vars(r)
+	voucherID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetVoucher, err := kostHandler.kost.UpdateVoucher(currentUser, uint(voucherID), voucherReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetVoucher, rw)
+	return
+}
+
+// AdminUpdateMasterData is a method to update the given master data of the given kind by the admin
+func (kostHandler *KostHandler) AdminUpdateMasterData(rw http.ResponseWriter, r *http.Request) {
+
+	// get the master data via context
+	masterDataReq := r.Context().Value(KeyMasterData{}).(*entities.MasterData)
+
+	// get the master data kind and id via mux
+	vars := mux.Vars(r)
+	masterDataID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetMasterData, err := kostHandler.kost.UpdateMasterData(currentUser, vars["kind"], uint(masterDataID), masterDataReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetMasterData, rw)
+	return
+}
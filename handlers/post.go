@@ -1,16 +1,19 @@
 package handlers
 
 import (
-	"io/ioutil"
+	"io"
+	"mime/multipart"
 	"net/http"
-	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fakhripraya/kost-service/config"
 	"github.com/fakhripraya/kost-service/data"
 	"github.com/fakhripraya/kost-service/database"
 	"github.com/fakhripraya/kost-service/entities"
+	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
@@ -22,251 +25,125 @@ func (kostHandler *KostHandler) AddKost(rw http.ResponseWriter, r *http.Request)
 
 	// get the current user login
 	var currentUser *database.MasterUser
-	currentUser, err := kostHandler.kost.GetCurrentUser(rw, r, kostHandler.store)
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
 	if err != nil {
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	// proceed to create the new kost with transaction scope
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		_, dbErr := kostHandler.kost.CreateKost(tx, currentUser, kostReq)
 
-		// set variables
-		var newKost database.DBKost
-		var dbErr error
-
-		newKost.OwnerID = currentUser.ID
-		newKost.TypeID = kostReq.TypeID // kategori kos kosan atau kontrakan atau dll
-		newKost.Status = 0              // TODO: create a documented status later // status 0 = baru
-		newKost.KostCode, dbErr = kostHandler.kost.GenerateCode("K", kostReq.Country[0:1], kostReq.City[0:1])
-
-		if dbErr != nil {
-			return dbErr
-		}
-
-		newKost.KostName = kostReq.KostName
-		newKost.KostDesc = kostReq.KostDesc
-		newKost.Country = kostReq.Country
-		newKost.City = kostReq.City
-		newKost.Address = kostReq.Address
-		newKost.Latitude = kostReq.Latitude
-		newKost.Longitude = kostReq.Longitude
-		newKost.ThumbnailURL = kostReq.Rooms[0].RoomPicts[0].URL
-		newKost.UpRate = 0
-		newKost.UpRateExpired = time.Now().Local()
-		newKost.IsVerified = false
-		newKost.IsActive = true
-		newKost.Created = time.Now().Local()
-		newKost.CreatedBy = currentUser.Username
-		newKost.Modified = time.Now().Local()
-		newKost.ModifiedBy = currentUser.Username
-
-		if dbErr = tx.Create(&newKost).Error; dbErr != nil {
-			return dbErr
-		}
-
-		// proceed to create the new kost periods with transaction scope
-		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
-
-			// create the variable specific to the nested transaction
-			var dbErr2 error
-			var kostPeriod = kostReq.KostPeriods
-
-			// add the kost id to the slices
-			for i := range kostPeriod {
-				(&kostPeriod[i]).KostID = newKost.ID
-				(&kostPeriod[i]).IsActive = true
-				(&kostPeriod[i]).Created = time.Now().Local()
-				(&kostPeriod[i]).CreatedBy = currentUser.Username
-				(&kostPeriod[i]).Modified = time.Now().Local()
-				(&kostPeriod[i]).ModifiedBy = currentUser.Username
-			}
-
-			// insert the new kost periods to database
-			if dbErr2 = tx2.Create(&kostPeriod).Error; dbErr2 != nil {
-				return dbErr2
-			}
-
-			// return nil will commit the whole nested transaction
-			return nil
-		})
-
-		// if transaction error, return the error
-		if dbErr != nil {
-			return dbErr
-		}
-
-		// proceed to create the new kost picts with transaction scope
-		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
-
-			// create the variable specific to the nested transaction
-			var dbErr2 error
-			var kostPicts = kostReq.KostPicts
-
-			// add the kost id to the slices
-			for i := range kostPicts {
-				(&kostPicts[i]).KostID = newKost.ID
-				(&kostPicts[i]).IsActive = true
-				(&kostPicts[i]).Created = time.Now().Local()
-				(&kostPicts[i]).CreatedBy = currentUser.Username
-				(&kostPicts[i]).Modified = time.Now().Local()
-				(&kostPicts[i]).ModifiedBy = currentUser.Username
-			}
-
-			// insert the new kost picts to database
-			if dbErr2 = tx2.Create(&kostPicts).Error; dbErr2 != nil {
-				return dbErr2
-			}
-
-			// return nil will commit the whole nested transaction
-			return nil
-		})
-
-		// if transaction error, return the error
-		if dbErr != nil {
-			return dbErr
-		}
-
-		// proceed to create the new kost benchmark with transaction scope
-		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
-
-			// create the variable specific to the nested transaction
-			var dbErr2 error
-			var kostBenchmark = kostReq.KostBenchmark
-
-			// add the kost id to the slices
-			for i := range kostBenchmark {
-				(&kostBenchmark[i]).KostID = newKost.ID
-				(&kostBenchmark[i]).IsActive = true
-				(&kostBenchmark[i]).Created = time.Now().Local()
-				(&kostBenchmark[i]).CreatedBy = currentUser.Username
-				(&kostBenchmark[i]).Modified = time.Now().Local()
-				(&kostBenchmark[i]).ModifiedBy = currentUser.Username
-			}
-
-			// insert the new kost picts to database
-			if dbErr2 = tx2.Create(&kostBenchmark).Error; dbErr2 != nil {
-				return dbErr2
-			}
-
-			// return nil will commit the whole nested transaction
-			return nil
-		})
-
-		// if transaction error, return the error
-		if dbErr != nil {
-			return dbErr
-		}
-
-		// proceed to create the new kost accessibility with transaction scope
-		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
+		return dbErr
+	})
 
-			// create the variable specific to the nested transaction
-			var dbErr2 error
-			var kostAccess = kostReq.KostAccess
-
-			// add the kost id to the slices
-			for i := range kostAccess {
-				(&kostAccess[i]).KostID = newKost.ID
-				(&kostAccess[i]).IsActive = true
-				(&kostAccess[i]).Created = time.Now().Local()
-				(&kostAccess[i]).CreatedBy = currentUser.Username
-				(&kostAccess[i]).Modified = time.Now().Local()
-				(&kostAccess[i]).ModifiedBy = currentUser.Username
-			}
+	// if transaction error
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
-			// insert the new kost picts to database
-			if dbErr2 = tx2.Create(&kostAccess).Error; dbErr2 != nil {
-				return dbErr2
-			}
+		return
+	}
 
-			// return nil will commit the whole nested transaction
-			return nil
-		})
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses menambah kost baru"}, rw)
+	return
+}
 
-		// if transaction error, return the error
-		if dbErr != nil {
-			return dbErr
-		}
+// AddKostDraft is a method to save a new kost draft from the kost info, the rest of the kost
+// is filled section by section before the draft is submitted
+func (kostHandler *KostHandler) AddKostDraft(rw http.ResponseWriter, r *http.Request) {
 
-		// proceed to create the new around kost with transaction scope
-		dbErr = tx.Transaction(func(tx2 *gorm.DB) error {
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
 
-			// create the variable specific to the nested transaction
-			var dbErr2 error
-			var kostAround = kostReq.KostAround
-
-			// add the kost id to the slices
-			for i := range kostAround {
-				(&kostAround[i]).KostID = newKost.ID
-				(&kostAround[i]).IsActive = true
-				(&kostAround[i]).Created = time.Now().Local()
-				(&kostAround[i]).CreatedBy = currentUser.Username
-				(&kostAround[i]).Modified = time.Now().Local()
-				(&kostAround[i]).ModifiedBy = currentUser.Username
-			}
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
-			// insert the new kost picts to database
-			if dbErr2 = tx2.Create(&kostAround).Error; dbErr2 != nil {
-				return dbErr2
-			}
+		return
+	}
 
-			// return nil will commit the whole nested transaction
-			return nil
-		})
+	// save the new kost draft
+	newKost, err := kostHandler.kost.AddKostDraft(currentUser, kostReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
-		// if transaction error, return the error
-		if dbErr != nil {
-			return dbErr
-		}
+		return
+	}
 
-		// loop the room slices
-		for _, room := range kostReq.Rooms {
+	// get the checklist of the new draft so the owner knows what to fill next
+	completeness, err := kostHandler.kost.GetKostCompleteness(newKost, data.GetLanguage(r))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
-			// add the kostReq room slices into the database
-			dbErr = kostHandler.kost.AddRoom(currentUser, newKost.ID, &room)
+		return
+	}
 
-			// if transaction error, return the error
-			if dbErr != nil {
-				return dbErr
-			}
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(completeness, rw)
+	return
+}
 
-		}
+// SubmitKostDraft is a method to submit the kost draft for the admin approval by the owner
+func (kostHandler *KostHandler) SubmitKostDraft(rw http.ResponseWriter, r *http.Request) {
 
-		// add the kost facilities to the database
-		dbErr = kostHandler.kost.AddFacilities(currentUser, newKost.ID, kostReq.Facilities)
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
 
-		// if transaction error, return the error
-		if dbErr != nil {
-			return dbErr
-		}
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
 
-		// return nil will commit the whole transaction
-		return nil
+		return
+	}
 
-	})
+	// look for the target kost in the db, only the kost owner can submit the draft
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
 
-	// if transaction error
+	// submit the draft, the incomplete draft is rejected with its missing items
+	err = kostHandler.kost.SubmitKostDraft(currentUser, targetKost)
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
 
 	rw.WriteHeader(http.StatusOK)
-	data.ToJSON(&GenericError{Message: "Sukses menambah kost baru"}, rw)
+	data.ToJSON(&GenericError{Message: "Sukses mengajukan kost baru"}, rw)
 	return
 }
 
+// AddKostAds is a method to submit the new given kost ads and store its files
 func (kostHandler *KostHandler) AddKostAds(rw http.ResponseWriter, r *http.Request) {
 
 	// get the kost via context
 	kostAdsReq := r.Context().Value(KeyKostAds{}).(*entities.KostAds)
 
+	// look for the ads package picked by the advertiser in the catalogue
+	adsPackage, err := kostHandler.kost.ResolveAdsPackage(kostAdsReq.AdsPackageID, kostAdsReq.AdsType)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the same ads submitted again while the previous one is still being processed is rejected by its submission lock
+	submissionHash := data.AdsSubmissionHash(kostAdsReq, adsPackage.ID)
+
+	// keep track of the stored files so they can be removed if the transaction fails
+	var storedKeys []string
+
 	// proceed to create the new kost ads with transaction scope
-	err := config.DB.Transaction(func(tx *gorm.DB) error {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
 
 		// set variables
 		var newKostAds database.DBKostAds
@@ -279,7 +156,10 @@ func (kostHandler *KostHandler) AddKostAds(rw http.ResponseWriter, r *http.Reque
 			return dbErr
 		}
 
-		newKostAds.AdsType = kostAdsReq.AdsType
+		newKostAds.SubmissionHash = submissionHash
+		newKostAds.SubmissionLock = &submissionHash
+		newKostAds.AdsPackageID = adsPackage.ID
+		newKostAds.AdsType = adsPackage.PackageName
 		newKostAds.AdsKostType = kostAdsReq.AdsKostType
 		newKostAds.AdsOwner = kostAdsReq.AdsOwner
 		newKostAds.AdsOwnerIG = kostAdsReq.AdsOwnerIG
@@ -301,14 +181,16 @@ func (kostHandler *KostHandler) AddKostAds(rw http.ResponseWriter, r *http.Reque
 		newKostAds.Modified = time.Now().Local()
 		newKostAds.ModifiedBy = "System"
 
-		if dbErr = tx.Create(&newKostAds).Error; dbErr != nil {
+		if dbErr = kostHandler.kost.ReleaseAdsSubmissionLock(tx, submissionHash); dbErr != nil {
 			return dbErr
 		}
 
-		var baseFileDirPath = os.Getenv("APP_MK_DIR_ADS_FILE_PATH") + "/File-Directory-" + strconv.FormatUint(uint64(newKostAds.ID), 10)
+		if dbErr = tx.Create(&newKostAds).Error; dbErr != nil {
+			return data.AdsSubmissionError(dbErr)
+		}
 
-		// the WriteFile method returns an error if unsuccessful
-		dbErr = os.MkdirAll(baseFileDirPath, 0777)
+		// add the requested posting dates of the ads
+		dbErr = kostHandler.kost.AddAdsScheduleRequests(tx, newKostAds.ID, kostAdsReq.AdsScheduleRequests, "System")
 		if dbErr != nil {
 			return dbErr
 		}
@@ -318,42 +200,64 @@ func (kostHandler *KostHandler) AddKostAds(rw http.ResponseWriter, r *http.Reque
 
 			// create the variable specific to the nested transaction
 			var dbErr2 error
-			var kostAdsFiles = kostAdsReq.AdsFiles
+			var kostAdsFiles []database.DBKostAdsFiles
 
-			// add the kost id to the slices
-			for i := range kostAdsFiles {
+			// store the multipart uploaded files as binary objects
+			for i, upload := range kostAdsReq.AdsUploads {
+
+				file, dbErr2 := upload.FileHeader.Open()
+				if dbErr2 != nil {
+					return dbErr2
+				}
 
-				// specify the directory path of the desired file
-				var specificDirPath = baseFileDirPath + "/FOLDER_" + (&kostAdsFiles[i]).AdsFileType
+				kostAdsFile := database.DBKostAdsFiles{
+					AdsID:       newKostAds.ID,
+					AdsFileType: upload.AdsFileType,
+					AdsFileName: filepath.Base(upload.FileHeader.Filename),
+				}
 
-				(&kostAdsFiles[i]).AdsID = newKostAds.ID
-				(&kostAdsFiles[i]).IsActive = true
-				(&kostAdsFiles[i]).Created = time.Now().Local()
-				(&kostAdsFiles[i]).CreatedBy = "System"
-				(&kostAdsFiles[i]).Modified = time.Now().Local()
-				(&kostAdsFiles[i]).ModifiedBy = "System"
+				dbErr2 = kostHandler.kost.StoreAdsFile(&kostAdsFile, i, file)
+				file.Close()
+				if dbErr2 != nil {
+					return dbErr2
+				}
 
-				_, err := os.Stat(specificDirPath)
-				if os.IsNotExist(err) {
-					// the WriteFile method returns an error if unsuccessful
-					dbErr = os.Mkdir(specificDirPath, 0777)
-					if dbErr != nil {
-						return dbErr
-					}
+				storedKeys = append(storedKeys, kostAdsFile.StorageKey)
+				kostAdsFiles = append(kostAdsFiles, kostAdsFile)
+			}
+
+			// the legacy json payload sends the files as base64 string, decode and store them as binary objects too
+			for i, legacyFile := range kostAdsReq.AdsFiles {
+
+				kostAdsFile := database.DBKostAdsFiles{
+					AdsID:       newKostAds.ID,
+					AdsFileType: legacyFile.AdsFileType,
 				}
 
-				dbErr = ioutil.WriteFile(specificDirPath+"/"+strconv.Itoa(i)+".txt", []byte((&kostAdsFiles[i]).BASE64STRING), 0777)
-				// handle this error
-				if dbErr != nil {
-					// print it out
-					return dbErr
+				dbErr2 = kostHandler.kost.StoreAdsFile(&kostAdsFile, len(kostAdsReq.AdsUploads)+i, kostHandler.kost.DecodeBase64AdsFile(legacyFile.BASE64STRING))
+				if dbErr2 != nil {
+					return dbErr2
 				}
 
-				(&kostAdsFiles[i]).AdsDirPath = specificDirPath + "/" + strconv.Itoa(i) + ".txt"
-				(&kostAdsFiles[i]).BASE64STRING = ""
+				storedKeys = append(storedKeys, kostAdsFile.StorageKey)
+				kostAdsFiles = append(kostAdsFiles, kostAdsFile)
+			}
+
+			// nothing to insert if the ads has no files
+			if len(kostAdsFiles) == 0 {
+				return nil
+			}
+
+			// add the ads id to the slices
+			for i := range kostAdsFiles {
+				(&kostAdsFiles[i]).IsActive = true
+				(&kostAdsFiles[i]).Created = time.Now().Local()
+				(&kostAdsFiles[i]).CreatedBy = "System"
+				(&kostAdsFiles[i]).Modified = time.Now().Local()
+				(&kostAdsFiles[i]).ModifiedBy = "System"
 			}
 
-			// insert the new kost periods to database
+			// insert the new kost ads files to database
 			if dbErr2 = tx2.Create(&kostAdsFiles).Error; dbErr2 != nil {
 				return dbErr2
 			}
@@ -374,8 +278,11 @@ func (kostHandler *KostHandler) AddKostAds(rw http.ResponseWriter, r *http.Reque
 
 	// if transaction error
 	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
-		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+
+		// remove the already stored files as the ads is not saved
+		kostHandler.kost.DeleteAdsFiles(storedKeys)
+
+		kostHandler.writeError(rw, r, err)
 
 		return
 	}
@@ -384,3 +291,882 @@ func (kostHandler *KostHandler) AddKostAds(rw http.ResponseWriter, r *http.Reque
 	data.ToJSON(&GenericError{Message: "Sukses submit form iklan, sekarang kamu hanya tinggal tunggu kami proses deh, kalau menurut kamu kelamaan dipostnya jangan lupa untuk tegur kita ya :)"}, rw)
 	return
 }
+
+// AddKostPict is a method to upload a new kost pict and generate its variants
+func (kostHandler *KostHandler) AddKostPict(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// parse the uploaded pict from the multipart form
+	file, pictDesc, err := kostHandler.parsePictUpload(rw, r)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	defer file.Close()
+
+	// generate the pict variants, this also strips the EXIF metadata of the original pict
+	variants, err := kostHandler.kost.GeneratePictVariants(file, "kost-"+strconv.FormatUint(uint64(targetKost.ID), 10))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// put the new pict at the end of the gallery
+	sortOrder, err := kostHandler.kost.GetNextKostPictOrder(targetKost.ID)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newKostPict := database.DBKostPict{
+		KostID:           targetKost.ID,
+		PictDesc:         pictDesc,
+		URL:              variants.LargeJpegURL,
+		ThumbnailWebpURL: variants.ThumbnailWebpURL,
+		ThumbnailJpegURL: variants.ThumbnailJpegURL,
+		MediumWebpURL:    variants.MediumWebpURL,
+		MediumJpegURL:    variants.MediumJpegURL,
+		LargeWebpURL:     variants.LargeWebpURL,
+		LargeJpegURL:     variants.LargeJpegURL,
+		Blurhash:         variants.Blurhash,
+		SortOrder:        sortOrder,
+		IsActive:         true,
+		Created:          time.Now().Local(),
+		CreatedBy:        currentUser.Username,
+		Modified:         time.Now().Local(),
+		ModifiedBy:       currentUser.Username,
+	}
+
+	// insert the new kost pict to database
+	if err := config.DB.Create(&newKostPict).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newKostPict, rw)
+	return
+}
+
+// AddKostRoomPict is a method to upload a new kost room pict and generate its variants
+func (kostHandler *KostHandler) AddKostRoomPict(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+
+	// get the room id via mux
+	vars := mux.Vars(r)
+	roomID, err := strconv.ParseUint(vars["roomId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// look for the target kost in the db, only the kost owner can manage its picts
+	targetKost, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser)
+	if !ok {
+		return
+	}
+
+	// look for the target room in the db, it must belong to the target kost
+	var targetRoom database.DBKostRoom
+	if err := config.DB.Where("id = ? AND kost_id = ?", roomID, targetKost.ID).First(&targetRoom).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the uploaded pict from the multipart form
+	file, pictDesc, err := kostHandler.parsePictUpload(rw, r)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	defer file.Close()
+
+	// generate the pict variants, this also strips the EXIF metadata of the original pict
+	variants, err := kostHandler.kost.GeneratePictVariants(file, "kost-"+strconv.FormatUint(uint64(targetKost.ID), 10)+"/room-"+strconv.FormatUint(uint64(targetRoom.ID), 10))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// put the new pict at the end of the gallery
+	sortOrder, err := kostHandler.kost.GetNextKostRoomPictOrder(targetRoom.ID)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newKostRoomPict := database.DBKostRoomPict{
+		RoomID:           targetRoom.ID,
+		PictDesc:         pictDesc,
+		URL:              variants.LargeJpegURL,
+		ThumbnailWebpURL: variants.ThumbnailWebpURL,
+		ThumbnailJpegURL: variants.ThumbnailJpegURL,
+		MediumWebpURL:    variants.MediumWebpURL,
+		MediumJpegURL:    variants.MediumJpegURL,
+		LargeWebpURL:     variants.LargeWebpURL,
+		LargeJpegURL:     variants.LargeJpegURL,
+		Blurhash:         variants.Blurhash,
+		SortOrder:        sortOrder,
+		IsActive:         true,
+		Created:          time.Now().Local(),
+		CreatedBy:        currentUser.Username,
+		Modified:         time.Now().Local(),
+		ModifiedBy:       currentUser.Username,
+	}
+
+	// insert the new kost room pict to database
+	if err := config.DB.Create(&newKostRoomPict).Error; err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newKostRoomPict, rw)
+	return
+}
+
+// maxPictUploadSize is the max size of a single uploaded pict (10 MB)
+const maxPictUploadSize = 10 << 20
+
+// parsePictUpload parses the uploaded pict file and its description from the multipart form
+func (kostHandler *KostHandler) parsePictUpload(rw http.ResponseWriter, r *http.Request) (multipart.File, string, error) {
+
+	// limit the request body so a huge upload can't exhaust the server memory or disk,
+	// the body can take longer than the server read timeout to arrive at its max size
+	r.Body = http.MaxBytesReader(rw, r.Body, maxPictUploadSize)
+	kostHandler.extendReadDeadline(rw, r)
+	if err := r.ParseMultipartForm(maxPictUploadSize); err != nil {
+
+		return nil, "", data.RequestBodyError(err)
+	}
+
+	file, _, err := r.FormFile("pict")
+	if err != nil {
+
+		return nil, "", data.WrapError(data.ErrCodeValidation, err, "Foto harus diisi")
+	}
+
+	return file, r.FormValue("pict_desc"), nil
+}
+
+// AdminScheduleAds is a method to allocate the posting slots of the given ads by the admin
+func (kostHandler *KostHandler) AdminScheduleAds(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads schedule via context
+	adsScheduleReq := r.Context().Value(KeyAdsSchedule{}).(*entities.AdsSchedule)
+
+	// get the ads id via mux
+	vars := mux.Vars(r)
+	adsID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// allocate the posting slots of the ads
+	adsSlots, conflicts, err := kostHandler.kost.ScheduleAds(currentUser, uint(adsID), *adsScheduleReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	if len(conflicts) > 0 {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&entities.AdsScheduleConflict{
+			Code:      string(data.ErrCodeConflict),
+			Message:   data.Translate(data.GetLanguage(r), "Jadwal iklan bentrok"),
+			Conflicts: conflicts,
+		}, rw)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(adsSlots, rw)
+	return
+}
+
+// AdminAddAdsPackage is a method to add a new ads package to the catalogue by the admin
+func (kostHandler *KostHandler) AdminAddAdsPackage(rw http.ResponseWriter, r *http.Request) {
+
+	// get the ads package via context
+	adsPackageReq := r.Context().Value(KeyAdsPackage{}).(*entities.MasterAdsPackage)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newAdsPackage, err := kostHandler.kost.AddAdsPackage(currentUser, adsPackageReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newAdsPackage, rw)
+	return
+}
+
+// AdminImportAdsMetrics is a method to import the ads performance metrics from the instagram and tiktok csv exports by the admin,
+// the csv is sent either as the metrics field of a multipart form or as the raw request body
+func (kostHandler *KostHandler) AdminImportAdsMetrics(rw http.ResponseWriter, r *http.Request) {
+
+	// validate content type to be application/json
+	rw.Header().Add("Content-Type", "application/json")
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the file can take longer than the server read timeout to arrive at its max size
+	r.Body = http.MaxBytesReader(rw, r.Body, maxAdsMetricImportSize)
+	kostHandler.extendReadDeadline(rw, r)
+
+	// the channel is used for the rows without the channel column
+	channel := r.URL.Query().Get("channel")
+	var src io.Reader = r.Body
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("metrics")
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		defer file.Close()
+
+		src = file
+		if formChannel := r.FormValue("channel"); formChannel != "" {
+			channel = formChannel
+		}
+	}
+
+	result, err := kostHandler.kost.ImportAdsMetrics(currentUser, src, channel)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(result, rw)
+	return
+}
+
+// ImportKosts is a method to add the kosts of the csv or xlsx import template in bulk by the owner,
+// the file is sent either as the kosts field of a multipart form or as the raw request body,
+// the Idempotency-Key header identifies the import so its retry is rejected, the file hash is used if it is not sent
+func (kostHandler *KostHandler) ImportKosts(rw http.ResponseWriter, r *http.Request) {
+
+	// validate content type to be application/json
+	rw.Header().Add("Content-Type", "application/json")
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the file can take longer than the server read timeout to arrive at its max size
+	r.Body = http.MaxBytesReader(rw, r.Body, maxKostImportSize)
+	kostHandler.extendReadDeadline(rw, r)
+
+	// the mode decides whether the valid kosts are added when the other kosts are not
+	mode := r.URL.Query().Get("mode")
+	var src io.Reader = r.Body
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("kosts")
+		if err != nil {
+			kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+			return
+		}
+
+		defer file.Close()
+
+		src = file
+		if formMode := r.FormValue("mode"); formMode != "" {
+			mode = formMode
+		}
+	}
+
+	result, err := kostHandler.kost.ImportKosts(currentUser, src, mode, strings.TrimSpace(r.Header.Get("Idempotency-Key")), data.GetLanguage(r))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// the row errors are reported along with the added kosts
+	if len(result.Failed) > 0 && !result.Committed {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+	} else {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	data.ToJSON(result, rw)
+	return
+}
+
+// RequestKostBoost is a method to request the given boost package for the owned kost by the owner,
+// the boost is not charged here so it stays pending until the admin confirms the payment
+func (kostHandler *KostHandler) RequestKostBoost(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the kost boost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	kostBoostReq := r.Context().Value(KeyKostBoost{}).(*entities.KostBoostRequest)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// only the kost owner can request a boost for the kost
+	if _, ok := kostHandler.getOwnedKost(rw, r, kostReq.ID, currentUser); !ok {
+		return
+	}
+
+	kostBoost, err := kostHandler.kost.RequestKostBoost(currentUser, kostReq.ID, kostBoostReq.BoostPackageID)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(kostBoost, rw)
+	return
+}
+
+// AdminGrantKostBoost is a method to grant the given boost package to the given kost right away by the admin, e.g. as a promo,
+// the paid boosts are requested by the owner and confirmed instead
+func (kostHandler *KostHandler) AdminGrantKostBoost(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the kost boost via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	kostBoostReq := r.Context().Value(KeyKostBoost{}).(*entities.KostBoostRequest)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	kostBoost, err := kostHandler.kost.ApplyKostBoost(currentUser, kostReq.ID, kostBoostReq.BoostPackageID)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(kostBoost, rw)
+	return
+}
+
+// AdminAddBoostPackage is a method to add a new boost package to the catalogue by the admin
+func (kostHandler *KostHandler) AdminAddBoostPackage(rw http.ResponseWriter, r *http.Request) {
+
+	// get the boost package via context
+	boostPackageReq := r.Context().Value(KeyBoostPackage{}).(*entities.MasterBoostPackage)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newBoostPackage, err := kostHandler.kost.AddBoostPackage(currentUser, boostPackageReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newBoostPackage, rw)
+	return
+}
+
+// AdminAddEvent is a method to add a new event by the admin
+func (kostHandler *KostHandler) AdminAddEvent(rw http.ResponseWriter, r *http.Request) {
+
+	// get the event via context
+	eventReq := r.Context().Value(KeyEvent{}).(*entities.Event)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newEvent, err := kostHandler.kost.AddEvent(currentUser, eventReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newEvent, rw)
+	return
+}
+
+// AdminUploadEventThumbnail is a method to upload the thumbnail of the given event by the admin
+func (kostHandler *KostHandler) AdminUploadEventThumbnail(rw http.ResponseWriter, r *http.Request) {
+
+	// get the event id via mux
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// parse the uploaded pict from the multipart form
+	file, _, err := kostHandler.parsePictUpload(rw, r)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	defer file.Close()
+
+	// generate the pict variants, this also strips the EXIF metadata of the original pict
+	variants, err := kostHandler.kost.GeneratePictVariants(file, "event-"+strconv.FormatUint(eventID, 10))
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	targetEvent, err := kostHandler.kost.SetEventThumbnail(currentUser, uint(eventID), variants)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(targetEvent, rw)
+	return
+}
+
+// EnrollEventKost is a method to enroll a kost to the given event,
+// the admin can assign any kost while the owner can only opt in their own kost
+func (kostHandler *KostHandler) EnrollEventKost(rw http.ResponseWriter, r *http.Request) {
+
+	// get the event enrollment via context
+	eventEnrollmentReq := r.Context().Value(KeyEventEnrollment{}).(*entities.EventEnrollment)
+
+	// get the event id via mux
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	eventDetail, err := kostHandler.kost.EnrollEventKost(currentUser, uint(eventID), eventEnrollmentReq.KostID)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(eventDetail, rw)
+	return
+}
+
+// AdminAddVoucher is a method to add a new voucher by the admin
+func (kostHandler *KostHandler) AdminAddVoucher(rw http.ResponseWriter, r *http.Request) {
+
+	// get the voucher via context
+	voucherReq := r.Context().Value(KeyVoucher{}).(*entities.Voucher)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newVoucher, err := kostHandler.kost.AddVoucher(currentUser, voucherReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newVoucher, rw)
+	return
+}
+
+// QuoteKostRoomBook is a method to preview the invoice amount of a room booking with the given voucher
+func (kostHandler *KostHandler) QuoteKostRoomBook(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the room booking via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	roomBookReq := r.Context().Value(KeyRoomBook{}).(*entities.RoomBookRequest)
+
+	// get the room id via mux
+	vars := mux.Vars(r)
+	roomID, err := strconv.ParseUint(vars["roomId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID kamar tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	quote, err := kostHandler.kost.QuoteRoomBook(currentUser, kostReq.ID, uint(roomID), roomBookReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(quote, rw)
+	return
+}
+
+// AddKostRoomBook is a method to book the given kost room, the given voucher discount is applied to the invoice
+func (kostHandler *KostHandler) AddKostRoomBook(rw http.ResponseWriter, r *http.Request) {
+
+	// get the kost and the room booking via context
+	kostReq := r.Context().Value(KeyKost{}).(*entities.Kost)
+	roomBookReq := r.Context().Value(KeyRoomBook{}).(*entities.RoomBookRequest)
+
+	// get the room id via mux
+	vars := mux.Vars(r)
+	roomID, err := strconv.ParseUint(vars["roomId"], 10, 32)
+	if err != nil {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "ID kamar tidak valid"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err = kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newRoomBook, err := kostHandler.kost.CreateRoomBook(currentUser, kostReq.ID, uint(roomID), roomBookReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newRoomBook, rw)
+	return
+}
+
+// AdminAddMasterData is a method to add a new master data of the given kind by the admin
+func (kostHandler *KostHandler) AdminAddMasterData(rw http.ResponseWriter, r *http.Request) {
+
+	// get the master data via context
+	masterDataReq := r.Context().Value(KeyMasterData{}).(*entities.MasterData)
+
+	// get the master data kind via mux
+	vars := mux.Vars(r)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	newMasterData, err := kostHandler.kost.AddMasterData(currentUser, vars["kind"], masterDataReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(newMasterData, rw)
+	return
+}
+
+// RefreshToken is a method to issue a new token pair from the given refresh token,
+// the refresh token is read from the body and falls back to the one kept in the session cookie
+func (kostHandler *KostHandler) RefreshToken(rw http.ResponseWriter, r *http.Request) {
+
+	// the body is optional for the web clients, so the empty body is not an error
+	refreshReq := &entities.RefreshTokenRequest{}
+	if err := data.FromJSON(refreshReq, r.Body); err != nil && err != io.EOF {
+		kostHandler.writeError(rw, r, data.RequestBodyError(err))
+
+		return
+	}
+
+	// Get a session (existing/new)
+	session, err := kostHandler.store.Get(r, "session-name")
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	fromSession := false
+	if refreshReq.RefreshToken == "" {
+		refreshReq.RefreshToken, _ = session.Values["refreshToken"].(string)
+		fromSession = true
+	}
+
+	if refreshReq.RefreshToken == "" {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeUnauthorized, "Refresh token harus diisi"))
+
+		return
+	}
+
+	authToken, claims, err := kostHandler.kost.RefreshAuthSession(refreshReq.RefreshToken)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// keep the rotated refresh token in the session cookie it was read from
+	if fromSession {
+		if err := kostHandler.saveAuthToken(rw, r, session, authToken, claims); err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(authToken, rw)
+	return
+}
+
+// ExchangeToken is a method to start a new auth session for the bearer client still holding a token issued by the login,
+// the client must use the returned refresh token from now on
+func (kostHandler *KostHandler) ExchangeToken(rw http.ResponseWriter, r *http.Request) {
+
+	// the token already belonging to a session is renewed via the refresh token instead
+	if claims := data.ClaimsFromContext(r.Context()); claims != nil && claims.SessionID != "" {
+		kostHandler.writeError(rw, r, data.NewError(data.ErrCodeBadRequest, "Token sudah memiliki sesi, gunakan refresh token"))
+
+		return
+	}
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	authToken, _, err := kostHandler.kost.IssueAuthSession(currentUser)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(authToken, rw)
+	return
+}
+
+// Logout is a method to revoke the current auth session and clear the session cookie
+func (kostHandler *KostHandler) Logout(rw http.ResponseWriter, r *http.Request) {
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	if claims := data.ClaimsFromContext(r.Context()); claims != nil && claims.SessionID != "" {
+		if err := kostHandler.kost.RevokeAuthSession(claims.SessionID, "logout", currentUser.Username); err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+	}
+
+	// Get a session (existing/new)
+	session, err := kostHandler.store.Get(r, "session-name")
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	// remove the session cookie of the web clients
+	if !session.IsNew {
+		session.Options.MaxAge = -1
+		delete(session.Values, "token")
+		delete(session.Values, "refreshToken")
+		delete(session.Values, "userLoggedin")
+
+		if err := session.Save(r, rw); err != nil {
+			kostHandler.writeError(rw, r, err)
+
+			return
+		}
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(&GenericError{Message: "Sukses logout"}, rw)
+	return
+}
+
+// AdminIssueAPIKey is a method to issue a new api key for an internal consumer by the admin,
+// the key is only returned once
+func (kostHandler *KostHandler) AdminIssueAPIKey(rw http.ResponseWriter, r *http.Request) {
+
+	// get the api key via context
+	apiKeyReq := r.Context().Value(KeyAPIKey{}).(*entities.APIKey)
+
+	// get the current user login
+	var currentUser *database.MasterUser
+	currentUser, err := kostHandler.kost.GetCurrentUser(r, kostHandler.store)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	issuedAPIKey, err := kostHandler.kost.IssueAPIKey(currentUser, apiKeyReq)
+	if err != nil {
+		kostHandler.writeError(rw, r, err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	data.ToJSON(issuedAPIKey, rw)
+	return
+}
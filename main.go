@@ -102,8 +102,34 @@ func main() {
 
 	defer sessionStore.Close()
 
+	// the pict variants are served from their own directory, an empty path would serve the working directory with the env file
+	if data.GetPictFileDirPath() == "" {
+		log.Fatal("APP_MK_DIR_PICT_FILE_PATH is not set")
+	}
+
+	// creates the storage for the uploaded ads files
+	adsStorage := data.NewLocalStorage(os.Getenv("APP_MK_DIR_ADS_FILE_PATH"))
+
 	// creates a kost instance
-	kost := data.NewKost(logger)
+	kost := data.NewKost(logger, adsStorage)
+
+	// seed the ads package catalogue with the legacy ads types and link the legacy ads to their package
+	if err := kost.SeedAdsPackages(); err != nil {
+		logger.Error("Unable to seed the ads packages", "error", err.Error())
+	}
+
+	// give the events created before their schedule a window so they stay listed
+	if err := kost.BackfillEventSchedule(); err != nil {
+		logger.Error("Unable to backfill the event schedule", "error", err.Error())
+	}
+
+	// reset the expired kost boosts and sync the revoked auth sessions in the background until the app is shut down
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	go kost.RunBoostExpiryJob(jobCtx, 10*time.Minute)
+	go kost.RunEventScheduleJob(jobCtx, time.Minute)
+	go kost.RunAuthRevocationSyncJob(jobCtx, time.Minute)
 
 	// creates the kost handler
 	kostHandler := handlers.NewKostHandler(logger, kost, sessionStore)
@@ -138,18 +164,106 @@ func main() {
 	getRequestNoMiddleware.HandleFunc("/ads/ig", kostHandler.GetKostInstagramAdsList)
 	getRequestNoMiddleware.HandleFunc("/ads/tiktok", kostHandler.GetKostTiktokAdsList)
 	getRequestNoMiddleware.HandleFunc("/ads/{id:[0-9]+}/files", kostHandler.GetKostAdsFileList)
+	getRequestNoMiddleware.HandleFunc("/ads/{id:[0-9]+}/files/{fileId:[0-9]+}", kostHandler.DownloadKostAdsFile)
+	getRequestNoMiddleware.HandleFunc("/ads/packages", kostHandler.GetAdsPackageList)
+	getRequestNoMiddleware.HandleFunc("/ads/challenge", kostHandler.GetAdsChallenge)
+	getRequestNoMiddleware.HandleFunc("/boost/packages", kostHandler.GetBoostPackageList)
+
+	// get the master data for the clients
+	getRequestNoMiddleware.HandleFunc("/master/{kind:[a-z-]+}", kostHandler.GetMasterDataList)
+
+	// get the generated kost and room pict variants
+	getRequestNoMiddleware.PathPrefix(data.PictFileURLPrefix + "/").Handler(
+		http.StripPrefix(data.PictFileURLPrefix+"/", handlers.NewPictFileServer(data.GetPictFileDirPath())),
+	)
 
 	// get kost handlers
 	getRequest.HandleFunc("/all/{category:[0-9]+}/{page:[0-9]+}", Adapt(
 		http.HandlerFunc(kostHandler.GetKostList),
+		kostHandler.RateLimit(data.RateLimitKostList),
 		kostHandler.MiddlewareParseUserRequest,
 	).ServeHTTP)
 	//TODO: ganti jadi all/h/{category:[0-9]+}
 	getRequest.HandleFunc("/all/near", Adapt(
 		http.HandlerFunc(kostHandler.GetNearYouList),
+		kostHandler.RateLimit(data.RateLimitNearby),
 		kostHandler.MiddlewareParseUserRequest,
 	).ServeHTTP)
 	getRequest.HandleFunc("/event/all", kostHandler.GetEventList)
+	getRequest.HandleFunc("/event/{id:[0-9]+}/kosts/{page:[0-9]+}", kostHandler.GetEventKostList)
+	getRequest.HandleFunc("/voucher/all", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetVoucherList),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+	).ServeHTTP)
+	getRequest.HandleFunc("/master/{kind:[a-z-]+}/all", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetMasterDataList),
+		kostHandler.RequirePermission(data.PermManageMaster),
+	).ServeHTTP)
+	getRequest.HandleFunc("/{id:[0-9]+}/draft/completeness", Adapt(
+		http.HandlerFunc(kostHandler.GetKostCompleteness),
+		kostHandler.RequirePermission(data.PermManageKost),
+		kostHandler.MiddlewareParseKostGetRequest,
+	).ServeHTTP)
+	getRequest.HandleFunc("/export/{dataset:kosts|rooms|bookings}", Adapt(
+		http.HandlerFunc(kostHandler.ExportKostData),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+	getRequest.HandleFunc("/import/template.csv", Adapt(
+		http.HandlerFunc(kostHandler.GetKostImportTemplate),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+	getRequest.HandleFunc("/apikey/all", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAPIKeyList),
+		kostHandler.RequirePermission(data.PermManageAPIKey),
+	).ServeHTTP)
+	getRequest.HandleFunc("/boost/requests", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetKostBoostList),
+		kostHandler.RequirePermission(data.PermBoostKost),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/calendar", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsCalendar),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/packages/all", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsPackageList),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/ig/all", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetKostInstagramAdsList),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/tiktok/all", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetKostTiktokAdsList),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/{id:[0-9]+}/files/review", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetKostAdsFileList),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/{id:[0-9]+}/files/{fileId:[0-9]+}/review", Adapt(
+		http.HandlerFunc(kostHandler.AdminDownloadKostAdsFile),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/{id:[0-9]+}/caption", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsCaption),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/{id:[0-9]+}/caption/export", Adapt(
+		http.HandlerFunc(kostHandler.AdminExportAdsCaption),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/{id:[0-9]+}/bundle.zip", Adapt(
+		http.HandlerFunc(kostHandler.AdminDownloadAdsBundle),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/{id:[0-9]+}/metrics", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsMetricReport),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+	getRequest.HandleFunc("/ads/packages/{id:[0-9]+}/metrics", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsPackageMetricReport),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
 
 	// get global middleware
 	getRequest.Use(kostHandler.MiddlewareValidateAuth)
@@ -158,11 +272,101 @@ func main() {
 	// post handlers
 	postRequest := serveMux.Methods(http.MethodPost).Subrouter()
 	postRequestWithoutAuth := serveMux.Methods(http.MethodPost).Subrouter()
+	postUploadRequest := serveMux.Methods(http.MethodPost).Subrouter()
+	postAdsRequest := serveMux.Methods(http.MethodPost).Subrouter()
+	postKostRequest := serveMux.Methods(http.MethodPost).Subrouter()
+	postBoostRequest := serveMux.Methods(http.MethodPost).Subrouter()
+	postImportRequest := serveMux.Methods(http.MethodPost).Subrouter()
 
 	// post add new kost
-	postRequest.HandleFunc("/add", kostHandler.AddKost)
+	postRequest.HandleFunc("/add", Adapt(
+		http.HandlerFunc(kostHandler.AddKost),
+		kostHandler.RequirePermission(data.PermManageKost),
+		kostHandler.MiddlewareValidateKostRequest,
+	).ServeHTTP)
 	postRequestWithoutAuth.HandleFunc("/add/ads", kostHandler.AddKostAds)
 
+	// post new kost draft and submit it once it is complete
+	postRequest.HandleFunc("/draft", Adapt(
+		http.HandlerFunc(kostHandler.AddKostDraft),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+	postKostRequest.HandleFunc("/{id:[0-9]+}/draft/submit", Adapt(
+		http.HandlerFunc(kostHandler.SubmitKostDraft),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+
+	// post bulk kost import from the csv or xlsx template by the owner
+	postImportRequest.HandleFunc("/import", Adapt(
+		http.HandlerFunc(kostHandler.ImportKosts),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+
+	// post kost boost request by the owner, it is applied once the admin confirms the payment
+	postKostRequest.HandleFunc("/{id:[0-9]+}/boost", Adapt(
+		http.HandlerFunc(kostHandler.RequestKostBoost),
+		kostHandler.RequirePermission(data.PermRequestBoost),
+		kostHandler.MiddlewareParseKostBoostRequest,
+	).ServeHTTP)
+
+	// post kost boost grant by the admin, e.g. as a promo
+	postKostRequest.HandleFunc("/{id:[0-9]+}/boost/grant", Adapt(
+		http.HandlerFunc(kostHandler.AdminGrantKostBoost),
+		kostHandler.RequirePermission(data.PermBoostKost),
+		kostHandler.MiddlewareParseKostBoostRequest,
+	).ServeHTTP)
+
+	// post room booking and its invoice preview by the booker
+	postKostRequest.HandleFunc("/{id:[0-9]+}/rooms/{roomId:[0-9]+}/book", Adapt(
+		http.HandlerFunc(kostHandler.AddKostRoomBook),
+		kostHandler.RateLimit(data.RateLimitRoomBook),
+		kostHandler.RequirePermission(data.PermBookRoom),
+		kostHandler.MiddlewareParseRoomBookRequest,
+	).ServeHTTP)
+	postKostRequest.HandleFunc("/{id:[0-9]+}/rooms/{roomId:[0-9]+}/book/quote", Adapt(
+		http.HandlerFunc(kostHandler.QuoteKostRoomBook),
+		kostHandler.RateLimit(data.RateLimitRoomBook),
+		kostHandler.RequirePermission(data.PermBookRoom),
+		kostHandler.MiddlewareParseRoomBookRequest,
+	).ServeHTTP)
+
+	// post new boost package to the catalogue by the admin
+	postBoostRequest.HandleFunc("/boost/packages", Adapt(
+		http.HandlerFunc(kostHandler.AdminAddBoostPackage),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+		kostHandler.MiddlewareParseBoostPackageRequest,
+	).ServeHTTP)
+
+	// post upload kost and room picts
+	postUploadRequest.HandleFunc("/{id:[0-9]+}/picts", Adapt(
+		http.HandlerFunc(kostHandler.AddKostPict),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+	postUploadRequest.HandleFunc("/{id:[0-9]+}/rooms/{roomId:[0-9]+}/picts", Adapt(
+		http.HandlerFunc(kostHandler.AddKostRoomPict),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+
+	// post ads posting slot allocation by the admin
+	postAdsRequest.HandleFunc("/ads/{id:[0-9]+}/schedule", Adapt(
+		http.HandlerFunc(kostHandler.AdminScheduleAds),
+		kostHandler.RequirePermission(data.PermManageAds),
+		kostHandler.MiddlewareParseAdsScheduleRequest,
+	).ServeHTTP)
+
+	// post ads performance metrics csv import by the admin
+	postAdsRequest.HandleFunc("/ads/metrics/import", Adapt(
+		http.HandlerFunc(kostHandler.AdminImportAdsMetrics),
+		kostHandler.RequirePermission(data.PermManageAds),
+	).ServeHTTP)
+
+	// post new ads package to the catalogue by the admin
+	postAdsRequest.HandleFunc("/ads/packages", Adapt(
+		http.HandlerFunc(kostHandler.AdminAddAdsPackage),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+		kostHandler.MiddlewareParseAdsPackageRequest,
+	).ServeHTTP)
+
 	// post global middleware
 	postRequest.Use(
 		kostHandler.MiddlewareValidateAuth,
@@ -170,11 +374,382 @@ func main() {
 	)
 
 	postRequestWithoutAuth.Use(
+		kostHandler.MiddlewareProtectAdsSubmission,
 		kostHandler.MiddlewareParseKostAdsPostRequest,
+		kostHandler.MiddlewareValidateAdsSubmission,
+	)
+
+	postUploadRequest.Use(
+		kostHandler.MiddlewareValidateAuth,
+		kostHandler.MiddlewareParseKostGetRequest,
 	)
 
+	postAdsRequest.Use(kostHandler.MiddlewareValidateAuth)
+	postImportRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	postKostRequest.Use(
+		kostHandler.MiddlewareValidateAuth,
+		kostHandler.MiddlewareParseKostGetRequest,
+	)
+
+	postBoostRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// post event and voucher handlers
+	postEventRequest := serveMux.Methods(http.MethodPost).Subrouter()
+
+	// post new event by the admin
+	postEventRequest.HandleFunc("/event", Adapt(
+		http.HandlerFunc(kostHandler.AdminAddEvent),
+		kostHandler.RequirePermission(data.PermManageEvent),
+		kostHandler.MiddlewareParseEventRequest,
+	).ServeHTTP)
+
+	// post event thumbnail upload by the admin
+	postEventRequest.HandleFunc("/event/{id:[0-9]+}/thumbnail", Adapt(
+		http.HandlerFunc(kostHandler.AdminUploadEventThumbnail),
+		kostHandler.RequirePermission(data.PermManageEvent),
+	).ServeHTTP)
+
+	// post kost enrollment to the event by the admin or the kost owner
+	postEventRequest.HandleFunc("/event/{id:[0-9]+}/kosts", Adapt(
+		http.HandlerFunc(kostHandler.EnrollEventKost),
+		kostHandler.RequirePermission(data.PermEnrollEvent),
+		kostHandler.MiddlewareParseEventEnrollmentRequest,
+	).ServeHTTP)
+
+	// post new voucher by the admin
+	postEventRequest.HandleFunc("/voucher", Adapt(
+		http.HandlerFunc(kostHandler.AdminAddVoucher),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+		kostHandler.MiddlewareParseVoucherRequest,
+	).ServeHTTP)
+
+	// post event and voucher global middleware
+	postEventRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// post access token renewal with the refresh token, it has no auth as the access token may be expired already
+	postRefreshRequest := serveMux.Methods(http.MethodPost).Subrouter()
+	postRefreshRequest.HandleFunc("/auth/refresh", Adapt(
+		http.HandlerFunc(kostHandler.RefreshToken),
+		kostHandler.RateLimit(data.RateLimitAuth),
+	).ServeHTTP)
+
+	// post auth session handlers
+	postAuthRequest := serveMux.Methods(http.MethodPost).Subrouter()
+	postAuthRequest.HandleFunc("/auth/token", Adapt(
+		http.HandlerFunc(kostHandler.ExchangeToken),
+		kostHandler.RateLimit(data.RateLimitAuth),
+	).ServeHTTP)
+	postAuthRequest.HandleFunc("/auth/logout", kostHandler.Logout)
+
+	// post auth session global middleware
+	postAuthRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// post api key handlers
+	postAPIKeyRequest := serveMux.Methods(http.MethodPost).Subrouter()
+
+	// post new api key for an internal consumer by the admin
+	postAPIKeyRequest.HandleFunc("/apikey", Adapt(
+		http.HandlerFunc(kostHandler.AdminIssueAPIKey),
+		kostHandler.RequirePermission(data.PermManageAPIKey),
+		kostHandler.MiddlewareParseAPIKeyRequest,
+	).ServeHTTP)
+
+	// post api key global middleware
+	postAPIKeyRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// post master data handlers
+	postMasterRequest := serveMux.Methods(http.MethodPost).Subrouter()
+
+	// post new master data by the admin
+	postMasterRequest.HandleFunc("/master/{kind:[a-z-]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminAddMasterData),
+		kostHandler.RequirePermission(data.PermManageMaster),
+		kostHandler.MiddlewareParseMasterDataRequest,
+	).ServeHTTP)
+
+	// post master data global middleware
+	postMasterRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// patch handlers
+	patchRequest := serveMux.Methods(http.MethodPatch).Subrouter()
+
+	// patch kost and room pict gallery
+	patchRequest.HandleFunc("/{id:[0-9]+}/picts/order", Adapt(
+		http.HandlerFunc(kostHandler.ReorderKostPicts),
+		kostHandler.RequirePermission(data.PermManageKost),
+		kostHandler.MiddlewareParsePictOrderRequest,
+	).ServeHTTP)
+	patchRequest.HandleFunc("/{id:[0-9]+}/picts/{pictId:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.UpdateKostPictCaption),
+		kostHandler.RequirePermission(data.PermManageKost),
+		kostHandler.MiddlewareParsePictCaptionRequest,
+	).ServeHTTP)
+	patchRequest.HandleFunc("/{id:[0-9]+}/rooms/{roomId:[0-9]+}/picts/order", Adapt(
+		http.HandlerFunc(kostHandler.ReorderKostRoomPicts),
+		kostHandler.RequirePermission(data.PermManageKost),
+		kostHandler.MiddlewareParsePictOrderRequest,
+	).ServeHTTP)
+	patchRequest.HandleFunc("/{id:[0-9]+}/rooms/{roomId:[0-9]+}/picts/{pictId:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.UpdateKostRoomPictCaption),
+		kostHandler.RequirePermission(data.PermManageKost),
+		kostHandler.MiddlewareParsePictCaptionRequest,
+	).ServeHTTP)
+
+	// patch global middleware
+	patchRequest.Use(
+		kostHandler.MiddlewareValidateAuth,
+		kostHandler.MiddlewareParseKostGetRequest,
+	)
+
+	// patch kost draft handlers
+	patchDraftRequest := serveMux.Methods(http.MethodPatch).Subrouter()
+
+	// patch a section of the kost draft by the owner
+	patchDraftRequest.HandleFunc("/{id:[0-9]+}/draft/{section:[a-z]+}", Adapt(
+		http.HandlerFunc(kostHandler.UpdateKostDraftSection),
+		kostHandler.RequirePermission(data.PermManageKost),
+		kostHandler.MiddlewareParseKostDraftRequest,
+	).ServeHTTP)
+
+	// patch kost draft global middleware
+	patchDraftRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// patch ads handlers
+	patchAdsRequest := serveMux.Methods(http.MethodPatch).Subrouter()
+
+	// patch ads moderation status by the admin
+	patchAdsRequest.HandleFunc("/ads/{id:[0-9]+}/status", Adapt(
+		http.HandlerFunc(kostHandler.AdminTransitionAdsStatus),
+		kostHandler.RequirePermission(data.PermManageAds),
+		kostHandler.MiddlewareParseAdsStatusRequest,
+	).ServeHTTP)
+
+	// patch ads package of the catalogue by the admin
+	patchAdsRequest.HandleFunc("/ads/packages/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminUpdateAdsPackage),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+		kostHandler.MiddlewareParseAdsPackageRequest,
+	).ServeHTTP)
+
+	// patch ads global middleware
+	patchAdsRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// patch boost handlers
+	patchBoostRequest := serveMux.Methods(http.MethodPatch).Subrouter()
+
+	// patch boost package of the catalogue by the admin
+	patchBoostRequest.HandleFunc("/boost/packages/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminUpdateBoostPackage),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+		kostHandler.MiddlewareParseBoostPackageRequest,
+	).ServeHTTP)
+
+	// patch pending kost boost confirmation and rejection by the admin
+	patchBoostRequest.HandleFunc("/boost/requests/{id:[0-9]+}/confirm", Adapt(
+		http.HandlerFunc(kostHandler.AdminConfirmKostBoost),
+		kostHandler.RequirePermission(data.PermBoostKost),
+	).ServeHTTP)
+	patchBoostRequest.HandleFunc("/boost/requests/{id:[0-9]+}/reject", Adapt(
+		http.HandlerFunc(kostHandler.AdminRejectKostBoost),
+		kostHandler.RequirePermission(data.PermBoostKost),
+	).ServeHTTP)
+
+	// patch boost global middleware
+	patchBoostRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// patch event and voucher handlers
+	patchEventRequest := serveMux.Methods(http.MethodPatch).Subrouter()
+
+	// patch event by the admin
+	patchEventRequest.HandleFunc("/event/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminUpdateEvent),
+		kostHandler.RequirePermission(data.PermManageEvent),
+		kostHandler.MiddlewareParseEventRequest,
+	).ServeHTTP)
+
+	// patch voucher by the admin
+	patchEventRequest.HandleFunc("/voucher/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminUpdateVoucher),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+		kostHandler.MiddlewareParseVoucherRequest,
+	).ServeHTTP)
+
+	// patch event and voucher global middleware
+	patchEventRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// patch master data handlers
+	patchMasterRequest := serveMux.Methods(http.MethodPatch).Subrouter()
+
+	// patch master data by the admin
+	patchMasterRequest.HandleFunc("/master/{kind:[a-z-]+}/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminUpdateMasterData),
+		kostHandler.RequirePermission(data.PermManageMaster),
+		kostHandler.MiddlewareParseMasterDataRequest,
+	).ServeHTTP)
+
+	// patch master data global middleware
+	patchMasterRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// patch kost approval handlers
+	patchApprovalRequest := serveMux.Methods(http.MethodPatch).Subrouter()
+
+	// patch new kost approval by the admin
+	patchApprovalRequest.HandleFunc("/approval", Adapt(
+		http.HandlerFunc(kostHandler.AdminApprovalKost),
+		kostHandler.RequirePermission(data.PermApproveKost),
+		kostHandler.MiddlewareParseApprovalRequest,
+	).ServeHTTP)
+
+	// patch kost approval global middleware
+	patchApprovalRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// delete handlers
+	deleteRequest := serveMux.Methods(http.MethodDelete).Subrouter()
+
+	// delete kost and room pict from the gallery
+	deleteRequest.HandleFunc("/{id:[0-9]+}/picts/{pictId:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.RemoveKostPict),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+	deleteRequest.HandleFunc("/{id:[0-9]+}/rooms/{roomId:[0-9]+}/picts/{pictId:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.RemoveKostRoomPict),
+		kostHandler.RequirePermission(data.PermManageKost),
+	).ServeHTTP)
+
+	// delete global middleware
+	deleteRequest.Use(
+		kostHandler.MiddlewareValidateAuth,
+		kostHandler.MiddlewareParseKostGetRequest,
+	)
+
+	// delete ads handlers
+	deleteAdsRequest := serveMux.Methods(http.MethodDelete).Subrouter()
+
+	// delete (deactivate) ads package of the catalogue by the admin
+	deleteAdsRequest.HandleFunc("/ads/packages/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminDeactivateAdsPackage),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+	).ServeHTTP)
+
+	// delete ads global middleware
+	deleteAdsRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// delete event and voucher handlers
+	deleteEventRequest := serveMux.Methods(http.MethodDelete).Subrouter()
+
+	// delete (deactivate) event by the admin
+	deleteEventRequest.HandleFunc("/event/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminDeactivateEvent),
+		kostHandler.RequirePermission(data.PermManageEvent),
+	).ServeHTTP)
+
+	// delete kost from the event by the admin or the kost owner
+	deleteEventRequest.HandleFunc("/event/{id:[0-9]+}/kosts/{kostId:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.WithdrawEventKost),
+		kostHandler.RequirePermission(data.PermEnrollEvent),
+	).ServeHTTP)
+
+	// delete (deactivate) voucher by the admin
+	deleteEventRequest.HandleFunc("/voucher/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminDeactivateVoucher),
+		kostHandler.RequirePermission(data.PermManageCatalogue),
+	).ServeHTTP)
+
+	// delete event and voucher global middleware
+	deleteEventRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// delete master data handlers
+	deleteMasterRequest := serveMux.Methods(http.MethodDelete).Subrouter()
+
+	// delete (deactivate) master data by the admin
+	deleteMasterRequest.HandleFunc("/master/{kind:[a-z-]+}/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminDeactivateMasterData),
+		kostHandler.RequirePermission(data.PermManageMaster),
+	).ServeHTTP)
+
+	// delete master data global middleware
+	deleteMasterRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// delete api key handlers
+	deleteAPIKeyRequest := serveMux.Methods(http.MethodDelete).Subrouter()
+
+	// delete (revoke) api key by the admin
+	deleteAPIKeyRequest.HandleFunc("/apikey/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.AdminRevokeAPIKey),
+		kostHandler.RequirePermission(data.PermManageAPIKey),
+	).ServeHTTP)
+
+	// delete api key global middleware
+	deleteAPIKeyRequest.Use(kostHandler.MiddlewareValidateAuth)
+
+	// internal handlers for the consumers authenticated by an api key instead of a user session
+	internalRequest := serveMux.PathPrefix("/internal").Subrouter()
+
+	// get the ads for the reporting and social media tooling
+	internalRequest.HandleFunc("/ads/calendar", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsCalendar),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadAds),
+	).ServeHTTP).Methods(http.MethodGet)
+	internalRequest.HandleFunc("/ads/{id:[0-9]+}/caption", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsCaption),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadAds),
+	).ServeHTTP).Methods(http.MethodGet)
+	internalRequest.HandleFunc("/ads/{id:[0-9]+}/caption/export", Adapt(
+		http.HandlerFunc(kostHandler.AdminExportAdsCaption),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadAds),
+	).ServeHTTP).Methods(http.MethodGet)
+	internalRequest.HandleFunc("/ads/{id:[0-9]+}/bundle.zip", Adapt(
+		http.HandlerFunc(kostHandler.AdminDownloadAdsBundle),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadAds),
+	).ServeHTTP).Methods(http.MethodGet)
+	internalRequest.HandleFunc("/ads/{id:[0-9]+}/metrics", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsMetricReport),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadAds),
+	).ServeHTTP).Methods(http.MethodGet)
+	internalRequest.HandleFunc("/ads/packages/{id:[0-9]+}/metrics", Adapt(
+		http.HandlerFunc(kostHandler.AdminGetAdsPackageMetricReport),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadAds),
+	).ServeHTTP).Methods(http.MethodGet)
+
+	// get the kosts for the reporting tooling
+	internalRequest.HandleFunc("/kost/all/{category:[0-9]+}/{page:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.GetKostList),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadKost),
+		kostHandler.MiddlewareParseUserRequest,
+	).ServeHTTP).Methods(http.MethodGet)
+	internalRequest.HandleFunc("/kost/{id:[0-9]+}", Adapt(
+		http.HandlerFunc(kostHandler.GetKost),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadKost),
+		kostHandler.MiddlewareParseKostGetRequest,
+	).ServeHTTP).Methods(http.MethodGet)
+	internalRequest.HandleFunc("/kost/{id:[0-9]+}/rooms", Adapt(
+		http.HandlerFunc(kostHandler.GetKostRoomList),
+		kostHandler.RequireAPIKeyScope(data.ScopeReadKost),
+		kostHandler.MiddlewareParseKostGetRequest,
+	).ServeHTTP).Methods(http.MethodGet)
+
+	// post the ads performance metrics csv import from the social media tooling
+	internalRequest.HandleFunc("/ads/metrics/import", Adapt(
+		http.HandlerFunc(kostHandler.AdminImportAdsMetrics),
+		kostHandler.RequireAPIKeyScope(data.ScopeWriteAdsMetrics),
+	).ServeHTTP).Methods(http.MethodPost)
+
+	// internal global middleware
+	internalRequest.Use(
+		kostHandler.MiddlewareValidateAPIKey,
+		kostHandler.RateLimit(data.RateLimitInternal),
+	)
+
+	// limit every request per client ip on top of the route rate limits
+	serveMux.Use(kostHandler.RateLimit(data.RateLimitDefault))
+
 	// CORS
-	corsHandler := gohandlers.CORS(gohandlers.AllowedOrigins([]string{"*"}))
+	corsHandler := gohandlers.CORS(
+		gohandlers.AllowedOrigins([]string{"*"}),
+		gohandlers.AllowedMethods([]string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete}),
+		gohandlers.AllowedHeaders([]string{"Authorization", "Content-Type", "Idempotency-Key", "X-Ads-Challenge", "X-Ads-Nonce", data.APIKeyHeader}),
+	)
 
 	// creates a new server
 	server := http.Server{
@@ -219,6 +794,7 @@ func main() {
 	logger.Info("Got signal", "info", sig)
 
 	// gracefully shutdown the server, waiting max 30 seconds for current operations to complete
-	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 	server.Shutdown(ctx)
 }